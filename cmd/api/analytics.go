@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/Syha-01/national-inservice-training/internal/data"
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+// readAnalyticsFilters reads the from, to and min_responses query parameters.
+// min_responses can raise the configured minimum number of responses but never
+// lower it, so a client cannot ask for slices small enough to identify a rater.
+func (a *application) readAnalyticsFilters(r *http.Request, v *validator.Validator) data.AnalyticsFilters {
+	qs := r.URL.Query()
+
+	filters := data.AnalyticsFilters{
+		From:         a.readDate(qs, "from", v),
+		To:           a.readDate(qs, "to", v),
+		MinResponses: a.readInt(qs, "min_responses", a.config.feedback.minResponses, v),
+	}
+
+	data.ValidateAnalyticsFilters(v, filters)
+	filters.MinResponses = max(filters.MinResponses, a.config.feedback.minResponses)
+
+	return filters
+}
+
+// showFacilitatorAnalyticsHandler summarises the ratings a facilitator has received
+func (a *application) showFacilitatorAnalyticsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+
+	filters := a.readAnalyticsFilters(r, v)
+	period := a.readString(r.URL.Query(), "period", "month")
+	v.Check(slices.Contains(data.AnalyticsPeriods, period), "period", "must be either 'month' or 'quarter'")
+
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	_, err = a.models.Facilitators.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	analytics, err := a.models.Feedback.GetFacilitatorAnalytics(id, period, filters)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"analytics": analytics}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// showFacilitatorLeaderboardHandler ranks facilitators by their average rating
+func (a *application) showFacilitatorLeaderboardHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		data.Filters
+		data.AnalyticsFilters
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	input.Filters.Page = a.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = a.readInt(qs, "page_size", 20, v)
+	input.AnalyticsFilters = a.readAnalyticsFilters(r, v)
+
+	if data.ValidateFilters(v, input.Filters); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	leaderboard, metadata, err := a.models.Feedback.GetFacilitatorLeaderboard(input.AnalyticsFilters, input.Filters)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"leaderboard": leaderboard, "metadata": metadata}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
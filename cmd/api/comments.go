@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Syha-01/national-inservice-training/internal/data"
+	"github.com/Syha-01/national-inservice-training/internal/textanalysis"
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+// commentAnalysisInput is the query string accepted by the comment analysis endpoints.
+type commentAnalysisInput struct {
+	SessionID   int64
+	MinComments int
+	Limit       int
+}
+
+// readCommentAnalysisInput reads the session to narrow the analysis to, how
+// many comments a keyword must appear in and how many keywords to return,
+// sending a failed validation response if any are invalid
+func (a *application) readCommentAnalysisInput(w http.ResponseWriter, r *http.Request) (commentAnalysisInput, bool) {
+	v := validator.New()
+	qs := r.URL.Query()
+
+	sessionID := a.readInt(qs, "session_id", 0, v)
+	input := commentAnalysisInput{
+		SessionID:   int64(sessionID),
+		MinComments: a.readInt(qs, "min_comments", 2, v),
+		Limit:       a.readInt(qs, "limit", 20, v),
+	}
+
+	v.Check(sessionID >= 0, "session_id", "must not be negative")
+	v.Check(input.MinComments >= 1, "min_comments", "must be greater than zero")
+	v.Check(input.Limit >= 1, "limit", "must be greater than zero")
+	v.Check(input.Limit <= 100, "limit", "must be a maximum of 100")
+
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return input, false
+	}
+
+	return input, true
+}
+
+// writeCommentAnalysis analyses the comments and writes the result. Nothing but
+// the number of comments is reported until there are at least as many as the
+// minimum number of feedback responses, so no comment can be singled out.
+func (a *application) writeCommentAnalysis(w http.ResponseWriter, r *http.Request, comments []string, input commentAnalysisInput) {
+	reportable := len(comments) >= a.config.feedback.minResponses
+
+	analysis := textanalysis.Analysis{Comments: len(comments), Keywords: []textanalysis.Keyword{}}
+	if reportable {
+		analysis = textanalysis.Analyse(comments, input.MinComments, input.Limit)
+	}
+
+	err := a.writeJSON(w, http.StatusOK, envelope{"analysis": analysis, "reportable": reportable}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// showFacilitatorCommentAnalysisHandler reports the sentiment and recurring
+// themes of the comments left for a facilitator
+func (a *application) showFacilitatorCommentAnalysisHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	input, ok := a.readCommentAnalysisInput(w, r)
+	if !ok {
+		return
+	}
+
+	_, err = a.models.Facilitators.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	comments, err := a.models.Feedback.GetCommentsForFacilitator(id, input.SessionID)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	a.writeCommentAnalysis(w, r, comments, input)
+}
+
+// showCourseCommentAnalysisHandler reports the sentiment and recurring themes
+// of the comments left for a course
+func (a *application) showCourseCommentAnalysisHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	input, ok := a.readCommentAnalysisInput(w, r)
+	if !ok {
+		return
+	}
+
+	course, err := a.models.Courses.GetCourse(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	if course == nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	comments, err := a.models.Feedback.GetCommentsForCourse(id, input.SessionID)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	a.writeCommentAnalysis(w, r, comments, input)
+}
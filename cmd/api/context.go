@@ -12,6 +12,8 @@ type contextKey string
 
 const userContextKey = contextKey("user")
 
+const sessionContextKey = contextKey("session")
+
 // contextSetUser adds the user to the request context
 func (a *application) contextSetUser(r *http.Request, user *data.User) *http.Request {
 	ctx := context.WithValue(r.Context(), userContextKey, user)
@@ -25,4 +27,17 @@ func (a *application) contextGetUser(r *http.Request) *data.User {
 		panic("missing user value in request context")
 	}
 	return user
-}
\ No newline at end of file
+}
+
+// contextSetSession adds the authentication token the request was made with to the request context
+func (a *application) contextSetSession(r *http.Request, session *data.Session) *http.Request {
+	ctx := context.WithValue(r.Context(), sessionContextKey, session)
+	return r.WithContext(ctx)
+}
+
+// contextGetSession retrieves the authentication token the request was made
+// with, or nil for an anonymous request
+func (a *application) contextGetSession(r *http.Request) *data.Session {
+	session, _ := r.Context().Value(sessionContextKey).(*data.Session)
+	return session
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Syha-01/national-inservice-training/internal/data"
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+// listOfficerDuplicatesHandler returns pairs of officers who are likely to be the same person
+func (a *application) listOfficerDuplicatesHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		data.Filters
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	input.Filters.Page = a.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = a.readInt(qs, "page_size", 20, v)
+
+	data.ValidateFilters(v, input.Filters)
+
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	candidates, metadata, err := a.models.Officers.GetDuplicateCandidates(input.Filters)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"duplicates": candidates, "metadata": metadata}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// mergeOfficerHandler folds a duplicate officer record into the officer identified in the URL
+func (a *application) mergeOfficerHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		DuplicateID int64 `json:"duplicate_id"`
+	}
+
+	err = a.readJSON(w, r, &input)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(input.DuplicateID > 0, "duplicate_id", "must be provided and be a positive integer")
+	v.Check(input.DuplicateID != id, "duplicate_id", "must be a different officer from the one being kept")
+
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	summary, err := a.models.Officers.Merge(id, input.DuplicateID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrMergeConflict):
+			message := "both officers are linked to user accounts, unlink one of the accounts before merging"
+			a.errorResponseJSON(w, r, http.StatusConflict, message)
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	officer, err := a.models.Officers.GetOfficer(id)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"officer": officer, "merge": summary}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+
+	"github.com/Syha-01/national-inservice-training/internal/data"
 )
 
 // log an error message
@@ -85,6 +88,11 @@ func (a *application) invalidAuthenticationTokenResponse(w http.ResponseWriter,
 	a.errorResponseJSON(w, r, http.StatusUnauthorized, message)
 }
 
+func (a *application) invalidRefreshTokenResponse(w http.ResponseWriter, r *http.Request) {
+	message := "invalid, expired or revoked refresh token"
+	a.errorResponseJSON(w, r, http.StatusUnauthorized, message)
+}
+
 func (a *application) authenticationRequiredResponse(w http.ResponseWriter, r *http.Request) {
 	message := "you must be authenticated to access this resource"
 	a.errorResponseJSON(w, r, http.StatusUnauthorized, message)
@@ -98,4 +106,67 @@ func (a *application) inactiveAccountResponse(w http.ResponseWriter, r *http.Req
 func (a *application) notPermittedResponse(w http.ResponseWriter, r *http.Request) {
 	message := "your user account doesn't have the necessary permissions to access this resource"
 	a.errorResponseJSON(w, r, http.StatusForbidden, message)
-}
\ No newline at end of file
+}
+
+func (a *application) noLinkedOfficerResponse(w http.ResponseWriter, r *http.Request) {
+	message := "your user account must be linked to an officer record to access this resource"
+	a.errorResponseJSON(w, r, http.StatusForbidden, message)
+}
+
+func (a *application) notEnrollmentOwnerResponse(w http.ResponseWriter, r *http.Request) {
+	message := "you can only give feedback on your own enrollments"
+	a.errorResponseJSON(w, r, http.StatusForbidden, message)
+}
+
+func (a *application) noLinkedFacilitatorResponse(w http.ResponseWriter, r *http.Request) {
+	message := "your user account must be linked to a facilitator record to access this resource"
+	a.errorResponseJSON(w, r, http.StatusForbidden, message)
+}
+
+func (a *application) conflictResponse(w http.ResponseWriter, r *http.Request, errors map[string]string) {
+	a.errorResponseJSON(w, r, http.StatusConflict, errors)
+}
+
+// send a field-level error response when a write is rejected by a database constraint:
+// 409 when the value clashes with an existing record, 422 when the value itself is unacceptable
+func (a *application) constraintViolationResponse(w http.ResponseWriter, r *http.Request, err error) {
+	var constraintErr *data.ConstraintError
+	if !errors.As(err, &constraintErr) {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	field := constraintErr.Column
+	if field == "" {
+		field = constraintErr.Constraint
+	}
+
+	switch {
+	case errors.Is(err, data.ErrDuplicateRecord):
+		a.conflictResponse(w, r, map[string]string{field: "a record with this value already exists"})
+	case errors.Is(err, data.ErrRecordInUse):
+		a.conflictResponse(w, r, map[string]string{constraintErr.Table: "the record is still referenced by other records"})
+	case errors.Is(err, data.ErrInvalidReference):
+		a.failedValidationResponse(w, r, map[string]string{field: "references a record that does not exist"})
+	case errors.Is(err, data.ErrCheckViolation):
+		a.failedValidationResponse(w, r, map[string]string{field: "is not an accepted value"})
+	default:
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// send a 409 listing why a facilitator cannot be assigned to a session
+func (a *application) facilitatorUnavailableResponse(w http.ResponseWriter, r *http.Request, problems []string) {
+	message := envelope{
+		"facilitator_id": "the facilitator is not available for this session, set force to assign them anyway",
+		"problems":       problems,
+	}
+	a.errorResponseJSON(w, r, http.StatusConflict, message)
+}
+
+func (a *application) unqualifiedFacilitatorResponse(w http.ResponseWriter, r *http.Request) {
+	message := map[string]string{
+		"facilitator_id": "the facilitator does not hold a valid qualification for this course, an administrator must give an override_reason to assign them",
+	}
+	a.errorResponseJSON(w, r, http.StatusUnprocessableEntity, message)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Syha-01/national-inservice-training/internal/data"
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+// showCourseEvaluationHandler summarises the course ratings of a course
+// overall, by location and by session, so its deliveries can be compared
+func (a *application) showCourseEvaluationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	filters := data.EvaluationFilters{
+		From:      a.readDate(qs, "from", v),
+		To:        a.readDate(qs, "to", v),
+		Locations: a.readCSV(qs, "location", []string{}),
+	}
+
+	if data.ValidateEvaluationFilters(v, filters); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	course, err := a.models.Courses.GetCourse(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	if course == nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	evaluation, err := a.models.Feedback.GetCourseEvaluation(id, filters, a.config.feedback.minResponses)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"evaluation": evaluation}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// showSessionEvaluationHandler summarises the course ratings of a single session
+func (a *application) showSessionEvaluationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	evaluation, err := a.models.Feedback.GetSessionEvaluation(id, a.config.feedback.minResponses)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"evaluation": evaluation}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
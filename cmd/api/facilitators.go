@@ -11,10 +11,11 @@ import (
 
 func (a *application) createFacilitatorHandler(w http.ResponseWriter, r *http.Request) {
 	var input struct {
-		FirstName   string `json:"first_name"`
-		LastName    string `json:"last_name"`
-		Email       string `json:"email"`
-		PersonnelID *int64 `json:"personnel_id"`
+		FirstName           string `json:"first_name"`
+		LastName            string `json:"last_name"`
+		Email               string `json:"email"`
+		PersonnelID         *int64 `json:"personnel_id"`
+		MaxSessionsPerMonth *int32 `json:"max_sessions_per_month"`
 	}
 
 	err := a.readJSON(w, r, &input)
@@ -24,14 +25,21 @@ func (a *application) createFacilitatorHandler(w http.ResponseWriter, r *http.Re
 	}
 
 	facilitator := &data.Facilitator{
-		FirstName: input.FirstName,
-		LastName:  input.LastName,
-		Email:     input.Email,
+		FirstName:           input.FirstName,
+		LastName:            input.LastName,
+		Email:               input.Email,
+		MaxSessionsPerMonth: data.DefaultMaxSessionsPerMonth,
 	}
 
+	if input.MaxSessionsPerMonth != nil {
+		facilitator.MaxSessionsPerMonth = *input.MaxSessionsPerMonth
+	}
+
+	v := validator.New()
+
 	if input.PersonnelID != nil {
-		v := validator.New()
-		if _, err := a.models.Officers.GetOfficer(*input.PersonnelID); err != nil {
+		officer, err := a.models.Officers.GetOfficer(*input.PersonnelID)
+		if err != nil {
 			v.AddError("personnel_id", "personnel_id does not exist")
 			a.failedValidationResponse(w, r, v.Errors)
 			return
@@ -43,13 +51,120 @@ func (a *application) createFacilitatorHandler(w http.ResponseWriter, r *http.Re
 			return
 		}
 
+		// A facilitator linked to an officer always goes by the officer's name
+		data.ValidateOfficerFacilitatorName(v, officer, input.FirstName, input.LastName)
+		facilitator.FirstName = officer.FirstName
+		facilitator.LastName = officer.LastName
+
 		facilitator.PersonnelID.Int64 = *input.PersonnelID
 		facilitator.PersonnelID.Valid = true
 	}
 
+	if data.ValidateFacilitator(v, facilitator); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
 	err = a.models.Facilitators.Create(facilitator)
+	if err != nil {
+		switch {
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// External facilitators are invited to set up an account straight away. The
+	// facilitator has been created either way, so a failed invitation is only
+	// logged and can be sent again through the invitation endpoint.
+	var invitation *data.Invitation
+	if !facilitator.PersonnelID.Valid && facilitator.Email != "" {
+		invitation, err = a.inviteFacilitator(r, facilitator)
+		if err != nil {
+			a.logError(r, err)
+		}
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/facilitators/%d", facilitator.ID))
+
+	err = a.writeJSON(w, http.StatusCreated, envelope{"facilitator": facilitator, "invitation": invitation}, headers)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// createFacilitatorFromOfficerHandler promotes an existing officer to a facilitator
+func (a *application) createFacilitatorFromOfficerHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Email               string `json:"email"`
+		MaxSessionsPerMonth *int32 `json:"max_sessions_per_month"`
+	}
+
+	err = a.readJSON(w, r, &input)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	officer, err := a.models.Officers.GetOfficer(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	_, err = a.models.Facilitators.GetByPersonnelID(id)
+	switch {
+	case err == nil:
+		a.conflictResponse(w, r, map[string]string{"personnel_id": "the officer is already a facilitator"})
+		return
+	case !errors.Is(err, data.ErrRecordNotFound):
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	facilitator := &data.Facilitator{
+		FirstName:           officer.FirstName,
+		LastName:            officer.LastName,
+		Email:               input.Email,
+		PersonnelID:         data.NullInt64{Int64: officer.ID, Valid: true},
+		MaxSessionsPerMonth: data.DefaultMaxSessionsPerMonth,
+	}
+
+	if input.MaxSessionsPerMonth != nil {
+		facilitator.MaxSessionsPerMonth = *input.MaxSessionsPerMonth
+	}
+
+	v := validator.New()
+
+	if data.ValidateFacilitator(v, facilitator); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = a.models.Facilitators.CreateFromOfficer(facilitator)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
@@ -65,6 +180,7 @@ func (a *application) createFacilitatorHandler(w http.ResponseWriter, r *http.Re
 func (a *application) listFacilitatorsHandler(w http.ResponseWriter, r *http.Request) {
 	var input struct {
 		data.Filters
+		Expand data.Expand
 	}
 
 	v := validator.New()
@@ -72,15 +188,17 @@ func (a *application) listFacilitatorsHandler(w http.ResponseWriter, r *http.Req
 
 	input.Filters.Page = a.readInt(qs, "page", 1, v)
 	input.Filters.PageSize = a.readInt(qs, "page_size", 20, v)
+	input.Expand = data.NewExpand(a.readCSV(qs, "expand", []string{}))
 
 	data.ValidateFilters(v, input.Filters)
+	data.ValidateExpand(v, input.Expand, data.FacilitatorExpandSafelist)
 
 	if !v.IsEmpty() {
 		a.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	facilitators, metadata, err := a.models.Facilitators.GetAll(input.Filters)
+	facilitators, metadata, err := a.models.Facilitators.GetAll(input.Filters, input.Expand)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 		return
@@ -99,7 +217,15 @@ func (a *application) showFacilitatorHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	facilitator, err := a.models.Facilitators.Get(id)
+	v := validator.New()
+	expand := data.NewExpand(a.readCSV(r.URL.Query(), "expand", []string{}))
+
+	if data.ValidateExpand(v, expand, data.FacilitatorExpandSafelist); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	facilitator, err := a.models.Facilitators.GetExpanded(id, expand)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -140,10 +266,11 @@ func (a *application) updateFacilitatorHandler(w http.ResponseWriter, r *http.Re
 	}
 
 	var input struct {
-		FirstName   *string `json:"first_name"`
-		LastName    *string `json:"last_name"`
-		Email       *string `json:"email"`
-		PersonnelID *int64  `json:"personnel_id"`
+		FirstName           *string `json:"first_name"`
+		LastName            *string `json:"last_name"`
+		Email               *string `json:"email"`
+		PersonnelID         *int64  `json:"personnel_id"`
+		MaxSessionsPerMonth *int32  `json:"max_sessions_per_month"`
 	}
 
 	err = a.readJSON(w, r, &input)
@@ -161,15 +288,42 @@ func (a *application) updateFacilitatorHandler(w http.ResponseWriter, r *http.Re
 	if input.Email != nil {
 		facilitator.Email = *input.Email
 	}
+	if input.MaxSessionsPerMonth != nil {
+		facilitator.MaxSessionsPerMonth = *input.MaxSessionsPerMonth
+	}
+
+	v := validator.New()
+
 	if input.PersonnelID != nil {
-		v := validator.New()
-		if _, err := a.models.Officers.GetOfficer(*input.PersonnelID); err != nil {
+		facilitator.PersonnelID.Int64 = *input.PersonnelID
+		facilitator.PersonnelID.Valid = true
+	}
+
+	// A facilitator linked to an officer always goes by the officer's name
+	if facilitator.PersonnelID.Valid {
+		officer, err := a.models.Officers.GetOfficer(facilitator.PersonnelID.Int64)
+		if err != nil {
 			v.AddError("personnel_id", "personnel_id does not exist")
 			a.failedValidationResponse(w, r, v.Errors)
 			return
 		}
-		facilitator.PersonnelID.Int64 = *input.PersonnelID
-		facilitator.PersonnelID.Valid = true
+
+		var firstName, lastName string
+		if input.FirstName != nil {
+			firstName = *input.FirstName
+		}
+		if input.LastName != nil {
+			lastName = *input.LastName
+		}
+
+		data.ValidateOfficerFacilitatorName(v, officer, firstName, lastName)
+		facilitator.FirstName = officer.FirstName
+		facilitator.LastName = officer.LastName
+	}
+
+	if data.ValidateFacilitator(v, facilitator); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
 	}
 
 	err = a.models.Facilitators.Update(facilitator)
@@ -177,6 +331,8 @@ func (a *application) updateFacilitatorHandler(w http.ResponseWriter, r *http.Re
 		switch {
 		case errors.Is(err, data.ErrEditConflict):
 			a.editConflictResponse(w, r)
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
 		default:
 			a.serverErrorResponse(w, r, err)
 		}
@@ -201,6 +357,8 @@ func (a *application) deleteFacilitatorHandler(w http.ResponseWriter, r *http.Re
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
 			a.notFoundResponse(w, r)
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
 		default:
 			a.serverErrorResponse(w, r, err)
 		}
@@ -221,7 +379,9 @@ func (a *application) assignFacilitatorToSessionHandler(w http.ResponseWriter, r
 	}
 
 	var input struct {
-		FacilitatorID int64 `json:"facilitator_id"`
+		FacilitatorID  int64  `json:"facilitator_id"`
+		Force          bool   `json:"force"`
+		OverrideReason string `json:"override_reason"`
 	}
 
 	err = a.readJSON(w, r, &input)
@@ -252,13 +412,72 @@ func (a *application) assignFacilitatorToSessionHandler(w http.ResponseWriter, r
 		return
 	}
 
-	err = a.models.Facilitators.AssignToSession(sessionID, input.FacilitatorID)
+	// Refuse to double book a facilitator or assign them outside their availability
+	// unless the planner has chosen to override it
+	check, err := a.models.Availability.CheckForSession(input.FacilitatorID, sessionID)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 		return
 	}
 
-	err = a.writeJSON(w, http.StatusCreated, envelope{"message": "facilitator assigned to session successfully"}, nil)
+	// Only an administrator may assign a facilitator who is not qualified for
+	// the course, and they must say why so the override can be audited
+	var override *data.AssignmentOverride
+	if !check.Qualified {
+		if input.OverrideReason == "" {
+			a.unqualifiedFacilitatorResponse(w, r)
+			return
+		}
+
+		user := a.contextGetUser(r)
+
+		permissions, err := a.models.Permissions.GetAllForUser(user.ID)
+		if err != nil {
+			a.serverErrorResponse(w, r, err)
+			return
+		}
+		if !permissions.Include("admin:all") {
+			a.notPermittedResponse(w, r)
+			return
+		}
+
+		v := validator.New()
+
+		if data.ValidateOverrideReason(v, input.OverrideReason); !v.IsEmpty() {
+			a.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		override = &data.AssignmentOverride{UserID: user.ID, Reason: input.OverrideReason}
+	}
+
+	if !check.Available && !input.Force {
+		a.facilitatorUnavailableResponse(w, r, check.Problems)
+		return
+	}
+
+	err = a.models.Facilitators.AssignToSession(sessionID, input.FacilitatorID, override)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateRecord):
+			a.conflictResponse(w, r, map[string]string{"facilitator_id": "facilitator is already assigned to this session"})
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	response := envelope{"message": "facilitator assigned to session successfully"}
+	if !check.Available {
+		response["warnings"] = check.Problems
+	}
+	if override != nil {
+		response["override"] = override
+	}
+
+	err = a.writeJSON(w, http.StatusCreated, response, nil)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 	}
@@ -273,6 +492,7 @@ func (a *application) listFacilitatorsForSessionHandler(w http.ResponseWriter, r
 
 	var input struct {
 		data.Filters
+		Expand data.Expand
 	}
 
 	v := validator.New()
@@ -280,15 +500,17 @@ func (a *application) listFacilitatorsForSessionHandler(w http.ResponseWriter, r
 
 	input.Filters.Page = a.readInt(qs, "page", 1, v)
 	input.Filters.PageSize = a.readInt(qs, "page_size", 20, v)
+	input.Expand = data.NewExpand(a.readCSV(qs, "expand", []string{}))
 
 	data.ValidateFilters(v, input.Filters)
+	data.ValidateExpand(v, input.Expand, data.FacilitatorExpandSafelist)
 
 	if !v.IsEmpty() {
 		a.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	facilitators, metadata, err := a.models.Facilitators.GetAllForSession(sessionID, input.Filters)
+	facilitators, metadata, err := a.models.Facilitators.GetAllForSession(sessionID, input.Filters, input.Expand)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 		return
@@ -307,7 +529,7 @@ func (a *application) removeFacilitatorFromSessionHandler(w http.ResponseWriter,
 		return
 	}
 
-	facilitatorID, err := a.readIDParam(r)
+	facilitatorID, err := a.readNamedIDParam(r, "facilitator_id")
 	if err != nil {
 		a.notFoundResponse(w, r)
 		return
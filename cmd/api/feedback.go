@@ -1,14 +1,124 @@
 package main
 
 import (
+	"encoding/csv"
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/Syha-01/national-inservice-training/internal/data"
 	"github.com/Syha-01/national-inservice-training/internal/validator"
 )
 
+// checkFeedbackEligibility ensures the authenticated user owns the enrollment being rated, that
+// it is for the course when courseID is not zero, that its session has started and that the
+// facilitator is assigned to the session when facilitatorID is not zero. The error response is
+// sent and false returned when any of these fail.
+func (a *application) checkFeedbackEligibility(w http.ResponseWriter, r *http.Request, eligibility *data.FeedbackEligibility, courseID, facilitatorID int64) bool {
+	user := a.contextGetUser(r)
+
+	if !user.PersonnelID.Valid {
+		a.noLinkedOfficerResponse(w, r)
+		return false
+	}
+
+	err := eligibility.Check(user.PersonnelID.Int64, courseID, facilitatorID, time.Now())
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, data.ErrNotEnrollmentOwner):
+		a.notEnrollmentOwnerResponse(w, r)
+	case errors.Is(err, data.ErrCourseMismatch):
+		a.failedValidationResponse(w, r, map[string]string{"session_enrollment_id": "the enrollment is not for this course"})
+	case errors.Is(err, data.ErrSessionNotStarted):
+		a.failedValidationResponse(w, r, map[string]string{"session_enrollment_id": "feedback can only be given once the session has started"})
+	case errors.Is(err, data.ErrFacilitatorNotAssigned):
+		a.failedValidationResponse(w, r, map[string]string{"facilitator_id": "the facilitator is not assigned to the session of this enrollment"})
+	default:
+		a.serverErrorResponse(w, r, err)
+	}
+
+	return false
+}
+
+// readFeedbackFilters reads the filters, page and format of a feedback listing,
+// sending an error response if any are invalid. Users without the
+// feedback:moderate permission only see published feedback.
+func (a *application) readFeedbackFilters(w http.ResponseWriter, r *http.Request) (data.FeedbackFilters, string, bool) {
+	v := validator.New()
+	qs := r.URL.Query()
+
+	filters := data.FeedbackFilters{
+		From:        a.readDate(qs, "from", v),
+		To:          a.readDate(qs, "to", v),
+		MinScore:    a.readInt(qs, "min_score", 0, v),
+		MaxScore:    a.readInt(qs, "max_score", 0, v),
+		SessionID:   int64(a.readInt(qs, "session_id", 0, v)),
+		FormationID: int64(a.readInt(qs, "formation_id", 0, v)),
+	}
+	filters.Filters.Page = a.readInt(qs, "page", 1, v)
+	filters.Filters.PageSize = a.readInt(qs, "page_size", 20, v)
+
+	format := a.readString(qs, "format", "json")
+	v.Check(format == "json" || format == "csv", "format", "must be either 'json' or 'csv'")
+
+	permissions, err := a.models.Permissions.GetAllForUser(a.contextGetUser(r).ID)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return filters, format, false
+	}
+
+	// Only moderators see feedback which is pending or hidden
+	filters.Statuses = []string{data.FeedbackPublished}
+	if permissions.Include("feedback:moderate") {
+		filters.Statuses = a.readCSV(qs, "status", []string{})
+	}
+
+	if data.ValidateFeedbackFilters(v, filters); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return filters, format, false
+	}
+
+	return filters, format, true
+}
+
+// feedbackCSVHeader is the header row of a feedback export.
+var feedbackCSVHeader = []string{"id", "session_enrollment_id", "score", "comment", "anonymous", "status", "created_at"}
+
+// feedbackCSVRecord formats the fields shared by facilitator and course ratings
+// as a row of a feedback export. The enrollment of an anonymous rating is blank.
+func feedbackCSVRecord(id, sessionEnrollmentID int64, score int, comment string, anonymous bool, status string, createdAt time.Time) []string {
+	enrollment := ""
+	if sessionEnrollmentID != 0 {
+		enrollment = strconv.FormatInt(sessionEnrollmentID, 10)
+	}
+
+	return []string{
+		strconv.FormatInt(id, 10),
+		enrollment,
+		strconv.Itoa(score),
+		comment,
+		strconv.FormatBool(anonymous),
+		status,
+		createdAt.Format(time.RFC3339),
+	}
+}
+
+// startFeedbackCSV writes the headers and header row of a feedback export. Rows
+// are streamed to the client as they are written, so errors after this point
+// can only be logged.
+func startFeedbackCSV(w http.ResponseWriter, filename string, owner string) *csv.Writer {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write(append([]string{owner}, feedbackCSVHeader...))
+
+	return cw
+}
+
 func (a *application) createFacilitatorFeedbackHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := a.readIDParam(r)
 	if err != nil {
@@ -20,6 +130,7 @@ func (a *application) createFacilitatorFeedbackHandler(w http.ResponseWriter, r
 		SessionEnrollmentID int64  `json:"session_enrollment_id"`
 		Score               int    `json:"score"`
 		Comment             string `json:"comment"`
+		Anonymous           bool   `json:"anonymous"`
 	}
 
 	err = a.readJSON(w, r, &input)
@@ -28,20 +139,44 @@ func (a *application) createFacilitatorFeedbackHandler(w http.ResponseWriter, r
 		return
 	}
 
+	v := validator.New()
+
+	if data.ValidateRating(v, input.Score, nil); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	eligibility, err := a.models.Feedback.GetEligibility(input.SessionEnrollmentID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.failedValidationResponse(w, r, map[string]string{"session_enrollment_id": "enrollment does not exist"})
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if !a.checkFeedbackEligibility(w, r, eligibility, 0, id) {
+		return
+	}
+
 	feedback := &data.FacilitatorFeedback{
 		FacilitatorID:       id,
 		SessionEnrollmentID: input.SessionEnrollmentID,
 		Score:               input.Score,
 		Comment:             input.Comment,
+		Anonymous:           input.Anonymous,
 	}
+	feedback.Status, feedback.ModerationReason = data.ModerationStatus(input.Comment, a.config.feedback.blocklist)
 
 	err = a.models.Feedback.InsertFacilitatorFeedback(feedback)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrDuplicateRecord):
-			v := validator.New()
-			v.AddError("feedback", "you have already provided feedback for this facilitator")
-			a.failedValidationResponse(w, r, v.Errors)
+			a.conflictResponse(w, r, map[string]string{"feedback": "you have already provided feedback for this facilitator"})
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
 		default:
 			a.serverErrorResponse(w, r, err)
 		}
@@ -57,6 +192,28 @@ func (a *application) createFacilitatorFeedbackHandler(w http.ResponseWriter, r
 	}
 }
 
+// finishFeedbackCSV flushes a feedback export. The header row is still buffered
+// if the export failed before any rating was written, so the error can be sent
+// to the client instead; after that it can only be logged.
+func (a *application) finishFeedbackCSV(w http.ResponseWriter, r *http.Request, cw *csv.Writer, written int, err error) {
+	if err != nil && written == 0 {
+		w.Header().Del("Content-Disposition")
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	cw.Flush()
+	if err == nil {
+		err = cw.Error()
+	}
+	if err != nil {
+		a.logError(r, err)
+	}
+}
+
+// listFacilitatorFeedbackHandler lists a page of the ratings given to a
+// facilitator which match the filters, along with a summary of all of them, or
+// exports them all as CSV
 func (a *application) listFacilitatorFeedbackHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := a.readIDParam(r)
 	if err != nil {
@@ -64,13 +221,31 @@ func (a *application) listFacilitatorFeedbackHandler(w http.ResponseWriter, r *h
 		return
 	}
 
-	feedback, err := a.models.Feedback.GetAllForFacilitator(id)
+	filters, format, ok := a.readFeedbackFilters(w, r)
+	if !ok {
+		return
+	}
+
+	if format == "csv" {
+		cw := startFeedbackCSV(w, fmt.Sprintf("facilitator-%d-feedback.csv", id), "facilitator_id")
+
+		written := 0
+		err = a.models.Feedback.StreamForFacilitator(id, filters, a.config.feedback.minResponses, func(f *data.FacilitatorFeedback) error {
+			written++
+			record := feedbackCSVRecord(f.ID, f.SessionEnrollmentID, f.Score, f.Comment, f.Anonymous, f.Status, f.CreatedAt)
+			return cw.Write(append([]string{strconv.FormatInt(f.FacilitatorID, 10)}, record...))
+		})
+		a.finishFeedbackCSV(w, r, cw, written, err)
+		return
+	}
+
+	feedback, summary, metadata, err := a.models.Feedback.GetAllForFacilitator(id, filters, a.config.feedback.minResponses)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 		return
 	}
 
-	err = a.writeJSON(w, http.StatusOK, envelope{"feedback": feedback}, nil)
+	err = a.writeJSON(w, http.StatusOK, envelope{"feedback": feedback, "summary": summary, "metadata": metadata}, nil)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 	}
@@ -84,8 +259,10 @@ func (a *application) createCourseRatingHandler(w http.ResponseWriter, r *http.R
 	}
 
 	var input struct {
-		Score   int    `json:"score"`
-		Comment string `json:"comment"`
+		Score          int    `json:"score"`
+		Comment        string `json:"comment"`
+		Anonymous      bool   `json:"anonymous"`
+		WouldRecommend *int   `json:"would_recommend"`
 	}
 
 	err = a.readJSON(w, r, &input)
@@ -94,15 +271,45 @@ func (a *application) createCourseRatingHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	v := validator.New()
+
+	if data.ValidateRating(v, input.Score, input.WouldRecommend); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	eligibility, err := a.models.Feedback.GetEligibility(enrollmentID, 0)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if !a.checkFeedbackEligibility(w, r, eligibility, 0, 0) {
+		return
+	}
+
 	feedback := &data.CourseFeedback{
 		SessionEnrollmentID: enrollmentID,
 		Score:               input.Score,
 		Comment:             input.Comment,
+		Anonymous:           input.Anonymous,
+		WouldRecommend:      input.WouldRecommend,
 	}
+	feedback.Status, feedback.ModerationReason = data.ModerationStatus(input.Comment, a.config.feedback.blocklist)
 
 	err = a.models.Feedback.InsertCourseFeedback(feedback)
 	if err != nil {
-		a.serverErrorResponse(w, r, err)
+		switch {
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
@@ -126,6 +333,7 @@ func (a *application) createFacilitatorRatingHandler(w http.ResponseWriter, r *h
 		FacilitatorID int64  `json:"facilitator_id"`
 		Score         int    `json:"score"`
 		Comment       string `json:"comment"`
+		Anonymous     bool   `json:"anonymous"`
 	}
 
 	err = a.readJSON(w, r, &input)
@@ -134,16 +342,47 @@ func (a *application) createFacilitatorRatingHandler(w http.ResponseWriter, r *h
 		return
 	}
 
+	v := validator.New()
+
+	v.Check(input.FacilitatorID >= 1, "facilitator_id", "must be provided")
+
+	if data.ValidateRating(v, input.Score, nil); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	eligibility, err := a.models.Feedback.GetEligibility(enrollmentID, input.FacilitatorID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if !a.checkFeedbackEligibility(w, r, eligibility, 0, input.FacilitatorID) {
+		return
+	}
+
 	feedback := &data.FacilitatorFeedback{
 		FacilitatorID:       input.FacilitatorID,
 		SessionEnrollmentID: enrollmentID,
 		Score:               input.Score,
 		Comment:             input.Comment,
+		Anonymous:           input.Anonymous,
 	}
+	feedback.Status, feedback.ModerationReason = data.ModerationStatus(input.Comment, a.config.feedback.blocklist)
 
 	err = a.models.Feedback.InsertFacilitatorFeedback(feedback)
 	if err != nil {
-		a.serverErrorResponse(w, r, err)
+		switch {
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
@@ -157,7 +396,7 @@ func (a *application) createFacilitatorRatingHandler(w http.ResponseWriter, r *h
 }
 
 func (a *application) createCourseFeedbackHandler(w http.ResponseWriter, r *http.Request) {
-	_, err := a.readIDParam(r)
+	id, err := a.readIDParam(r)
 	if err != nil {
 		a.notFoundResponse(w, r)
 		return
@@ -167,6 +406,8 @@ func (a *application) createCourseFeedbackHandler(w http.ResponseWriter, r *http
 		SessionEnrollmentID int64  `json:"session_enrollment_id"`
 		Score               int    `json:"score"`
 		Comment             string `json:"comment"`
+		Anonymous           bool   `json:"anonymous"`
+		WouldRecommend      *int   `json:"would_recommend"`
 	}
 
 	err = a.readJSON(w, r, &input)
@@ -175,15 +416,45 @@ func (a *application) createCourseFeedbackHandler(w http.ResponseWriter, r *http
 		return
 	}
 
+	v := validator.New()
+
+	if data.ValidateRating(v, input.Score, input.WouldRecommend); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	eligibility, err := a.models.Feedback.GetEligibility(input.SessionEnrollmentID, 0)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.failedValidationResponse(w, r, map[string]string{"session_enrollment_id": "enrollment does not exist"})
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if !a.checkFeedbackEligibility(w, r, eligibility, id, 0) {
+		return
+	}
+
 	feedback := &data.CourseFeedback{
 		SessionEnrollmentID: input.SessionEnrollmentID,
 		Score:               input.Score,
 		Comment:             input.Comment,
+		Anonymous:           input.Anonymous,
+		WouldRecommend:      input.WouldRecommend,
 	}
+	feedback.Status, feedback.ModerationReason = data.ModerationStatus(input.Comment, a.config.feedback.blocklist)
 
 	err = a.models.Feedback.InsertCourseFeedback(feedback)
 	if err != nil {
-		a.serverErrorResponse(w, r, err)
+		switch {
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
@@ -196,6 +467,8 @@ func (a *application) createCourseFeedbackHandler(w http.ResponseWriter, r *http
 	}
 }
 
+// listCourseFeedbackHandler lists a page of the ratings given to a course which
+// match the filters, along with a summary of all of them, or exports them all as CSV
 func (a *application) listCourseFeedbackHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := a.readIDParam(r)
 	if err != nil {
@@ -203,6 +476,11 @@ func (a *application) listCourseFeedbackHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	filters, format, ok := a.readFeedbackFilters(w, r)
+	if !ok {
+		return
+	}
+
 	course, err := a.models.Courses.GetCourse(id)
 	if err != nil {
 		switch {
@@ -218,13 +496,26 @@ func (a *application) listCourseFeedbackHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	feedback, err := a.models.Feedback.GetAllForCourse(id)
+	if format == "csv" {
+		cw := startFeedbackCSV(w, fmt.Sprintf("course-%d-feedback.csv", id), "course_id")
+
+		written := 0
+		err = a.models.Feedback.StreamForCourse(id, filters, a.config.feedback.minResponses, func(f *data.CourseFeedback) error {
+			written++
+			record := feedbackCSVRecord(f.ID, f.SessionEnrollmentID, f.Score, f.Comment, f.Anonymous, f.Status, f.CreatedAt)
+			return cw.Write(append([]string{strconv.FormatInt(f.CourseID, 10)}, record...))
+		})
+		a.finishFeedbackCSV(w, r, cw, written, err)
+		return
+	}
+
+	feedback, summary, metadata, err := a.models.Feedback.GetAllForCourse(id, filters, a.config.feedback.minResponses)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 		return
 	}
 
-	err = a.writeJSON(w, http.StatusOK, envelope{"feedback": feedback}, nil)
+	err = a.writeJSON(w, http.StatusOK, envelope{"feedback": feedback, "summary": summary, "metadata": metadata}, nil)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 	}
@@ -5,10 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Syha-01/national-inservice-training/internal/validator"
 	"github.com/julienschmidt/httprouter"
@@ -45,11 +47,36 @@ func (a *application) readInt(qs url.Values, key string, defaultValue int, v *va
 	return i
 }
 
+// readDate reads a YYYY-MM-DD date from the query string, returning nil when it is absent
+func (a *application) readDate(qs url.Values, key string, v *validator.Validator) *time.Time {
+	return a.parseDate(qs.Get(key), key, v)
+}
+
+// parseDate parses a date in the format YYYY-MM-DD sent in a query string or
+// request body, returning nil when s is empty or not a valid date
+func (a *application) parseDate(s, key string, v *validator.Validator) *time.Time {
+	if s == "" {
+		return nil
+	}
+	date, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		v.AddError(key, "must be a date in the format YYYY-MM-DD")
+		return nil
+	}
+	return &date
+}
+
 func (a *application) readIDParam(r *http.Request) (int64, error) {
+	return a.readNamedIDParam(r, "id")
+}
+
+// readNamedIDParam reads a positive integer ID from the named URL parameter,
+// e.g. "facilitator_id" in /v1/sessions/:id/facilitators/:facilitator_id
+func (a *application) readNamedIDParam(r *http.Request, name string) (int64, error) {
 	params := httprouter.ParamsFromContext(r.Context())
-	id, err := strconv.ParseInt(params.ByName("id"), 10, 64)
+	id, err := strconv.ParseInt(params.ByName(name), 10, 64)
 	if err != nil || id < 1 {
-		return 0, errors.New("invalid id parameter")
+		return 0, fmt.Errorf("invalid %s parameter", name)
 	}
 	return id, nil
 }
@@ -123,3 +150,12 @@ func (a *application) background(fn func()) {
 		fn()
 	}()
 }
+
+// clientIP returns the address a request came from, without its port
+func (a *application) clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Syha-01/national-inservice-training/internal/data"
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+// inviteFacilitator sends a facilitator an invitation to set up their user account
+func (a *application) inviteFacilitator(r *http.Request, facilitator *data.Facilitator) (*data.Invitation, error) {
+	invitation, token, err := a.models.Invitations.Invite(facilitator, a.contextGetUser(r).ID, a.config.invitation.ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	a.background(func() {
+		data := map[string]any{
+			"firstName":       facilitator.FirstName,
+			"invitationToken": token.Plaintext,
+			"expiry":          token.Expiry.Format("2 January 2006 at 15:04 MST"),
+		}
+		err := a.mailer.Send(invitation.Email, "facilitator_invitation.tmpl", data)
+		if err != nil {
+			a.logger.Error(err.Error())
+		}
+	})
+
+	return invitation, nil
+}
+
+// createFacilitatorInvitationHandler sends, or sends again, an invitation to a facilitator
+func (a *application) createFacilitatorInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	facilitator, err := a.models.Facilitators.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	v := validator.New()
+
+	if v.Check(facilitator.Email != "", "email", "the facilitator must have an email address to be invited"); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	invitation, err := a.inviteFacilitator(r, facilitator)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrAccountActivated):
+			a.conflictResponse(w, r, map[string]string{"facilitator_id": "the facilitator has already accepted an invitation"})
+		case errors.Is(err, data.ErrDuplicateRecord):
+			a.conflictResponse(w, r, map[string]string{"email": "a user with this email address already exists, link it to the facilitator instead"})
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/facilitators/%d/invitation", id))
+
+	err = a.writeJSON(w, http.StatusCreated, envelope{"invitation": invitation}, headers)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// showFacilitatorInvitationHandler returns the most recent invitation sent to a facilitator
+func (a *application) showFacilitatorInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	invitation, err := a.models.Invitations.GetLatestForFacilitator(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"invitation": invitation}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// revokeFacilitatorInvitationHandler withdraws the open invitation of a facilitator
+func (a *application) revokeFacilitatorInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	err = a.models.Invitations.Revoke(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"message": "invitation successfully revoked"}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// acceptInvitationHandler sets the password of an invited facilitator's account and activates it
+func (a *application) acceptInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		TokenPlaintext string `json:"token"`
+		Password       string `json:"password"`
+	}
+
+	err := a.readJSON(w, r, &input)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	data.ValidateTokenPlaintext(v, input.TokenPlaintext)
+	data.ValidatePasswordPlaintext(v, input.Password)
+
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	user, err := a.models.Invitations.Accept(input.TokenPlaintext, input.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("token", "invalid, expired or revoked invitation token")
+			a.failedValidationResponse(w, r, v.Errors)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"user": user}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
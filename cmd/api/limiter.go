@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// emailLimiter limits how often something can happen for each email address,
+// such as sending it an email. Up to count events are allowed for an address
+// within any window, remembering when each happened rather than refilling at a
+// steady rate, so the limit is never exceeded however the events are spread.
+// Addresses are compared ignoring case.
+type emailLimiter struct {
+	mu     sync.Mutex
+	count  int
+	window time.Duration
+	pruned time.Time
+	events map[string][]time.Time
+}
+
+// newEmailLimiter allows up to count events for an address within window
+func newEmailLimiter(count int, window time.Duration) *emailLimiter {
+	return &emailLimiter{
+		count:  count,
+		window: window,
+		events: make(map[string][]time.Time),
+	}
+}
+
+// Allow reports whether an event can happen for the address now, counting it if so
+func (l *emailLimiter) Allow(email string, now time.Time) bool {
+	key := strings.ToLower(strings.TrimSpace(email))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Forget addresses whose events have all left the window
+	if now.Sub(l.pruned) > time.Minute {
+		for k, events := range l.events {
+			if now.Sub(events[len(events)-1]) >= l.window {
+				delete(l.events, k)
+			}
+		}
+		l.pruned = now
+	}
+
+	events := l.events[key]
+	for len(events) > 0 && now.Sub(events[0]) >= l.window {
+		events = events[1:]
+	}
+
+	if len(events) >= l.count {
+		if len(events) > 0 {
+			l.events[key] = events
+		}
+		return false
+	}
+
+	l.events[key] = append(events, now)
+	return true
+}
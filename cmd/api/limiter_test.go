@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEmailLimiter(t *testing.T) {
+	limiter := newEmailLimiter(2, time.Hour)
+	now := time.Now()
+
+	if !limiter.Allow("officer@example.com", now) || !limiter.Allow("Officer@Example.com ", now.Add(30*time.Minute)) {
+		t.Fatalf("expected the first two events to be allowed")
+	}
+	if limiter.Allow("officer@example.com", now.Add(59*time.Minute)) {
+		t.Errorf("expected a third event within the window to be refused")
+	}
+	if !limiter.Allow("other@example.com", now) {
+		t.Errorf("expected another address to be limited separately")
+	}
+	if !limiter.Allow("officer@example.com", now.Add(time.Hour)) {
+		t.Errorf("expected an event to be allowed once the first has left the window")
+	}
+	if limiter.Allow("officer@example.com", now.Add(89*time.Minute)) {
+		t.Errorf("expected the second event to still count until it leaves the window")
+	}
+}
+
+func TestEmailLimiterNeverExceedsCount(t *testing.T) {
+	const count = 3
+	limiter := newEmailLimiter(count, time.Hour)
+	start := time.Now()
+
+	// Try every minute for three hours and check every hour-long window
+	allowed := []time.Time{}
+	for minute := range 180 {
+		now := start.Add(time.Duration(minute) * time.Minute)
+		if limiter.Allow("officer@example.com", now) {
+			allowed = append(allowed, now)
+		}
+	}
+
+	for i := range allowed {
+		within := 0
+		for _, at := range allowed[i:] {
+			if at.Sub(allowed[i]) < time.Hour {
+				within++
+			}
+		}
+		if within > count {
+			t.Fatalf("expected at most %d events in an hour, got %d from %v", count, within, allowed[i])
+		}
+	}
+
+	if len(allowed) != 3*count {
+		t.Errorf("expected %d events over three hours, got %d", 3*count, len(allowed))
+	}
+}
+
+func TestEmailLimiterForgetsIdleAddresses(t *testing.T) {
+	limiter := newEmailLimiter(1, time.Hour)
+	now := time.Now()
+
+	limiter.Allow("officer@example.com", now)
+	limiter.Allow("other@example.com", now.Add(2*time.Hour))
+
+	if _, found := limiter.events["officer@example.com"]; found {
+		t.Errorf("expected an idle address to be forgotten")
+	}
+}
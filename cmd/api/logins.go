@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/data"
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+// lockoutPolicy returns how accounts are locked after failed logins
+func (a *application) lockoutPolicy() data.LockoutPolicy {
+	return data.LockoutPolicy{
+		MaxAttempts: a.config.login.maxAttempts,
+		Lockout:     a.config.login.lockout,
+		MaxLockout:  a.config.login.maxLockout,
+	}
+}
+
+// recordLoginAttempt adds a login attempt to the audit trail. A failure to
+// record it is logged rather than stopping the login.
+func (a *application) recordLoginAttempt(r *http.Request, user *data.User, email, result string) {
+	attempt := &data.LoginAttempt{
+		Email:     email,
+		Result:    result,
+		ClientIP:  a.clientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+	if user != nil {
+		attempt.UserID = data.NullInt64{Int64: user.ID, Valid: true}
+	}
+
+	err := a.models.Logins.RecordAttempt(attempt)
+	if err != nil {
+		a.logError(r, err)
+	}
+}
+
+// sendLockoutEmail warns a user that their account has been locked after
+// failed logins which may not have been theirs
+func (a *application) sendLockoutEmail(r *http.Request, user *data.User, lockedUntil time.Time) {
+	clientIP, userAgent := a.clientIP(r), r.UserAgent()
+
+	a.background(func() {
+		data := map[string]any{
+			"lockedUntil": lockedUntil.Format("2 January 2006 at 15:04 MST"),
+			"clientIP":    clientIP,
+			"userAgent":   userAgent,
+		}
+		err := a.mailer.Send(user.Email, "login_lockout.tmpl", data)
+		if err != nil {
+			a.logger.Error(err.Error())
+		}
+	})
+}
+
+// unlockUserHandler clears the failed logins and any lockout of a user's account
+func (a *application) unlockUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	err = a.models.Logins.Unlock(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"message": "account successfully unlocked"}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// listLoginAttemptsHandler returns a page of the login audit trail, most recent first
+func (a *application) listLoginAttemptsHandler(w http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+	qs := r.URL.Query()
+
+	filters := data.LoginAttemptFilters{
+		UserID:   int64(a.readInt(qs, "user_id", 0, v)),
+		Email:    a.readString(qs, "email", ""),
+		Result:   a.readString(qs, "result", ""),
+		ClientIP: a.readString(qs, "client_ip", ""),
+		From:     a.readDate(qs, "from", v),
+		To:       a.readDate(qs, "to", v),
+	}
+	filters.Filters.Page = a.readInt(qs, "page", 1, v)
+	filters.Filters.PageSize = a.readInt(qs, "page_size", 20, v)
+
+	if data.ValidateLoginAttemptFilters(v, filters); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	attempts, metadata, err := a.models.Logins.GetAttempts(filters)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"login_attempts": attempts, "metadata": metadata}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
@@ -38,15 +38,52 @@ type configuration struct {
 		password string
 		sender   string
 	}
+	feedback struct {
+		minResponses int
+		blocklist    []string
+	}
+	workload struct {
+		maxTeachingDays int
+	}
+	invitation struct {
+		ttl time.Duration
+	}
+	passwordReset struct {
+		ttl time.Duration
+	}
+	tokens struct {
+		accessTTL  time.Duration
+		refreshTTL time.Duration
+	}
+	activation struct {
+		resendLimit  int
+		resendWindow time.Duration
+	}
+	login struct {
+		maxAttempts int
+		lockout     time.Duration
+		maxLockout  time.Duration
+	}
+	reminders struct {
+		enabled  bool
+		interval time.Duration
+		max      int
+		gap      time.Duration
+		window   time.Duration
+	}
+	frontend struct {
+		url string
+	}
 }
 
 type application struct {
-	config          configuration
-	logger          *slog.Logger
-	models          data.Models
-	mailer          mailer.Mailer
-	wg              sync.WaitGroup
-	permissionModel data.PermissionModel
+	config            configuration
+	logger            *slog.Logger
+	models            data.Models
+	mailer            mailer.Mailer
+	wg                sync.WaitGroup
+	permissionModel   data.PermissionModel
+	activationLimiter *emailLimiter
 }
 
 func main() {
@@ -78,6 +115,49 @@ func main() {
 	flag.StringVar(&settings.smtp.password, "smtp-password", "2fa704cb93fea2", "SMTP password")
 	flag.StringVar(&settings.smtp.sender, "smtp-sender", "National Inservice Training <no-reply@nits.com>", "SMTP sender")
 
+	// Feedback configuration
+	flag.IntVar(&settings.feedback.minResponses, "feedback-min-responses", 5, "Minimum ratings before an average score is reported")
+	flag.Func("feedback-blocklist", "Words and phrases which hold a comment for moderation (comma separated)", func(val string) error {
+		for _, term := range strings.Split(val, ",") {
+			if term = strings.TrimSpace(term); term != "" {
+				settings.feedback.blocklist = append(settings.feedback.blocklist, term)
+			}
+		}
+		return nil
+	})
+
+	// Workload configuration
+	flag.IntVar(&settings.workload.maxTeachingDays, "workload-max-teaching-days", 30, "Teaching days in a workload report above which a facilitator is flagged")
+
+	// Invitation configuration
+	flag.DurationVar(&settings.invitation.ttl, "invitation-ttl", 7*24*time.Hour, "How long a facilitator invitation can be accepted for")
+
+	// Authentication token configuration
+	flag.DurationVar(&settings.tokens.accessTTL, "access-token-ttl", 15*time.Minute, "How long an authentication token can be used for")
+	flag.DurationVar(&settings.tokens.refreshTTL, "refresh-token-ttl", 30*24*time.Hour, "How long a refresh token can be exchanged for new tokens")
+
+	// Activation email configuration
+	flag.IntVar(&settings.activation.resendLimit, "activation-resend-limit", 3, "Most activation emails resent to one address within -activation-resend-window")
+	flag.DurationVar(&settings.activation.resendWindow, "activation-resend-window", time.Hour, "Window in which activation emails resent to one address are limited")
+
+	// Account lockout configuration
+	flag.IntVar(&settings.login.maxAttempts, "login-max-attempts", 5, "Consecutive failed logins before an account is locked")
+	flag.DurationVar(&settings.login.lockout, "login-lockout", time.Minute, "How long an account is first locked for, doubling with each further failed login")
+	flag.DurationVar(&settings.login.maxLockout, "login-max-lockout", time.Hour, "Longest an account is locked for after failed logins")
+
+	// Password reset configuration
+	flag.DurationVar(&settings.passwordReset.ttl, "password-reset-ttl", 45*time.Minute, "How long a password reset token can be used for")
+
+	// Feedback reminder configuration
+	flag.BoolVar(&settings.reminders.enabled, "reminders-enabled", true, "Email officers who have not given feedback on a completed session")
+	flag.DurationVar(&settings.reminders.interval, "reminders-interval", time.Hour, "How often to look for officers due a feedback reminder")
+	flag.IntVar(&settings.reminders.max, "reminders-max", 3, "Most feedback reminders sent for one enrollment")
+	flag.DurationVar(&settings.reminders.gap, "reminders-gap", 72*time.Hour, "Least time between feedback reminders for one enrollment")
+	flag.DurationVar(&settings.reminders.window, "reminders-window", 30*24*time.Hour, "How long after a session ends feedback reminders are sent for it")
+
+	// Frontend configuration
+	flag.StringVar(&settings.frontend.url, "frontend-url", "http://localhost:3000", "Base URL of the frontend, used for links in emails")
+
 	flag.Parse()
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
@@ -92,11 +172,12 @@ func main() {
 	logger.Info("database connection pool established")
 
 	appInstance := &application{
-		config:          settings,
-		logger:          logger,
-		models:          data.NewModels(db),
-		mailer:          mailer.New(settings.smtp.host, settings.smtp.port, settings.smtp.username, settings.smtp.password, settings.smtp.sender),
-		permissionModel: data.PermissionModel{DB: db},
+		config:            settings,
+		logger:            logger,
+		models:            data.NewModels(db),
+		mailer:            mailer.New(settings.smtp.host, settings.smtp.port, settings.smtp.username, settings.smtp.password, settings.smtp.sender),
+		permissionModel:   data.PermissionModel{DB: db},
+		activationLimiter: newEmailLimiter(settings.activation.resendLimit, settings.activation.resendWindow),
 	}
 
 	err = appInstance.serve()
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/data"
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+// showCurrentUserHandler returns the authenticated user along with their officer record, role and permissions
+func (a *application) showCurrentUserHandler(w http.ResponseWriter, r *http.Request) {
+	user := a.contextGetUser(r)
+
+	var officer *data.Officer
+	if user.PersonnelID.Valid {
+		var err error
+		officer, err = a.models.Officers.GetOfficer(user.PersonnelID.Int64)
+		if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+			a.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	role, err := a.models.Roles.Get(user.RoleID)
+	if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	permissions, err := a.models.Permissions.GetAllForUser(user.ID)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+	if permissions == nil {
+		permissions = data.Permissions{}
+	}
+
+	env := envelope{
+		"user":        user,
+		"officer":     officer,
+		"role":        role,
+		"permissions": permissions,
+	}
+
+	err = a.writeJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// listCurrentUserEnrollmentsHandler returns the enrollments of the authenticated user's officer record
+func (a *application) listCurrentUserEnrollmentsHandler(w http.ResponseWriter, r *http.Request) {
+	user := a.contextGetUser(r)
+
+	var input struct {
+		data.Filters
+		Expand data.Expand
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	input.Filters.Page = a.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = a.readInt(qs, "page_size", 20, v)
+	input.Expand = data.NewExpand(a.readCSV(qs, "expand", []string{}))
+
+	data.ValidateFilters(v, input.Filters)
+	data.ValidateExpand(v, input.Expand, data.EnrollmentExpandSafelist)
+
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	enrollments, metadata, err := a.models.Enrollments.GetAllForPersonnel(user.PersonnelID.Int64, input.Filters, input.Expand)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"enrollments": enrollments, "metadata": metadata}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// createCurrentUserEnrollmentHandler enrolls the authenticated user's officer record in an open session
+func (a *application) createCurrentUserEnrollmentHandler(w http.ResponseWriter, r *http.Request) {
+	user := a.contextGetUser(r)
+
+	var input struct {
+		SessionID int64 `json:"session_id"`
+	}
+
+	err := a.readJSON(w, r, &input)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	nit, err := a.models.Nits.Get(input.SessionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("session_id", "session does not exist")
+			a.failedValidationResponse(w, r, v.Errors)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// Only sessions which have not yet started are open for enrollment
+	if !nit.StartDate.After(time.Now()) {
+		v.AddError("session_id", "session is no longer open for enrollment")
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	id, err := a.models.Nits.EnrollPersonnel(nit.ID, user.PersonnelID.Int64)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateRecord):
+			a.conflictResponse(w, r, map[string]string{"session_id": "you are already enrolled in this session"})
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusCreated, envelope{"session_enrollment_id": id}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// showCurrentUserTranscriptHandler returns the completed training of the authenticated user's officer record
+func (a *application) showCurrentUserTranscriptHandler(w http.ResponseWriter, r *http.Request) {
+	user := a.contextGetUser(r)
+
+	transcript, err := a.models.Enrollments.GetTranscript(user.PersonnelID.Int64)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"transcript": transcript}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
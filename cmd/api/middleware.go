@@ -132,6 +132,9 @@ func (a *application) rateLimit(next http.Handler) http.Handler {
 	})
 }
 
+// sessionTouchInterval is how often the last used time of an authentication token is updated
+const sessionTouchInterval = 5 * time.Minute
+
 // authenticate extracts and validates the authentication token
 func (a *application) authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -165,7 +168,7 @@ func (a *application) authenticate(next http.Handler) http.Handler {
 		}
 
 		// Retrieve user associated with the token
-		user, err := a.models.Users.GetForToken(data.ScopeAuthentication, token)
+		user, session, err := a.models.Users.GetForAuthenticationToken(token)
 		if err != nil {
 			switch {
 			case errors.Is(err, data.ErrRecordNotFound):
@@ -176,8 +179,20 @@ func (a *application) authenticate(next http.Handler) http.Handler {
 			return
 		}
 
-		// Add user to request context
+		// Record when the token was last used, at most once per interval and
+		// off the request path, so authenticating stays a single read
+		if session.LastUsedAt == nil || time.Since(*session.LastUsedAt) > sessionTouchInterval {
+			a.background(func() {
+				err := a.models.Tokens.Touch(session.ID)
+				if err != nil {
+					a.logger.Error(err.Error())
+				}
+			})
+		}
+
+		// Add user and token to request context
 		r = a.contextSetUser(r, user)
+		r = a.contextSetSession(r, session)
 
 		next.ServeHTTP(w, r)
 	})
@@ -213,6 +228,23 @@ func (a *application) requireActivatedUser(next http.HandlerFunc) http.HandlerFu
 	return a.requireAuthenticatedUser(fn)
 }
 
+// requireLinkedOfficer ensures the user account is linked to a personnel record.
+// Only an administrator can set the link, with PUT /v1/officers/:id/user.
+func (a *application) requireLinkedOfficer(next http.HandlerFunc) http.HandlerFunc {
+	fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := a.contextGetUser(r)
+
+		if !user.PersonnelID.Valid {
+			a.noLinkedOfficerResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+
+	return a.requireActivatedUser(fn)
+}
+
 // requirePermission checks if user has specific permission based on their role
 func (a *application) requirePermission(code string, next http.HandlerFunc) http.HandlerFunc {
 	fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -235,4 +267,66 @@ func (a *application) requirePermission(code string, next http.HandlerFunc) http
 	})
 
 	return a.requireActivatedUser(fn)
-}
\ No newline at end of file
+}
+
+// requireLinkedFacilitator ensures user account is linked to a facilitator record
+func (a *application) requireLinkedFacilitator(next http.HandlerFunc) http.HandlerFunc {
+	fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := a.contextGetUser(r)
+
+		if !user.FacilitatorID.Valid {
+			a.noLinkedFacilitatorResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+
+	return a.requireActivatedUser(fn)
+}
+
+// requireSessionAccess lets through users holding the given permission, and
+// facilitators assigned to the session named by the :id parameter
+func (a *application) requireSessionAccess(code string, next http.HandlerFunc) http.HandlerFunc {
+	fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := a.contextGetUser(r)
+
+		sessionID, err := a.readIDParam(r)
+		if err != nil {
+			a.notFoundResponse(w, r)
+			return
+		}
+
+		permissions, err := a.models.Permissions.GetAllForUser(user.ID)
+		if err != nil {
+			a.serverErrorResponse(w, r, err)
+			return
+		}
+
+		if permissions.Include(code) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Without the permission, only a facilitator assigned to the session gets through
+		if !user.FacilitatorID.Valid {
+			a.notPermittedResponse(w, r)
+			return
+		}
+
+		assigned, err := a.models.Facilitators.IsAssignedToSession(user.FacilitatorID.Int64, sessionID)
+		if err != nil {
+			a.serverErrorResponse(w, r, err)
+			return
+		}
+
+		if !assigned {
+			a.notPermittedResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+
+	return a.requireActivatedUser(fn)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/Syha-01/national-inservice-training/internal/data"
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+	"github.com/julienschmidt/httprouter"
+)
+
+// listFeedbackModerationQueueHandler returns a page of the course and
+// facilitator ratings awaiting moderation, or in another status, oldest first
+func (a *application) listFeedbackModerationQueueHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Status string
+		data.Filters
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	input.Status = a.readString(qs, "status", data.FeedbackPending)
+	input.Filters.Page = a.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = a.readInt(qs, "page_size", 20, v)
+
+	v.Check(slices.Contains(data.FeedbackStatuses, input.Status), "status", "must be one of pending, published or hidden")
+
+	if data.ValidateFilters(v, input.Filters); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	items, metadata, err := a.models.Feedback.GetModerationQueue(input.Status, input.Filters)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"feedback": items, "metadata": metadata}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// moderateFeedbackHandler publishes, hides or holds a course or facilitator rating
+func (a *application) moderateFeedbackHandler(w http.ResponseWriter, r *http.Request) {
+	kind := httprouter.ParamsFromContext(r.Context()).ByName("kind")
+
+	id, err := a.readIDParam(r)
+	if err != nil || !slices.Contains(data.FeedbackKinds, kind) {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	}
+
+	err = a.readJSON(w, r, &input)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	if data.ValidateModeration(v, input.Status, input.Reason); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	item, err := a.models.Feedback.Moderate(kind, id, input.Status, input.Reason, a.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"feedback": item}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
@@ -43,7 +43,12 @@ func (a *application) createNitHandler(w http.ResponseWriter, r *http.Request) {
 
 	err = a.models.Nits.Create(nit)
 	if err != nil {
-		a.serverErrorResponse(w, r, err)
+		switch {
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
@@ -63,7 +68,15 @@ func (a *application) showNitHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	nit, err := a.models.Nits.Get(id)
+	v := validator.New()
+	expand := data.NewExpand(a.readCSV(r.URL.Query(), "expand", []string{}))
+
+	if data.ValidateExpand(v, expand, data.NitExpandSafelist); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	nit, err := a.models.Nits.GetExpanded(id, expand)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -138,6 +151,8 @@ func (a *application) updateNitHandler(w http.ResponseWriter, r *http.Request) {
 		switch {
 		case errors.Is(err, data.ErrEditConflict):
 			a.editConflictResponse(w, r)
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
 		default:
 			a.serverErrorResponse(w, r, err)
 		}
@@ -162,6 +177,8 @@ func (a *application) deleteNitHandler(w http.ResponseWriter, r *http.Request) {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
 			a.notFoundResponse(w, r)
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
 		default:
 			a.serverErrorResponse(w, r, err)
 		}
@@ -188,7 +205,14 @@ func (a *application) enrollPersonnelHandler(w http.ResponseWriter, r *http.Requ
 
 	id, err := a.models.Nits.EnrollPersonnel(input.SessionID, input.PersonnelID)
 	if err != nil {
-		a.serverErrorResponse(w, r, err)
+		switch {
+		case errors.Is(err, data.ErrDuplicateRecord):
+			a.conflictResponse(w, r, map[string]string{"personnel_id": "this officer is already enrolled in the session"})
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
@@ -201,6 +225,7 @@ func (a *application) enrollPersonnelHandler(w http.ResponseWriter, r *http.Requ
 func (a *application) listNitsHandler(w http.ResponseWriter, r *http.Request) {
 	var input struct {
 		data.Filters
+		Expand data.Expand
 	}
 
 	v := validator.New()
@@ -208,15 +233,17 @@ func (a *application) listNitsHandler(w http.ResponseWriter, r *http.Request) {
 
 	input.Filters.Page = a.readInt(qs, "page", 1, v)
 	input.Filters.PageSize = a.readInt(qs, "page_size", 20, v)
+	input.Expand = data.NewExpand(a.readCSV(qs, "expand", []string{}))
 
 	data.ValidateFilters(v, input.Filters)
+	data.ValidateExpand(v, input.Expand, data.NitExpandSafelist)
 
 	if !v.IsEmpty() {
 		a.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	nits, metadata, err := a.models.Nits.GetAll(input.Filters)
+	nits, metadata, err := a.models.Nits.GetAll(input.Filters, input.Expand)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 		return
@@ -266,7 +293,12 @@ func (a *application) createOfficerHandler(w http.ResponseWriter, r *http.Reques
 
 	err = a.models.Officers.CreateOfficer(officer)
 	if err != nil {
-		a.serverErrorResponse(w, r, err)
+		switch {
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
@@ -286,7 +318,15 @@ func (a *application) displayOfficerHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	officer, err := a.models.Officers.GetOfficer(id)
+	v := validator.New()
+	expand := data.NewExpand(a.readCSV(r.URL.Query(), "expand", []string{}))
+
+	if data.ValidateExpand(v, expand, data.OfficerExpandSafelist); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	officer, err := a.models.Officers.GetOfficerExpanded(id, expand)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -380,6 +420,8 @@ func (a *application) updateOfficerHandler(w http.ResponseWriter, r *http.Reques
 		switch {
 		case errors.Is(err, data.ErrEditConflict):
 			a.editConflictResponse(w, r)
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
 		default:
 			a.serverErrorResponse(w, r, err)
 		}
@@ -404,6 +446,8 @@ func (a *application) deleteOfficerHandler(w http.ResponseWriter, r *http.Reques
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
 			a.notFoundResponse(w, r)
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
 		default:
 			a.serverErrorResponse(w, r, err)
 		}
@@ -419,6 +463,7 @@ func (a *application) deleteOfficerHandler(w http.ResponseWriter, r *http.Reques
 func (a *application) listOfficersHandler(w http.ResponseWriter, r *http.Request) {
 	var input struct {
 		data.Filters
+		Expand data.Expand
 	}
 
 	v := validator.New()
@@ -426,15 +471,17 @@ func (a *application) listOfficersHandler(w http.ResponseWriter, r *http.Request
 
 	input.Filters.Page = a.readInt(qs, "page", 1, v)
 	input.Filters.PageSize = a.readInt(qs, "page_size", 20, v)
+	input.Expand = data.NewExpand(a.readCSV(qs, "expand", []string{}))
 
 	data.ValidateFilters(v, input.Filters)
+	data.ValidateExpand(v, input.Expand, data.OfficerExpandSafelist)
 
 	if !v.IsEmpty() {
 		a.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	officers, metadata, err := a.models.Officers.GetAllOfficers(input.Filters)
+	officers, metadata, err := a.models.Officers.GetAllOfficers(input.Filters, input.Expand)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 		return
@@ -532,7 +579,12 @@ func (a *application) createCourseHandler(w http.ResponseWriter, r *http.Request
 
 	err = a.models.Courses.CreateCourse(course)
 	if err != nil {
-		a.serverErrorResponse(w, r, err)
+		switch {
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
@@ -602,6 +654,8 @@ func (a *application) updateCourseHandler(w http.ResponseWriter, r *http.Request
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
 			a.notFoundResponse(w, r)
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
 		default:
 			a.serverErrorResponse(w, r, err)
 		}
@@ -627,6 +681,8 @@ func (a *application) deleteCourseHandler(w http.ResponseWriter, r *http.Request
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
 			a.notFoundResponse(w, r)
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
 		default:
 			a.serverErrorResponse(w, r, err)
 		}
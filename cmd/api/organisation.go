@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/data"
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+// readYear reads the reporting year from the query string, defaulting to the current year
+func (a *application) readYear(r *http.Request, v *validator.Validator) int {
+	year := a.readInt(r.URL.Query(), "year", time.Now().Year(), v)
+	v.Check(year >= 2000 && year <= 2100, "year", "must be between 2000 and 2100")
+	return year
+}
+
+// showOrgTreeHandler returns the region to formation hierarchy with training statistics
+func (a *application) showOrgTreeHandler(w http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+
+	year := a.readYear(r, v)
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	tree, err := a.models.Organisation.GetTree(year)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"org_tree": tree}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// showOrgRegionHandler returns a single region and its formations with training statistics
+func (a *application) showOrgRegionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+
+	year := a.readYear(r, v)
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	region, err := a.models.Organisation.GetRegion(id, year)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"year": year, "region": region}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// showOrgFormationHandler returns a single formation with training statistics broken down by course
+func (a *application) showOrgFormationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+
+	year := a.readYear(r, v)
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	formation, err := a.models.Organisation.GetFormation(id, year)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"year": year, "formation": formation}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
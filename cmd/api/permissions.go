@@ -32,7 +32,12 @@ func (app *application) addUserPermissionHandler(w http.ResponseWriter, r *http.
 
 	err = app.models.Permissions.AddForUser(id, input.Code)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		switch {
+		case data.IsConstraintViolation(err):
+			app.constraintViolationResponse(w, r, err)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
@@ -0,0 +1,439 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/data"
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+// listCurrentUserSessionsHandler returns the sessions the authenticated user's facilitator record is assigned to
+func (a *application) listCurrentUserSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	user := a.contextGetUser(r)
+
+	var input struct {
+		data.Filters
+		Expand data.Expand
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	input.Filters.Page = a.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = a.readInt(qs, "page_size", 20, v)
+	input.Expand = data.NewExpand(a.readCSV(qs, "expand", []string{}))
+
+	data.ValidateFilters(v, input.Filters)
+	data.ValidateExpand(v, input.Expand, data.NitExpandSafelist)
+
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	nits, metadata, err := a.models.Nits.GetAllForFacilitator(user.FacilitatorID.Int64, input.Filters, input.Expand)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"sessions": nits, "metadata": metadata}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// showSessionRosterHandler returns the officers enrolled in a session, as JSON or as a CSV download
+func (a *application) showSessionRosterHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+
+	format := a.readString(r.URL.Query(), "format", "json")
+	if v.Check(format == "json" || format == "csv", "format", "must be either 'json' or 'csv'"); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	_, err = a.models.Nits.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	roster, err := a.models.Enrollments.GetRoster(id)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if format == "csv" {
+		a.writeRosterCSV(w, r, id, roster)
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"roster": roster}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// writeRosterCSV writes a session roster as a CSV attachment
+func (a *application) writeRosterCSV(w http.ResponseWriter, r *http.Request, sessionID int64, roster []*data.RosterEntry) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="session-%d-roster.csv"`, sessionID))
+
+	cw := csv.NewWriter(w)
+
+	_ = cw.Write([]string{
+		"enrollment_id", "regulation_number", "last_name", "first_name", "rank", "formation",
+		"status", "completion_date", "grade", "days_present", "days_absent",
+	})
+
+	for _, entry := range roster {
+		var rank, formation, completionDate string
+		if entry.Officer.Rank != nil {
+			rank = entry.Officer.Rank.Name
+		}
+		if entry.Officer.Formation != nil {
+			formation = entry.Officer.Formation.Name
+		}
+		if entry.CompletionDate != nil {
+			completionDate = entry.CompletionDate.Format(time.DateOnly)
+		}
+
+		_ = cw.Write([]string{
+			strconv.FormatInt(entry.EnrollmentID, 10),
+			entry.Officer.RegulationNumber,
+			entry.Officer.LastName,
+			entry.Officer.FirstName,
+			rank,
+			formation,
+			entry.Status,
+			completionDate,
+			entry.Grade,
+			strconv.Itoa(entry.DaysPresent),
+			strconv.Itoa(entry.DaysAbsent),
+		})
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		a.logError(r, err)
+	}
+}
+
+// listSessionAttendanceHandler returns the attendance recorded for a session, optionally for a single day
+func (a *application) listSessionAttendanceHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+
+	attendedOn := a.readDate(r.URL.Query(), "attended_on", v)
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	_, err = a.models.Nits.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	attendance, err := a.models.Attendance.GetAllForSession(id, attendedOn)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"attendance": attendance}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// recordSessionAttendanceHandler records which enrolled officers were present on a day of a session
+func (a *application) recordSessionAttendanceHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		AttendedOn string `json:"attended_on"`
+		Records    []struct {
+			SessionEnrollmentID int64  `json:"session_enrollment_id"`
+			Present             bool   `json:"present"`
+			Note                string `json:"note"`
+		} `json:"records"`
+	}
+
+	err = a.readJSON(w, r, &input)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	// Attendance is taken for a day, so it is compared with the session dates as a date
+	var attendedOn time.Time
+	if date := a.parseDate(input.AttendedOn, "attended_on", v); date != nil {
+		attendedOn = *date
+	}
+
+	nit, err := a.models.Nits.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	records := make([]*data.AttendanceRecord, 0, len(input.Records))
+	for _, record := range input.Records {
+		records = append(records, &data.AttendanceRecord{
+			SessionEnrollmentID: record.SessionEnrollmentID,
+			AttendedOn:          attendedOn,
+			Present:             record.Present,
+			Note:                record.Note,
+		})
+	}
+
+	if data.ValidateAttendance(v, nit, attendedOn, records); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	user := a.contextGetUser(r)
+
+	err = a.models.Attendance.Record(id, records, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("records", "must only contain enrollments in this session")
+			a.failedValidationResponse(w, r, v.Errors)
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"attendance": records}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// updateSessionEnrollmentHandler records the outcome of an officer's enrollment in a session
+func (a *application) updateSessionEnrollmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	enrollmentID, err := a.readNamedIDParam(r, "enrollment_id")
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	nit, err := a.models.Nits.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	enrollment, err := a.models.Enrollments.GetForSession(id, enrollmentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	var input struct {
+		Status         *string    `json:"status"`
+		CompletionDate *time.Time `json:"completion_date"`
+		Grade          *string    `json:"grade"`
+	}
+
+	err = a.readJSON(w, r, &input)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Status != nil {
+		enrollment.Status = *input.Status
+		// A new status replaces the completion date unless one is given with it
+		enrollment.CompletionDate = nil
+	}
+	if input.CompletionDate != nil {
+		enrollment.CompletionDate = input.CompletionDate
+	}
+	if input.Grade != nil {
+		enrollment.Grade = *input.Grade
+	}
+
+	// Completed enrollments default to completing on the last day of the session
+	if enrollment.Status == "Completed" && enrollment.CompletionDate == nil {
+		completionDate := nit.EndDate
+		enrollment.CompletionDate = &completionDate
+	}
+
+	v := validator.New()
+
+	if data.ValidateEnrollmentOutcome(v, enrollment); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = a.models.Enrollments.UpdateOutcome(enrollment)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"enrollment": enrollment}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// linkFacilitatorUserHandler links a facilitator to the user account they sign in with
+func (a *application) linkFacilitatorUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		UserID int64 `json:"user_id"`
+	}
+
+	err = a.readJSON(w, r, &input)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	facilitator, err := a.models.Facilitators.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	v := validator.New()
+
+	user, err := a.models.Users.Get(input.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("user_id", "user does not exist")
+			a.failedValidationResponse(w, r, v.Errors)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	user.FacilitatorID = data.NullInt64{Int64: facilitator.ID, Valid: true}
+
+	err = a.models.Users.Update(user)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			a.editConflictResponse(w, r)
+		case errors.Is(err, data.ErrDuplicateRecord):
+			a.conflictResponse(w, r, map[string]string{"facilitator_id": "the facilitator is already linked to another user account"})
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"user": user}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// unlinkFacilitatorUserHandler removes the link between a facilitator and their user account
+func (a *application) unlinkFacilitatorUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	err = a.models.Users.UnlinkFacilitator(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"message": "facilitator successfully unlinked from user account"}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
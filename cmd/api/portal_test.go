@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordSessionAttendanceHandler(t *testing.T) {
+	app, db := newTestApplication(t)
+	routes := app.routes()
+
+	token := loginTestUser(t, app, newTestAdmin(t, db).ID)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	var sessionID, enrollmentID int64
+	err := db.QueryRow(`
+		WITH course AS (
+			INSERT INTO courses (title, category, credit_hours)
+			VALUES ('Attendance Course', 'Elective', 8)
+			RETURNING id
+		)
+		INSERT INTO training_sessions (course_id, start_date, end_date, location)
+		SELECT id, $1, $2, 'Belmopan' FROM course
+		RETURNING id`, today.AddDate(0, 0, -1), today).Scan(&sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.QueryRow(`
+		WITH officer AS (
+			INSERT INTO personnel (regulation_number, first_name, last_name, sex)
+			VALUES ('ATT-1', 'Test', 'Officer', 'Female')
+			RETURNING id
+		)
+		INSERT INTO session_enrollment (personnel_id, session_id, status)
+		SELECT id, $1, 'Enrolled' FROM officer
+		RETURNING id`, sessionID).Scan(&enrollmentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := func(attendedOn string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"attended_on": %q, "records": [{"session_enrollment_id": %d, "present": true}]}`, attendedOn, enrollmentID)
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/v1/sessions/%d/attendance", sessionID), strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+		return rr
+	}
+
+	tests := []struct {
+		name       string
+		attendedOn string
+		wantStatus int
+	}{
+		{"first day", today.AddDate(0, 0, -1).Format(time.DateOnly), http.StatusOK},
+		{"last day", today.Format(time.DateOnly), http.StatusOK},
+		{"before the session", today.AddDate(0, 0, -2).Format(time.DateOnly), http.StatusUnprocessableEntity},
+		{"after the session", today.AddDate(0, 0, 1).Format(time.DateOnly), http.StatusUnprocessableEntity},
+		{"timestamp", today.Add(15 * time.Hour).Format(time.RFC3339), http.StatusUnprocessableEntity},
+		{"missing", "", http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rr := record(tt.attendedOn); rr.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body)
+			}
+		})
+	}
+}
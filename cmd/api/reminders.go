@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// reminderBatchSize is the most feedback reminders sent in one run
+const reminderBatchSize = 200
+
+// feedbackLink is the page of the frontend where an officer gives feedback on an enrollment
+func (a *application) feedbackLink(enrollmentID int64) string {
+	return fmt.Sprintf("%s/enrollments/%d/feedback", strings.TrimRight(a.config.frontend.url, "/"), enrollmentID)
+}
+
+// sendFeedbackReminders emails the officers who completed a session which has
+// ended but have not yet rated the course or each of its facilitators. A
+// reminder is recorded before it is sent, so an officer is never sent more
+// than the configured number even if sending fails part way.
+func (a *application) sendFeedbackReminders(ctx context.Context) error {
+	cfg := a.config.reminders
+
+	reminders, err := a.models.Reminders.GetDue(ctx, cfg.max, cfg.gap, cfg.window, reminderBatchSize)
+	if err != nil {
+		return err
+	}
+
+	sent, failed := 0, 0
+
+	for _, reminder := range reminders {
+		// Leave the rest for the next run when the server is shutting down
+		if ctx.Err() != nil {
+			break
+		}
+
+		err := a.models.Reminders.Record(ctx, reminder.EnrollmentID)
+		if err != nil {
+			return err
+		}
+
+		data := map[string]any{
+			"firstName":    reminder.FirstName,
+			"courseTitle":  reminder.CourseTitle,
+			"location":     reminder.Location,
+			"endDate":      reminder.EndDate.Format("2 January 2006"),
+			"courseRated":  reminder.CourseRated,
+			"facilitators": reminder.Facilitators,
+			"link":         a.feedbackLink(reminder.EnrollmentID),
+		}
+
+		err = a.mailer.Send(reminder.Email, "feedback_reminder.tmpl", data)
+		if err != nil {
+			a.logger.Error("could not send feedback reminder", "enrollment_id", reminder.EnrollmentID, "error", err.Error())
+			failed++
+			continue
+		}
+		sent++
+	}
+
+	a.logger.Info("sent feedback reminders", "sent", sent, "failed", failed)
+
+	return nil
+}
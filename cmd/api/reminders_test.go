@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFeedbackLink(t *testing.T) {
+	app := &application{}
+	app.config.frontend.url = "https://training.example.org/"
+
+	want := "https://training.example.org/enrollments/42/feedback"
+	if got := app.feedbackLink(42); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestSendFeedbackRemindersRecordsBeforeSending(t *testing.T) {
+	app, db := newTestApplication(t)
+	app.config.reminders.max = 1
+	app.config.reminders.gap = time.Hour
+	app.config.reminders.window = 7 * 24 * time.Hour
+
+	ended := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -2)
+
+	var enrollmentID int64
+	err := db.QueryRow(`
+		WITH officer AS (
+			INSERT INTO personnel (regulation_number, first_name, last_name, sex)
+			VALUES ('REMIND-1', 'Test', 'Officer', 'Male')
+			RETURNING id
+		), course AS (
+			INSERT INTO courses (title, category, credit_hours)
+			VALUES ('Reminder Course', 'Elective', 4)
+			RETURNING id
+		), ts AS (
+			INSERT INTO training_sessions (course_id, start_date, end_date)
+			SELECT id, $1, $1 FROM course
+			RETURNING id
+		)
+		INSERT INTO session_enrollment (personnel_id, session_id, status)
+		SELECT officer.id, ts.id, 'Completed' FROM officer, ts
+		RETURNING id`, ended).Scan(&enrollmentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := newTestUser(t, db, "pa55word1234")
+	_, err = db.Exec(`
+		UPDATE users SET personnel_id = (SELECT personnel_id FROM session_enrollment WHERE id = $1)
+		WHERE id = $2`, enrollmentID, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The test mailer cannot deliver, yet the reminder must still count
+	for range 2 {
+		err = app.sendFeedbackReminders(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var sent int
+	err = db.QueryRow(`SELECT reminders_sent FROM feedback_reminders WHERE session_enrollment_id = $1`, enrollmentID).Scan(&sent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 1 {
+		t.Errorf("expected 1 reminder recorded, got %d", sent)
+	}
+}
@@ -28,6 +28,11 @@ func (app *application) routes() http.Handler {
 	router.HandlerFunc(http.MethodPatch, "/v1/officers/:id", app.requirePermission("officers:write", app.updateOfficerHandler))
 	router.HandlerFunc(http.MethodDelete, "/v1/officers/:id", app.requirePermission("officers:write", app.deleteOfficerHandler))
 	router.HandlerFunc(http.MethodGet, "/v1/officers", app.requirePermission("officers:read", app.listOfficersHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/officer-duplicates", app.requirePermission("officers:read", app.listOfficerDuplicatesHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/officers/:id/merge", app.requirePermission("officers:write", app.mergeOfficerHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/officers/:id/facilitator", app.requirePermission("facilitators:write", app.createFacilitatorFromOfficerHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/officers/:id/user", app.requirePermission("admin:all", app.linkOfficerUserHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/officers/:id/user", app.requirePermission("admin:all", app.unlinkOfficerUserHandler))
 
 	// facilitator routes
 	router.HandlerFunc(http.MethodPost, "/v1/facilitators", app.requirePermission("facilitators:write", app.createFacilitatorHandler))
@@ -38,6 +43,26 @@ func (app *application) routes() http.Handler {
 	router.HandlerFunc(http.MethodPost, "/v1/sessions/:id/facilitators", app.requirePermission("facilitators:write", app.assignFacilitatorToSessionHandler))
 	router.HandlerFunc(http.MethodGet, "/v1/sessions/:id/facilitators", app.requirePermission("facilitators:read", app.listFacilitatorsForSessionHandler))
 	router.HandlerFunc(http.MethodDelete, "/v1/sessions/:id/facilitators/:facilitator_id", app.requirePermission("facilitators:write", app.removeFacilitatorFromSessionHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/sessions/:id/facilitator-suggestions", app.requirePermission("facilitators:read", app.listFacilitatorSuggestionsHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/facilitators/:id/availability", app.requirePermission("facilitators:read", app.listFacilitatorAvailabilityHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/facilitators/:id/availability", app.requirePermission("facilitators:write", app.createFacilitatorAvailabilityHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/facilitators/:id/availability/:availability_id", app.requirePermission("facilitators:write", app.deleteFacilitatorAvailabilityHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/facilitators/:id/qualifications", app.requirePermission("facilitators:read", app.listFacilitatorQualificationsHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/facilitators/:id/qualifications", app.requirePermission("facilitators:write", app.createFacilitatorQualificationHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/facilitators/:id/qualifications/:qualification_id", app.requirePermission("facilitators:write", app.updateFacilitatorQualificationHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/facilitators/:id/qualifications/:qualification_id", app.requirePermission("facilitators:write", app.deleteFacilitatorQualificationHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/courses/:id/facilitators", app.requirePermission("facilitators:read", app.listCourseFacilitatorsHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/facilitators/:id/user", app.requirePermission("admin:all", app.linkFacilitatorUserHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/facilitators/:id/user", app.requirePermission("admin:all", app.unlinkFacilitatorUserHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/facilitators/:id/invitation", app.requirePermission("admin:all", app.createFacilitatorInvitationHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/facilitators/:id/invitation", app.requirePermission("admin:all", app.showFacilitatorInvitationHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/facilitators/:id/invitation", app.requirePermission("admin:all", app.revokeFacilitatorInvitationHandler))
+
+	// Session delivery routes, open to the facilitators assigned to the session
+	router.HandlerFunc(http.MethodGet, "/v1/sessions/:id/roster", app.requireSessionAccess("nits:read", app.showSessionRosterHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/sessions/:id/attendance", app.requireSessionAccess("nits:read", app.listSessionAttendanceHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/sessions/:id/attendance", app.requireSessionAccess("nits:write", app.recordSessionAttendanceHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/sessions/:id/enrollments/:enrollment_id", app.requireSessionAccess("nits:write", app.updateSessionEnrollmentHandler))
 
 	// Facilitator feedback routes
 	router.HandlerFunc(http.MethodPost, "/v1/facilitators/:id/feedback", app.requirePermission("feedback:write", app.createFacilitatorFeedbackHandler))
@@ -53,13 +78,29 @@ func (app *application) routes() http.Handler {
 	router.HandlerFunc(http.MethodPost, "/v1/courses/:id/feedback", app.requirePermission("feedback:write", app.createCourseFeedbackHandler))
 	router.HandlerFunc(http.MethodGet, "/v1/courses/:id/feedback", app.requirePermission("feedback:read", app.listCourseFeedbackHandler))
 
+	// Feedback moderation routes
+	router.HandlerFunc(http.MethodGet, "/v1/moderation/feedback", app.requirePermission("feedback:moderate", app.listFeedbackModerationQueueHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/moderation/feedback/:kind/:id", app.requirePermission("feedback:moderate", app.moderateFeedbackHandler))
+
+	// Evaluation survey routes
+	router.HandlerFunc(http.MethodPost, "/v1/surveys", app.requirePermission("courses:write", app.createSurveyHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/surveys", app.requirePermission("courses:read", app.listSurveysHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/surveys/:id", app.requirePermission("courses:read", app.showSurveyHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/surveys/:id", app.requirePermission("courses:write", app.deleteSurveyHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/surveys/:id/results", app.requirePermission("reports:read", app.showSurveyResultsHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/courses/:id/survey", app.requirePermission("courses:read", app.showCourseSurveyHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/courses/:id/survey", app.requirePermission("courses:write", app.attachCourseSurveyHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/courses/:id/survey", app.requirePermission("courses:write", app.detachCourseSurveyHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/courses/:id/survey-results", app.requirePermission("reports:read", app.showCourseSurveyResultsHandler))
+
 	// User routes
 	// router.HandlerFunc(http.MethodPost, "/v1/users", app.registerUserHandler)
 	// router.HandlerFunc(http.MethodPost, "/v1/tokens", app.createAuthenticationTokenHandler)
 
 	// Ratings and feedback routes
-	router.HandlerFunc(http.MethodPost, "/v1/enrollments/:id/courserating", app.requireActivatedUser(app.createCourseRatingHandler))
-	router.HandlerFunc(http.MethodPost, "/v1/enrollments/:id/facilitatorrating", app.requireActivatedUser(app.createFacilitatorRatingHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/enrollments/:id/courserating", app.requireLinkedOfficer(app.createCourseRatingHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/enrollments/:id/facilitatorrating", app.requireLinkedOfficer(app.createFacilitatorRatingHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/enrollments/:id/survey", app.requireLinkedOfficer(app.createSurveySubmissionHandler))
 
 	// TODO: Add more routes as you build your API
 	// router.HandlerFunc(http.MethodGet, "/v1/officers", app.listOfficersHandler)
@@ -74,10 +115,48 @@ func (app *application) routes() http.Handler {
 	// User authentication routes (public)
 	router.HandlerFunc(http.MethodPost, "/v1/users", app.registerUserHandler)
 	router.HandlerFunc(http.MethodPut, "/v1/users/activated", app.activateUserHandler)
+	router.HandlerFunc(http.MethodPut, "/v1/users/invitation", app.acceptInvitationHandler)
+	router.HandlerFunc(http.MethodPut, "/v1/users/password", app.updateUserPasswordHandler)
 	router.HandlerFunc(http.MethodPost, "/v1/tokens/authentication", app.createAuthenticationTokenHandler)
+	router.HandlerFunc(http.MethodPost, "/v1/tokens/password-reset", app.createPasswordResetTokenHandler)
+	router.HandlerFunc(http.MethodPost, "/v1/tokens/activation", app.createActivationTokenHandler)
+	router.HandlerFunc(http.MethodDelete, "/v1/tokens/authentication", app.requireAuthenticatedUser(app.deleteAuthenticationTokenHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/tokens/refresh", app.createRefreshedTokensHandler)
+
+	// Organisational hierarchy routes
+	router.HandlerFunc(http.MethodGet, "/v1/org-tree", app.requirePermission("reports:read", app.showOrgTreeHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/org-tree/regions/:id", app.requirePermission("reports:read", app.showOrgRegionHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/org-tree/formations/:id", app.requirePermission("reports:read", app.showOrgFormationHandler))
+
+	// Facilitator performance routes
+	router.HandlerFunc(http.MethodGet, "/v1/facilitators/:id/analytics", app.requirePermission("reports:read", app.showFacilitatorAnalyticsHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/reports/facilitators/leaderboard", app.requirePermission("reports:read", app.showFacilitatorLeaderboardHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/reports/facilitators/workload", app.requirePermission("reports:read", app.showFacilitatorWorkloadHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/facilitators/:id/feedback/analysis", app.requirePermission("reports:read", app.showFacilitatorCommentAnalysisHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/courses/:id/feedback/analysis", app.requirePermission("reports:read", app.showCourseCommentAnalysisHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/courses/:id/evaluation", app.requirePermission("reports:read", app.showCourseEvaluationHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/sessions/:id/evaluation", app.requirePermission("reports:read", app.showSessionEvaluationHandler))
+
+	// Self-service routes for the authenticated user
+	router.HandlerFunc(http.MethodGet, "/v1/me", app.requireActivatedUser(app.showCurrentUserHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/me/enrollments", app.requireLinkedOfficer(app.listCurrentUserEnrollmentsHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/me/enrollments", app.requireLinkedOfficer(app.createCurrentUserEnrollmentHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/me/transcript", app.requireLinkedOfficer(app.showCurrentUserTranscriptHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/me/sessions", app.requireLinkedFacilitator(app.listCurrentUserSessionsHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/me/tokens", app.requireAuthenticatedUser(app.listCurrentUserTokensHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/me/tokens", app.requireAuthenticatedUser(app.deleteCurrentUserTokensHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/me/tokens/:token_id", app.requireAuthenticatedUser(app.deleteCurrentUserTokenHandler))
 
 	// Permissions routes
 	router.HandlerFunc(http.MethodPost, "/v1/users/:id/permissions", app.requirePermission("admin:all", app.addUserPermissionHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/users/:id/role", app.requirePermission("admin:all", app.updateUserRoleHandler))
+
+	// Session management routes
+	router.HandlerFunc(http.MethodGet, "/v1/users/:id/tokens", app.requirePermission("admin:all", app.listUserTokensHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/users/:id/tokens", app.requirePermission("admin:all", app.deleteUserTokensHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/users/:id/tokens/:token_id", app.requirePermission("admin:all", app.deleteUserTokenHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/users/:id/lockout", app.requirePermission("admin:all", app.unlockUserHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/login-attempts", app.requirePermission("admin:all", app.listLoginAttemptsHandler))
 
 	//Middleware chain
 	// 1. recoverPanic - catches any panics and returns 500
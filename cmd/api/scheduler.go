@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// schedulerPollInterval is how often each scheduled job checks whether it is due
+const schedulerPollInterval = time.Minute
+
+// schedule runs fn in the background once every interval until ctx is
+// cancelled. When and whether the job is due is kept in the database, so it
+// runs once per interval across restarts and however many instances are running.
+func (a *application) schedule(ctx context.Context, name string, interval time.Duration, fn func(context.Context) error) {
+	err := a.models.Jobs.Register(name)
+	if err != nil {
+		a.logger.Error("could not register scheduled job", "job", name, "error", err.Error())
+		return
+	}
+
+	a.background(func() {
+		ticker := time.NewTicker(schedulerPollInterval)
+		defer ticker.Stop()
+
+		for {
+			a.runJob(ctx, name, interval, fn)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// runJob runs fn if this instance can claim the job, recording how it ended. A
+// panic is recovered and recorded as an error so the job runs again next time.
+func (a *application) runJob(ctx context.Context, name string, interval time.Duration, fn func(context.Context) error) {
+	claimed, err := a.models.Jobs.Claim(ctx, name, interval)
+	if err != nil {
+		if ctx.Err() == nil {
+			a.logger.Error("could not claim scheduled job", "job", name, "error", err.Error())
+		}
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	a.logger.Info("running scheduled job", "job", name)
+
+	func() {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("%v", p)
+			}
+		}()
+		err = fn(ctx)
+	}()
+
+	if err != nil {
+		a.logger.Error("scheduled job failed", "job", name, "error", err.Error())
+	}
+
+	err = a.models.Jobs.Finish(name, err)
+	if err != nil {
+		a.logger.Error("could not record scheduled job", "job", name, "error", err.Error())
+	}
+}
+
+// startJobs schedules the periodic jobs which are enabled
+func (a *application) startJobs(ctx context.Context) {
+	a.schedule(ctx, "token-cleanup", time.Hour, a.deleteExpiredTokens)
+
+	if a.config.reminders.enabled {
+		a.schedule(ctx, "feedback-reminders", a.config.reminders.interval, a.sendFeedbackReminders)
+	}
+}
+
+// deleteExpiredTokens removes tokens which can no longer be used, including
+// spent refresh tokens once they can no longer be reused
+func (a *application) deleteExpiredTokens(ctx context.Context) error {
+	deleted, err := a.models.Tokens.DeleteExpired(ctx)
+	if err != nil {
+		return err
+	}
+
+	a.logger.Info("deleted expired tokens", "deleted", deleted)
+
+	return nil
+}
@@ -34,6 +34,11 @@ func (a *application) serve() error {
 
 	shutdownError := make(chan error)
 
+	// Scheduled jobs run until the server starts shutting down
+	jobs, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	a.startJobs(jobs)
+
 	go func() {
 		quit := make(chan os.Signal, 1)
 		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -41,6 +46,8 @@ func (a *application) serve() error {
 
 		a.logger.Info("shutting down server", "signal", s.String())
 
+		stopJobs()
+
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Syha-01/national-inservice-training/internal/data"
+)
+
+// deleteAuthenticationTokenHandler logs out by revoking the authentication
+// token the request was made with, and the refresh token which renews it
+func (a *application) deleteAuthenticationTokenHandler(w http.ResponseWriter, r *http.Request) {
+	session := a.contextGetSession(r)
+
+	err := a.models.Tokens.DeleteSession(session.UserID, session.ID)
+	if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"message": "you have been logged out"}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// writeSessions lists the active authentication tokens of a user, marking the
+// one the request was made with
+func (a *application) writeSessions(w http.ResponseWriter, r *http.Request, userID int64) {
+	sessions, err := a.models.Tokens.GetSessionsForUser(userID)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if current := a.contextGetSession(r); current != nil {
+		for _, session := range sessions {
+			session.Current = session.ID == current.ID
+		}
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"tokens": sessions}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// deleteSession revokes one of a user's authentication tokens
+func (a *application) deleteSession(w http.ResponseWriter, r *http.Request, userID int64) {
+	id, err := a.readNamedIDParam(r, "token_id")
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	err = a.models.Tokens.DeleteSession(userID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"message": "token successfully revoked"}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// deleteAllSessions logs a user out everywhere by revoking all of their
+// authentication and refresh tokens
+func (a *application) deleteAllSessions(w http.ResponseWriter, r *http.Request, userID int64) {
+	err := a.models.Tokens.DeleteSessionsForUser(userID)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"message": "all tokens successfully revoked"}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// listCurrentUserTokensHandler lists the active sessions of the authenticated user
+func (a *application) listCurrentUserTokensHandler(w http.ResponseWriter, r *http.Request) {
+	a.writeSessions(w, r, a.contextGetUser(r).ID)
+}
+
+// deleteCurrentUserTokenHandler revokes one of the authenticated user's sessions
+func (a *application) deleteCurrentUserTokenHandler(w http.ResponseWriter, r *http.Request) {
+	a.deleteSession(w, r, a.contextGetUser(r).ID)
+}
+
+// deleteCurrentUserTokensHandler logs the authenticated user out everywhere,
+// including the session the request was made with
+func (a *application) deleteCurrentUserTokensHandler(w http.ResponseWriter, r *http.Request) {
+	a.deleteAllSessions(w, r, a.contextGetUser(r).ID)
+}
+
+// listUserTokensHandler lists the active sessions of any user
+func (a *application) listUserTokensHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	a.writeSessions(w, r, id)
+}
+
+// deleteUserTokenHandler revokes one of any user's sessions
+func (a *application) deleteUserTokenHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	a.deleteSession(w, r, id)
+}
+
+// deleteUserTokensHandler logs any user out everywhere
+func (a *application) deleteUserTokensHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	a.deleteAllSessions(w, r, id)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/data"
+)
+
+func TestSessionHandlers(t *testing.T) {
+	app, db := newTestApplication(t)
+	routes := app.routes()
+
+	user := newTestUser(t, db, "pa55word1234")
+	other := newTestUser(t, db, "pa55word1234")
+
+	// login starts a session and returns its access token and id
+	login := func(userID int64) (string, int64) {
+		t.Helper()
+
+		access, _, err := app.models.Tokens.NewSession(userID, time.Hour, 24*time.Hour, "203.0.113.7", "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, session, err := app.models.Users.GetForAuthenticationToken(access.Plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return access.Plaintext, session.ID
+	}
+
+	do := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// expectSignedOut checks a token is no longer accepted
+	expectSignedOut := func(token string) {
+		t.Helper()
+		if rr := do(http.MethodGet, "/v1/me/tokens", token); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected the token to be revoked, got status %d", rr.Code)
+		}
+	}
+
+	t.Run("current session is marked", func(t *testing.T) {
+		token, id := login(user.ID)
+		_, otherID := login(user.ID)
+
+		rr := do(http.MethodGet, "/v1/me/tokens", token)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+
+		var response struct {
+			Tokens []data.Session `json:"tokens"`
+		}
+		err := json.Unmarshal(rr.Body.Bytes(), &response)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		current := map[int64]bool{}
+		for _, session := range response.Tokens {
+			current[session.ID] = session.Current
+		}
+		if !current[id] {
+			t.Errorf("expected session %d to be current", id)
+		}
+		if flag, ok := current[otherID]; !ok || flag {
+			t.Errorf("expected session %d to be listed and not current", otherID)
+		}
+	})
+
+	t.Run("revoke one session", func(t *testing.T) {
+		token, _ := login(user.ID)
+		revoked, revokedID := login(user.ID)
+
+		rr := do(http.MethodDelete, fmt.Sprintf("/v1/me/tokens/%d", revokedID), token)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+
+		expectSignedOut(revoked)
+		if rr := do(http.MethodGet, "/v1/me/tokens", token); rr.Code != http.StatusOK {
+			t.Errorf("expected the other session to keep working, got status %d", rr.Code)
+		}
+	})
+
+	t.Run("cannot revoke another user's session", func(t *testing.T) {
+		token, _ := login(user.ID)
+		otherToken, otherID := login(other.ID)
+
+		rr := do(http.MethodDelete, fmt.Sprintf("/v1/me/tokens/%d", otherID), token)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, rr.Code)
+		}
+
+		if rr := do(http.MethodGet, "/v1/me/tokens", otherToken); rr.Code != http.StatusOK {
+			t.Errorf("expected the other user's session to keep working, got status %d", rr.Code)
+		}
+	})
+
+	t.Run("logout", func(t *testing.T) {
+		token, _ := login(user.ID)
+		remaining, _ := login(user.ID)
+
+		rr := do(http.MethodDelete, "/v1/tokens/authentication", token)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+
+		expectSignedOut(token)
+		if rr := do(http.MethodGet, "/v1/me/tokens", remaining); rr.Code != http.StatusOK {
+			t.Errorf("expected other sessions to keep working, got status %d", rr.Code)
+		}
+	})
+
+	t.Run("log out everywhere", func(t *testing.T) {
+		token, _ := login(user.ID)
+		another, _ := login(user.ID)
+		otherToken, _ := login(other.ID)
+
+		rr := do(http.MethodDelete, "/v1/me/tokens", token)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+
+		expectSignedOut(token)
+		expectSignedOut(another)
+		if rr := do(http.MethodGet, "/v1/me/tokens", otherToken); rr.Code != http.StatusOK {
+			t.Errorf("expected other users to stay logged in, got status %d", rr.Code)
+		}
+	})
+}
@@ -0,0 +1,425 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Syha-01/national-inservice-training/internal/data"
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+// createSurveyHandler creates a survey template along with its questions
+func (a *application) createSurveyHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Questions   []struct {
+			Kind     string   `json:"kind"`
+			Prompt   string   `json:"prompt"`
+			Options  []string `json:"options"`
+			Required *bool    `json:"required"`
+		} `json:"questions"`
+	}
+
+	err := a.readJSON(w, r, &input)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	survey := &data.Survey{
+		Title:       input.Title,
+		Description: input.Description,
+		Questions:   []*data.SurveyQuestion{},
+	}
+
+	for _, q := range input.Questions {
+		question := &data.SurveyQuestion{
+			Kind:     q.Kind,
+			Prompt:   q.Prompt,
+			Options:  q.Options,
+			Required: true,
+		}
+		if q.Required != nil {
+			question.Required = *q.Required
+		}
+		survey.Questions = append(survey.Questions, question)
+	}
+
+	v := validator.New()
+
+	if data.ValidateSurvey(v, survey); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = a.models.Surveys.Insert(survey)
+	if err != nil {
+		switch {
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/surveys/%d", survey.ID))
+
+	err = a.writeJSON(w, http.StatusCreated, envelope{"survey": survey}, headers)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// listSurveysHandler returns a page of survey templates
+func (a *application) listSurveysHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		data.Filters
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	input.Filters.Page = a.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = a.readInt(qs, "page_size", 20, v)
+
+	if data.ValidateFilters(v, input.Filters); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	surveys, metadata, err := a.models.Surveys.GetAll(input.Filters)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"surveys": surveys, "metadata": metadata}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// showSurveyHandler returns a survey template along with its questions
+func (a *application) showSurveyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	survey, err := a.models.Surveys.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"survey": survey}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// deleteSurveyHandler deletes a survey template which has not been answered yet
+func (a *application) deleteSurveyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	err = a.models.Surveys.Delete(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"message": "survey successfully deleted"}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// showSurveyResultsHandler aggregates the answers given to a survey question by
+// question, optionally only for the sessions of one course
+func (a *application) showSurveyResultsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+
+	courseID := a.readInt(r.URL.Query(), "course_id", 0, v)
+	if v.Check(courseID >= 0, "course_id", "must not be negative"); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	survey, err := a.models.Surveys.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	results, err := a.models.Surveys.GetResults(survey, int64(courseID), a.config.feedback.minResponses)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"results": results}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// showCourseSurveyHandler returns the survey attached to a course
+func (a *application) showCourseSurveyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	survey, err := a.models.Surveys.GetForCourse(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"survey": survey}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// attachCourseSurveyHandler sets the survey officers answer for a course
+func (a *application) attachCourseSurveyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		SurveyID int64 `json:"survey_id"`
+	}
+
+	err = a.readJSON(w, r, &input)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	if v.Check(input.SurveyID > 0, "survey_id", "must be provided"); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = a.models.Surveys.SetForCourse(id, input.SurveyID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	survey, err := a.models.Surveys.Get(input.SurveyID)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"survey": survey}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// detachCourseSurveyHandler removes the survey from a course
+func (a *application) detachCourseSurveyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	err = a.models.Surveys.SetForCourse(id, 0)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"message": "survey successfully removed from the course"}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// showCourseSurveyResultsHandler returns the results of the survey attached to
+// a course alongside its single-score ratings, which are read as legacy surveys
+func (a *application) showCourseSurveyResultsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	course, err := a.models.Courses.GetCourse(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	if course == nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	var results *data.SurveyResults
+
+	survey, err := a.models.Surveys.GetForCourse(id)
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+	case err != nil:
+		a.serverErrorResponse(w, r, err)
+		return
+	default:
+		results, err = a.models.Surveys.GetResults(survey, id, a.config.feedback.minResponses)
+		if err != nil {
+			a.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	legacy, err := a.models.Surveys.GetLegacyResults(id, a.config.feedback.minResponses)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"results": results, "legacy": legacy}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// createSurveySubmissionHandler records the answers an officer gives to the
+// survey of the course they were enrolled in
+func (a *application) createSurveySubmissionHandler(w http.ResponseWriter, r *http.Request) {
+	enrollmentID, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Answers []*data.SurveyAnswer `json:"answers"`
+	}
+
+	err = a.readJSON(w, r, &input)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	eligibility, err := a.models.Feedback.GetEligibility(enrollmentID, 0)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if !a.checkFeedbackEligibility(w, r, eligibility, 0, 0) {
+		return
+	}
+
+	survey, err := a.models.Surveys.GetForCourse(eligibility.CourseID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.failedValidationResponse(w, r, map[string]string{"survey": "the course of this enrollment has no survey"})
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	v := validator.New()
+
+	if data.ValidateSurveyAnswers(v, survey, input.Answers); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	submission := &data.SurveySubmission{
+		SurveyID:            survey.ID,
+		SessionEnrollmentID: enrollmentID,
+		Answers:             input.Answers,
+	}
+
+	err = a.models.Surveys.InsertSubmission(submission)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateRecord):
+			a.conflictResponse(w, r, map[string]string{"survey": "this survey has already been answered for the enrollment"})
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusCreated, envelope{"submission": submission}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/data"
+	"github.com/Syha-01/national-inservice-training/internal/mailer"
+	"github.com/Syha-01/national-inservice-training/internal/testdb"
+)
+
+// newTestApplication returns an application backed by a freshly migrated test
+// database. Its mailer points at a closed port, so every email fails to send.
+func newTestApplication(t *testing.T) (*application, *sql.DB) {
+	t.Helper()
+
+	db := testdb.Open(t)
+
+	app := &application{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		models: data.NewModels(db),
+		mailer: mailer.New("127.0.0.1", 1, "", "", "Training <no-reply@example.com>"),
+	}
+	app.config.env = "testing"
+
+	// Background emails must finish before the database is dropped
+	t.Cleanup(app.wg.Wait)
+
+	return app, db
+}
+
+// fixtureSeq keeps the unique values of fixture rows apart.
+var fixtureSeq atomic.Int64
+
+// newTestUser creates an activated user with the given password.
+func newTestUser(t *testing.T, db *sql.DB, plaintextPassword string) *data.User {
+	t.Helper()
+
+	user := &data.User{
+		Email:     fmt.Sprintf("user%d@example.com", fixtureSeq.Add(1)),
+		Activated: true,
+		RoleID:    3,
+	}
+
+	err := user.Password.Set(plaintextPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = data.UserModel{DB: db}.Insert(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return user
+}
+
+// newTestAdmin creates an activated user with the Administrator role.
+func newTestAdmin(t *testing.T, db *sql.DB) *data.User {
+	t.Helper()
+
+	user := newTestUser(t, db, "pa55word1234")
+
+	err := db.QueryRow(`
+		UPDATE users SET role_id = (SELECT id FROM roles WHERE name = 'Administrator')
+		WHERE id = $1
+		RETURNING role_id`, user.ID).Scan(&user.RoleID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return user
+}
+
+// loginTestUser starts a session for the user and returns its access token.
+func loginTestUser(t *testing.T, app *application, userID int64) string {
+	t.Helper()
+
+	access, _, err := app.models.Tokens.NewSession(userID, time.Hour, 24*time.Hour, "203.0.113.7", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return access.Plaintext
+}
@@ -35,6 +35,24 @@ func (a *application) createAuthenticationTokenHandler(w http.ResponseWriter, r
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
+			a.recordLoginAttempt(r, nil, input.Email, data.LoginUnknownEmail)
+			a.invalidCredentialsResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// The attempt is counted before the password is checked, so concurrent
+	// guesses cannot get past the lockout. The password of a locked account is
+	// not checked at all, so it cannot be guessed. Every refusal gets the same
+	// response as an unknown email address, so the lockout does not reveal which
+	// addresses have accounts; the owner is emailed instead.
+	lockedUntil, err := a.models.Logins.ClaimAttempt(user.ID, a.lockoutPolicy())
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrAccountLocked):
+			a.recordLoginAttempt(r, user, input.Email, data.LoginLocked)
 			a.invalidCredentialsResponse(w, r)
 		default:
 			a.serverErrorResponse(w, r, err)
@@ -49,19 +67,224 @@ func (a *application) createAuthenticationTokenHandler(w http.ResponseWriter, r
 	}
 
 	if !match {
+		if lockedUntil == nil {
+			a.recordLoginAttempt(r, user, input.Email, data.LoginInvalidPassword)
+		} else {
+			a.recordLoginAttempt(r, user, input.Email, data.LoginLockedOut)
+			a.sendLockoutEmail(r, user, *lockedUntil)
+		}
 		a.invalidCredentialsResponse(w, r)
 		return
 	}
 
-	// Generate authentication token valid for 24 hours
-	token, err := a.models.Tokens.New(user.ID, 24*time.Hour, data.ScopeAuthentication)
+	// A successful login clears the attempt, and the lock it may have taken
+	err = a.models.Logins.ResetFailures(user.ID)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	a.recordLoginAttempt(r, user, input.Email, data.LoginSucceeded)
+
+	// Generate a short-lived authentication token and the refresh token which renews it
+	token, refresh, err := a.models.Tokens.NewSession(user.ID, a.config.tokens.accessTTL, a.config.tokens.refreshTTL, a.clientIP(r), r.UserAgent())
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusCreated, envelope{"authentication_token": token, "refresh_token": refresh}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// createPasswordResetTokenHandler emails a password reset token to the user
+// with the given email address. The response is the same whether or not such
+// a user exists, so it cannot be used to find out which addresses have accounts.
+func (a *application) createPasswordResetTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Email string `json:"email"`
+	}
+
+	err := a.readJSON(w, r, &input)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	if data.ValidateEmail(v, input.Email); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	// The account is looked up after responding, so the response takes as long
+	// whether or not the email address belongs to anyone
+	a.background(func() {
+		err := a.sendPasswordReset(input.Email)
+		if err != nil {
+			a.logger.Error(err.Error())
+		}
+	})
+
+	env := envelope{"message": "if an account exists for this email address, you will be sent an email with instructions to reset your password"}
+
+	err = a.writeJSON(w, http.StatusAccepted, env, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// sendPasswordReset emails a password reset token to the owner of an account,
+// replacing any token sent before. Only an activated account can sign in, so
+// nothing is sent for an unknown or unactivated one.
+func (a *application) sendPasswordReset(email string) error {
+	user, err := a.models.Users.GetByEmail(email)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			return nil
+		default:
+			return err
+		}
+	}
+
+	if !user.Activated {
+		return nil
+	}
+
+	err = a.models.Tokens.DeleteAllForUser(data.ScopePasswordReset, user.ID)
+	if err != nil {
+		return err
+	}
+
+	token, err := a.models.Tokens.New(user.ID, a.config.passwordReset.ttl, data.ScopePasswordReset)
+	if err != nil {
+		return err
+	}
+
+	return a.mailer.Send(user.Email, "password_reset.tmpl", map[string]any{
+		"passwordResetToken": token.Plaintext,
+		"expiry":             token.Expiry.Format("2 January 2006 at 15:04 MST"),
+	})
+}
+
+// createRefreshedTokensHandler exchanges a refresh token for a new
+// authentication token and refresh token. Presenting a refresh token which has
+// already been exchanged logs out the session it belongs to.
+func (a *application) createRefreshedTokensHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	err := a.readJSON(w, r, &input)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	// The token is validated like any other, but reported under its own field
+	data.ValidateTokenPlaintext(v, input.RefreshToken)
+	if message, ok := v.Errors["token"]; ok {
+		a.failedValidationResponse(w, r, map[string]string{"refresh_token": message})
+		return
+	}
+
+	token, refresh, err := a.models.Tokens.Refresh(input.RefreshToken, a.config.tokens.accessTTL, a.config.tokens.refreshTTL, a.clientIP(r), r.UserAgent())
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRefreshTokenReused):
+			a.logger.Warn("refresh token reused, session revoked", "client_ip", a.clientIP(r))
+			a.invalidRefreshTokenResponse(w, r)
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.invalidRefreshTokenResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusCreated, envelope{"authentication_token": token, "refresh_token": refresh}, nil)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// createActivationTokenHandler sends a new activation token to a user whose
+// welcome email was lost or whose token has expired. The response is the same
+// whether or not the address belongs to an account waiting to be activated,
+// and only a few emails are sent to an address within a window.
+func (a *application) createActivationTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Email string `json:"email"`
+	}
+
+	err := a.readJSON(w, r, &input)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	if data.ValidateEmail(v, input.Email); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	err = a.writeJSON(w, http.StatusCreated, envelope{"authentication_token": token}, nil)
+	env := envelope{"message": "if an account waiting to be activated exists for this email address, you will be sent an email with activation instructions"}
+
+	// The account is looked up after responding, so the response takes as long
+	// whether or not the email address belongs to anyone
+	if a.activationLimiter.Allow(input.Email, time.Now()) {
+		a.background(func() {
+			err := a.sendActivation(input.Email)
+			if err != nil {
+				a.logger.Error(err.Error())
+			}
+		})
+	}
+
+	err = a.writeJSON(w, http.StatusAccepted, env, nil)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 	}
 }
+
+// sendActivation emails a new activation token to the owner of an account
+// waiting to be activated, replacing any token sent before. Nothing is sent for
+// an unknown or already activated account.
+func (a *application) sendActivation(email string) error {
+	user, err := a.models.Users.GetByEmail(email)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			return nil
+		default:
+			return err
+		}
+	}
+
+	if user.Activated {
+		return nil
+	}
+
+	err = a.models.Tokens.DeleteAllForUser(data.ScopeActivation, user.ID)
+	if err != nil {
+		return err
+	}
+
+	token, err := a.models.Tokens.New(user.ID, activationTokenTTL, data.ScopeActivation)
+	if err != nil {
+		return err
+	}
+
+	return a.mailer.Send(user.Email, "user_welcome.tmpl", map[string]any{
+		"activationToken": token.Plaintext,
+		"userID":          user.ID,
+	})
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/data"
+)
+
+func TestCreatePasswordResetTokenHandler(t *testing.T) {
+	app, db := newTestApplication(t)
+	app.config.passwordReset.ttl = time.Hour
+
+	user := newTestUser(t, db, "pa55word1234")
+
+	request := func(email string) *httptest.ResponseRecorder {
+		body := strings.NewReader(`{"email": "` + email + `"}`)
+		req := httptest.NewRequest(http.MethodPost, "/v1/tokens/password-reset", body)
+		rr := httptest.NewRecorder()
+		app.createPasswordResetTokenHandler(rr, req)
+		return rr
+	}
+
+	first := request(user.Email)
+	second := request(user.Email)
+	missing := request("nobody@example.com")
+
+	for _, rr := range []*httptest.ResponseRecorder{first, second, missing} {
+		if rr.Code != http.StatusAccepted {
+			t.Errorf("expected status %d, got %d", http.StatusAccepted, rr.Code)
+		}
+	}
+	if missing.Body.String() != first.Body.String() {
+		t.Errorf("expected the same response for an unknown address, got %s and %s", missing.Body, first.Body)
+	}
+
+	app.wg.Wait()
+
+	var tokens int
+	err := db.QueryRow(`SELECT COUNT(*) FROM tokens WHERE user_id = $1 AND scope = $2`,
+		user.ID, data.ScopePasswordReset).Scan(&tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens != 1 {
+		t.Errorf("expected only the latest password reset token to be kept, found %d", tokens)
+	}
+}
+
+func TestCreateActivationTokenHandler(t *testing.T) {
+	app, db := newTestApplication(t)
+	app.activationLimiter = newEmailLimiter(2, time.Hour)
+
+	user := newTestUser(t, db, "pa55word1234")
+	_, err := db.Exec(`UPDATE users SET activated = false WHERE id = $1`, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(email string) *httptest.ResponseRecorder {
+		body := strings.NewReader(`{"email": "` + email + `"}`)
+		req := httptest.NewRequest(http.MethodPost, "/v1/tokens/activation", body)
+		rr := httptest.NewRecorder()
+		app.createActivationTokenHandler(rr, req)
+		return rr
+	}
+
+	first := request(user.Email)
+	second := request(user.Email)
+	limited := request(user.Email)
+	missing := request("nobody@example.com")
+
+	for _, rr := range []*httptest.ResponseRecorder{first, second, limited, missing} {
+		if rr.Code != http.StatusAccepted {
+			t.Errorf("expected status %d, got %d", http.StatusAccepted, rr.Code)
+		}
+		if rr.Body.String() != first.Body.String() {
+			t.Errorf("expected the same response every time, got %s and %s", rr.Body, first.Body)
+		}
+	}
+
+	app.wg.Wait()
+
+	var tokens int
+	err = db.QueryRow(`SELECT COUNT(*) FROM tokens WHERE user_id = $1 AND scope = $2`,
+		user.ID, data.ScopeActivation).Scan(&tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens != 1 {
+		t.Errorf("expected only the latest activation token to be kept, found %d", tokens)
+	}
+}
+
+func TestCreateAuthenticationTokenHandlerLockout(t *testing.T) {
+	app, db := newTestApplication(t)
+	app.config.login.maxAttempts = 2
+	app.config.login.lockout = time.Minute
+	app.config.login.maxLockout = time.Hour
+
+	user := newTestUser(t, db, "pa55word1234")
+
+	login := func(email, password string) *httptest.ResponseRecorder {
+		body := strings.NewReader(`{"email": "` + email + `", "password": "` + password + `"}`)
+		req := httptest.NewRequest(http.MethodPost, "/v1/tokens/authentication", body)
+		rr := httptest.NewRecorder()
+		app.createAuthenticationTokenHandler(rr, req)
+		return rr
+	}
+
+	for range 2 {
+		if rr := login(user.Email, "wr0ngpassword"); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("expected status %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+	}
+
+	locked := login(user.Email, "pa55word1234")
+	unknown := login("nobody@example.com", "pa55word1234")
+
+	if locked.Code != unknown.Code || locked.Body.String() != unknown.Body.String() {
+		t.Errorf("expected a locked account to look like an unknown one, got %d %s and %d %s",
+			locked.Code, locked.Body, unknown.Code, unknown.Body)
+	}
+	if retryAfter := locked.Header().Get("Retry-After"); retryAfter != "" {
+		t.Errorf("expected no Retry-After header, got %q", retryAfter)
+	}
+
+	rows, err := db.Query(`SELECT result FROM login_attempts WHERE user_id = $1 ORDER BY id`, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	results := []string{}
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{data.LoginInvalidPassword, data.LoginLockedOut, data.LoginLocked}
+	if !slices.Equal(results, want) {
+		t.Errorf("expected login attempts %v, got %v", want, results)
+	}
+}
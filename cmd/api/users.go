@@ -10,12 +10,17 @@ import (
 	"github.com/Syha-01/national-inservice-training/internal/validator"
 )
 
+// activationTokenTTL is how long an activation token lasts, as stated in user_welcome.tmpl
+const activationTokenTTL = 3 * 24 * time.Hour
+
+// registerUserHandler creates a System User account and emails it an activation
+// token. The officer an account belongs to and its role are set afterwards by an
+// administrator, so a client cannot claim another officer's records or a role
+// with more permissions.
 func (a *application) registerUserHandler(w http.ResponseWriter, r *http.Request) {
 	var input struct {
-		Email       string        `json:"email"`
-		Password    string        `json:"password"`
-		PersonnelID sql.NullInt64 `json:"personnel_id"`
-		RoleID      int           `json:"role_id"` // Add this - allow client to specify role
+		Email    string `json:"email"`
+		Password string `json:"password"`
 	}
 
 	err := a.readJSON(w, r, &input)
@@ -24,16 +29,10 @@ func (a *application) registerUserHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// Set default role if not provided (e.g., 3 = "system_user")
-	if input.RoleID == 0 {
-		input.RoleID = 3 // Default to system_user role
-	}
-
 	user := &data.User{
-		Email:       input.Email,
-		Activated:   false,
-		PersonnelID: input.PersonnelID,
-		RoleID:      input.RoleID, // Add this
+		Email:     input.Email,
+		Activated: false,
+		RoleID:    3, // System User
 	}
 
 	err = user.Password.Set(input.Password)
@@ -62,9 +61,10 @@ func (a *application) registerUserHandler(w http.ResponseWriter, r *http.Request
 	err = a.models.Users.Insert(user)
 	if err != nil {
 		switch {
-		case errors.Is(err, data.ErrDuplicateEmail):
-			v.AddError("email", "a user with this email address already exists")
-			a.failedValidationResponse(w, r, v.Errors)
+		case data.ViolatesConstraint(err, "users_email_key"):
+			a.conflictResponse(w, r, map[string]string{"email": "a user with this email address already exists"})
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
 		default:
 			a.serverErrorResponse(w, r, err)
 		}
@@ -73,12 +73,17 @@ func (a *application) registerUserHandler(w http.ResponseWriter, r *http.Request
 
 	err = a.models.Permissions.AddForUser(user.ID, "nits:read")
 	if err != nil {
-		a.serverErrorResponse(w, r, err)
+		switch {
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
 	// Generate an activation token
-	token, err := a.models.Tokens.New(user.ID, 3*24*time.Hour, data.ScopeActivation)
+	token, err := a.models.Tokens.New(user.ID, activationTokenTTL, data.ScopeActivation)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 		return
@@ -138,6 +143,8 @@ func (a *application) activateUserHandler(w http.ResponseWriter, r *http.Request
 		switch {
 		case errors.Is(err, data.ErrEditConflict):
 			a.editConflictResponse(w, r)
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
 		default:
 			a.serverErrorResponse(w, r, err)
 		}
@@ -156,3 +163,202 @@ func (a *application) activateUserHandler(w http.ResponseWriter, r *http.Request
 		a.serverErrorResponse(w, r, err)
 	}
 }
+
+// updateUserPasswordHandler sets a new password for the user a password reset
+// token was issued to, and signs them out everywhere
+func (a *application) updateUserPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Password       string `json:"password"`
+		TokenPlaintext string `json:"token"`
+	}
+
+	err := a.readJSON(w, r, &input)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	data.ValidatePasswordPlaintext(v, input.Password)
+	data.ValidateTokenPlaintext(v, input.TokenPlaintext)
+
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	_, err = a.models.Users.ResetPassword(input.TokenPlaintext, input.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("token", "invalid or expired password reset token")
+			a.failedValidationResponse(w, r, v.Errors)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"message": "your password was successfully reset"}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// linkOfficerUserHandler links an officer to the user account they sign in
+// with, giving it their enrollments and transcript under /v1/me. Only an
+// administrator can set the link, once they have checked whose account it is.
+func (a *application) linkOfficerUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		UserID int64 `json:"user_id"`
+	}
+
+	err = a.readJSON(w, r, &input)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	officer, err := a.models.Officers.GetOfficer(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	v := validator.New()
+
+	user, err := a.models.Users.Get(input.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("user_id", "user does not exist")
+			a.failedValidationResponse(w, r, v.Errors)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	user.PersonnelID = sql.NullInt64{Int64: officer.ID, Valid: true}
+
+	err = a.models.Users.Update(user)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			a.editConflictResponse(w, r)
+		case data.ViolatesConstraint(err, "users_personnel_id_key"):
+			a.conflictResponse(w, r, map[string]string{"personnel_id": "the officer is already linked to another user account"})
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"user": user}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// unlinkOfficerUserHandler removes the link between an officer and their user account
+func (a *application) unlinkOfficerUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	err = a.models.Users.UnlinkOfficer(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"message": "officer successfully unlinked from user account"}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// updateUserRoleHandler gives a user account a different role
+func (a *application) updateUserRoleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		RoleID int `json:"role_id"`
+	}
+
+	err = a.readJSON(w, r, &input)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	user, err := a.models.Users.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	v := validator.New()
+
+	_, err = a.models.Roles.Get(input.RoleID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("role_id", "role does not exist")
+			a.failedValidationResponse(w, r, v.Errors)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	user.RoleID = input.RoleID
+
+	err = a.models.Users.Update(user)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			a.editConflictResponse(w, r)
+		case data.IsConstraintViolation(err):
+			a.constraintViolationResponse(w, r, err)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"user": user}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
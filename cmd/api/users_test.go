@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegisterUserHandler(t *testing.T) {
+	app, db := newTestApplication(t)
+
+	register := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/users", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		app.registerUserHandler(rr, req)
+		return rr
+	}
+
+	for name, body := range map[string]string{
+		"officer": `{"email": "claim@example.com", "password": "pa55word1234", "personnel_id": 1}`,
+		"role":    `{"email": "claim@example.com", "password": "pa55word1234", "role_id": 1}`,
+	} {
+		if rr := register(body); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected choosing the %s to be refused with status %d, got %d", name, http.StatusBadRequest, rr.Code)
+		}
+	}
+
+	rr := register(`{"email": "new@example.com", "password": "pa55word1234"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, rr.Code)
+	}
+
+	var role string
+	var linked bool
+	err := db.QueryRow(`
+		SELECT roles.name, users.personnel_id IS NOT NULL
+		FROM users INNER JOIN roles ON roles.id = users.role_id
+		WHERE users.email = 'new@example.com'`).Scan(&role, &linked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role != "System User" || linked {
+		t.Errorf("expected an unlinked System User, got role %q and linked %t", role, linked)
+	}
+}
+
+func TestLinkOfficerUserHandler(t *testing.T) {
+	app, db := newTestApplication(t)
+	routes := app.routes()
+
+	admin := newTestAdmin(t, db)
+	user := newTestUser(t, db, "pa55word1234")
+	other := newTestUser(t, db, "pa55word1234")
+
+	var officerID int64
+	err := db.QueryRow(`
+		INSERT INTO personnel (regulation_number, first_name, last_name, sex)
+		VALUES ('LINK-1', 'Test', 'Officer', 'Female')
+		RETURNING id`).Scan(&officerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+		return rr
+	}
+
+	adminToken, userToken := loginTestUser(t, app, admin.ID), loginTestUser(t, app, user.ID)
+	path := fmt.Sprintf("/v1/officers/%d/user", officerID)
+	link := func(userID int64) string { return fmt.Sprintf(`{"user_id": %d}`, userID) }
+
+	if rr := do(http.MethodGet, "/v1/me/transcript", userToken, ""); rr.Code != http.StatusForbidden {
+		t.Errorf("expected an unlinked account to be refused, got status %d", rr.Code)
+	}
+
+	if rr := do(http.MethodPut, path, userToken, link(user.ID)); rr.Code != http.StatusForbidden {
+		t.Errorf("expected a user linking their own account to be refused, got status %d", rr.Code)
+	}
+
+	if rr := do(http.MethodPut, path, adminToken, link(user.ID)); rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	if rr := do(http.MethodGet, "/v1/me/transcript", userToken, ""); rr.Code != http.StatusOK {
+		t.Errorf("expected a linked account to read its transcript, got status %d", rr.Code)
+	}
+
+	if rr := do(http.MethodPut, path, adminToken, link(other.ID)); rr.Code != http.StatusConflict {
+		t.Errorf("expected linking a second account to the officer to conflict, got status %d", rr.Code)
+	}
+
+	if rr := do(http.MethodDelete, path, adminToken, ""); rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	if rr := do(http.MethodGet, "/v1/me/transcript", userToken, ""); rr.Code != http.StatusForbidden {
+		t.Errorf("expected an unlinked account to be refused, got status %d", rr.Code)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/data"
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+// showFacilitatorWorkloadHandler reports the teaching load of each facilitator over a date range,
+// defaulting to the current year, with totals for the formations of police facilitators
+func (a *application) showFacilitatorWorkloadHandler(w http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+	qs := r.URL.Query()
+
+	now := time.Now().UTC()
+	filters := data.WorkloadFilters{
+		From:            time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC),
+		To:              time.Date(now.Year(), time.December, 31, 0, 0, 0, 0, time.UTC),
+		MaxTeachingDays: a.readInt(qs, "max_teaching_days", a.config.workload.maxTeachingDays, v),
+	}
+
+	if from := a.readDate(qs, "from", v); from != nil {
+		filters.From = *from
+	}
+	if to := a.readDate(qs, "to", v); to != nil {
+		filters.To = *to
+	}
+
+	if data.ValidateWorkloadFilters(v, filters); !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	report, err := a.models.Facilitators.GetWorkload(filters)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = a.writeJSON(w, http.StatusOK, envelope{"workload": report}, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
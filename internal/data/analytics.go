@@ -0,0 +1,291 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+// AnalyticsPeriods lists the accepted trend periods.
+var AnalyticsPeriods = []string{"month", "quarter"}
+
+// ScoreSummary describes a set of ratings. Average and Distribution are only
+// reported once there are at least MinResponses ratings, so a single poor rating
+// does not dominate and a few ratings cannot be traced to who gave them.
+type ScoreSummary struct {
+	Responses    int         `json:"responses"`
+	Average      *float64    `json:"average"`
+	Distribution map[int]int `json:"distribution"`
+	Reportable   bool        `json:"reportable"`
+	total        int
+}
+
+func newScoreSummary() ScoreSummary {
+	return ScoreSummary{Distribution: map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}}
+}
+
+// add records count ratings of the given score.
+func (s *ScoreSummary) add(score, count int) {
+	s.Responses += count
+	s.Distribution[score] += count
+	s.total += score * count
+}
+
+// finish works out the average once every rating has been added, or withholds
+// the distribution if there are too few ratings to report.
+func (s *ScoreSummary) finish(minResponses int) {
+	s.Reportable = s.Responses > 0 && s.Responses >= minResponses
+	if !s.Reportable {
+		s.Distribution = nil
+		return
+	}
+	average := float64(s.total) / float64(s.Responses)
+	s.Average = &average
+}
+
+// TrendPoint summarises the ratings given in a single month or quarter.
+type TrendPoint struct {
+	Period      string    `json:"period"`
+	PeriodStart time.Time `json:"period_start"`
+	ScoreSummary
+}
+
+// CourseScore summarises the ratings given for a facilitator on a single course.
+type CourseScore struct {
+	CourseID    int64  `json:"course_id"`
+	CourseTitle string `json:"course_title"`
+	ScoreSummary
+}
+
+// FacilitatorAnalytics summarises every rating a facilitator has received.
+type FacilitatorAnalytics struct {
+	FacilitatorID int64          `json:"facilitator_id"`
+	Period        string         `json:"period"`
+	MinResponses  int            `json:"min_responses"`
+	Overall       ScoreSummary   `json:"overall"`
+	Trend         []*TrendPoint  `json:"trend"`
+	Courses       []*CourseScore `json:"courses"`
+}
+
+// LeaderboardEntry is a facilitator's position when ranked by average rating.
+type LeaderboardEntry struct {
+	Rank        int          `json:"rank"`
+	Facilitator *Facilitator `json:"facilitator"`
+	ScoreSummary
+}
+
+// AnalyticsFilters restricts analytics to ratings given within a date range.
+// Either end may be left as nil.
+type AnalyticsFilters struct {
+	From         *time.Time
+	To           *time.Time
+	MinResponses int
+}
+
+// ValidateAnalyticsFilters validates an AnalyticsFilters struct
+func ValidateAnalyticsFilters(v *validator.Validator, f AnalyticsFilters) {
+	v.Check(f.MinResponses >= 1, "min_responses", "must be greater than zero")
+	v.Check(f.MinResponses <= 1000, "min_responses", "must be a maximum of 1000")
+	if f.From != nil && f.To != nil {
+		v.Check(!f.To.Before(*f.From), "to", "must not be before from")
+	}
+}
+
+// periodLabel names the month or quarter beginning at start, e.g. "2025-03" or "2025-Q1".
+func periodLabel(period string, start time.Time) string {
+	if period == "quarter" {
+		return fmt.Sprintf("%d-Q%d", start.Year(), (int(start.Month())-1)/3+1)
+	}
+	return start.Format("2006-01")
+}
+
+// ratingCount is a row of the facilitator analytics query: how many ratings of
+// a given score a facilitator received for a course within a period.
+type ratingCount struct {
+	periodStart time.Time
+	courseID    int64
+	courseTitle string
+	score       int
+	count       int
+}
+
+// buildFacilitatorAnalytics folds the rating counts into the overall summary,
+// the trend over time and the per-course breakdown.
+func buildFacilitatorAnalytics(facilitatorID int64, period string, minResponses int, counts []ratingCount) *FacilitatorAnalytics {
+	analytics := &FacilitatorAnalytics{
+		FacilitatorID: facilitatorID,
+		Period:        period,
+		MinResponses:  minResponses,
+		Overall:       newScoreSummary(),
+		Trend:         []*TrendPoint{},
+		Courses:       []*CourseScore{},
+	}
+
+	trend := map[time.Time]*TrendPoint{}
+	courses := map[int64]*CourseScore{}
+
+	for _, c := range counts {
+		analytics.Overall.add(c.score, c.count)
+
+		point, ok := trend[c.periodStart]
+		if !ok {
+			point = &TrendPoint{
+				Period:       periodLabel(period, c.periodStart),
+				PeriodStart:  c.periodStart,
+				ScoreSummary: newScoreSummary(),
+			}
+			trend[c.periodStart] = point
+			analytics.Trend = append(analytics.Trend, point)
+		}
+		point.add(c.score, c.count)
+
+		course, ok := courses[c.courseID]
+		if !ok {
+			course = &CourseScore{
+				CourseID:     c.courseID,
+				CourseTitle:  c.courseTitle,
+				ScoreSummary: newScoreSummary(),
+			}
+			courses[c.courseID] = course
+			analytics.Courses = append(analytics.Courses, course)
+		}
+		course.add(c.score, c.count)
+	}
+
+	analytics.Overall.finish(minResponses)
+	for _, point := range analytics.Trend {
+		point.finish(minResponses)
+	}
+	for _, course := range analytics.Courses {
+		course.finish(minResponses)
+	}
+
+	sort.Slice(analytics.Trend, func(i, j int) bool {
+		return analytics.Trend[i].PeriodStart.Before(analytics.Trend[j].PeriodStart)
+	})
+	sort.Slice(analytics.Courses, func(i, j int) bool {
+		return analytics.Courses[i].CourseID < analytics.Courses[j].CourseID
+	})
+
+	return analytics
+}
+
+// GetFacilitatorAnalytics summarises the ratings a specific facilitator has
+// received, with a trend by month or quarter and a breakdown by course.
+func (m FeedbackModel) GetFacilitatorAnalytics(facilitatorID int64, period string, filters AnalyticsFilters) (*FacilitatorAnalytics, error) {
+	// period is one of AnalyticsPeriods, which are also valid date_trunc fields
+	query := `
+		SELECT date_trunc($2, fr.created_at)::date, c.id, c.title, fr.score, COUNT(*)
+		FROM facilitator_ratings fr
+		INNER JOIN session_enrollment se ON se.id = fr.session_enrollment_id
+		INNER JOIN training_sessions ts ON ts.id = se.session_id
+		INNER JOIN courses c ON c.id = ts.course_id
+		WHERE fr.facilitator_id = $1
+		AND ($3::date IS NULL OR fr.created_at >= $3::date)
+		AND ($4::date IS NULL OR fr.created_at < $4::date + 1)
+		GROUP BY 1, c.id, c.title, fr.score`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, facilitatorID, period, filters.From, filters.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []ratingCount{}
+
+	for rows.Next() {
+		var c ratingCount
+		err := rows.Scan(&c.periodStart, &c.courseID, &c.courseTitle, &c.score, &c.count)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return buildFacilitatorAnalytics(facilitatorID, period, filters.MinResponses, counts), nil
+}
+
+// GetFacilitatorLeaderboard ranks the facilitators with at least the minimum
+// number of ratings by their average score, most ratings first on a tie.
+func (m FeedbackModel) GetFacilitatorLeaderboard(analyticsFilters AnalyticsFilters, filters Filters) ([]*LeaderboardEntry, Metadata, error) {
+	query := `
+		SELECT COUNT(*) OVER(),
+			RANK() OVER (ORDER BY AVG(fr.score) DESC),
+			` + facilitatorColumns + `,
+			COUNT(*) FILTER (WHERE fr.score = 1),
+			COUNT(*) FILTER (WHERE fr.score = 2),
+			COUNT(*) FILTER (WHERE fr.score = 3),
+			COUNT(*) FILTER (WHERE fr.score = 4),
+			COUNT(*) FILTER (WHERE fr.score = 5)
+		FROM ` + facilitatorTables + `
+		INNER JOIN facilitator_ratings fr ON fr.facilitator_id = f.id
+		WHERE ($1::date IS NULL OR fr.created_at >= $1::date)
+		AND ($2::date IS NULL OR fr.created_at < $2::date + 1)
+		GROUP BY f.id, fp.id
+		HAVING COUNT(*) >= $3
+		ORDER BY AVG(fr.score) DESC, COUNT(*) DESC, f.id
+		LIMIT $4 OFFSET $5`
+
+	args := []any{
+		analyticsFilters.From,
+		analyticsFilters.To,
+		analyticsFilters.MinResponses,
+		filters.limit(),
+		filters.offset(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	entries := []*LeaderboardEntry{}
+
+	for rows.Next() {
+		var facilitator Facilitator
+		var distribution [5]int
+
+		entry := &LeaderboardEntry{Facilitator: &facilitator}
+
+		dest := append([]any{&totalRecords, &entry.Rank}, facilitator.dest()...)
+		for i := range distribution {
+			dest = append(dest, &distribution[i])
+		}
+
+		err := rows.Scan(dest...)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		entry.ScoreSummary = newScoreSummary()
+		for i, count := range distribution {
+			entry.add(i+1, count)
+		}
+		entry.finish(analyticsFilters.MinResponses)
+
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return entries, metadata, nil
+}
@@ -0,0 +1,66 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildFacilitatorAnalytics(t *testing.T) {
+	jan := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	apr := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	counts := []ratingCount{
+		{periodStart: apr, courseID: 2, courseTitle: "First Aid", score: 1, count: 1},
+		{periodStart: jan, courseID: 1, courseTitle: "Defensive Driving", score: 5, count: 3},
+		{periodStart: jan, courseID: 1, courseTitle: "Defensive Driving", score: 4, count: 1},
+	}
+
+	analytics := buildFacilitatorAnalytics(7, "quarter", 2, counts)
+
+	t.Run("overall summary", func(t *testing.T) {
+		if analytics.Overall.Responses != 5 {
+			t.Errorf("expected 5 responses, got %d", analytics.Overall.Responses)
+		}
+		if analytics.Overall.Average == nil || *analytics.Overall.Average != 4 {
+			t.Errorf("expected an average of 4, got %v", analytics.Overall.Average)
+		}
+		if analytics.Overall.Distribution[5] != 3 || analytics.Overall.Distribution[2] != 0 {
+			t.Errorf("unexpected distribution %v", analytics.Overall.Distribution)
+		}
+	})
+
+	t.Run("trend is in date order", func(t *testing.T) {
+		if len(analytics.Trend) != 2 {
+			t.Fatalf("expected 2 trend points, got %d", len(analytics.Trend))
+		}
+		if analytics.Trend[0].Period != "2025-Q1" || analytics.Trend[1].Period != "2025-Q2" {
+			t.Errorf("unexpected periods %q and %q", analytics.Trend[0].Period, analytics.Trend[1].Period)
+		}
+	})
+
+	t.Run("summaries below the threshold are withheld", func(t *testing.T) {
+		firstAid := analytics.Courses[1]
+		if firstAid.CourseID != 2 {
+			t.Fatalf("expected course 2, got %d", firstAid.CourseID)
+		}
+		if firstAid.Reportable || firstAid.Average != nil || firstAid.Distribution != nil {
+			t.Errorf("expected a single rating not to be reportable, got %+v", firstAid.ScoreSummary)
+		}
+
+		secondQuarter := analytics.Trend[1]
+		if secondQuarter.Reportable || secondQuarter.Average != nil || secondQuarter.Distribution != nil {
+			t.Errorf("expected a period with a single rating not to be reportable, got %+v", secondQuarter.ScoreSummary)
+		}
+	})
+}
+
+func TestPeriodLabel(t *testing.T) {
+	start := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	if got := periodLabel("month", start); got != "2025-11" {
+		t.Errorf("expected 2025-11, got %s", got)
+	}
+	if got := periodLabel("quarter", start); got != "2025-Q4" {
+		t.Errorf("expected 2025-Q4, got %s", got)
+	}
+}
@@ -0,0 +1,137 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+// AttendanceRecord marks whether an enrolled officer attended a session on a given day.
+type AttendanceRecord struct {
+	ID                  int64     `json:"id"`
+	SessionEnrollmentID int64     `json:"session_enrollment_id"`
+	AttendedOn          time.Time `json:"attended_on"`
+	Present             bool      `json:"present"`
+	Note                string    `json:"note,omitempty"`
+	RecordedBy          NullInt64 `json:"recorded_by,omitempty"`
+	RecordedAt          time.Time `json:"recorded_at"`
+}
+
+// ValidateAttendance validates the attendance taken for a session on a single
+// day, which must fall within the session dates.
+func ValidateAttendance(v *validator.Validator, nit *Nit, attendedOn time.Time, records []*AttendanceRecord) {
+	v.Check(!attendedOn.IsZero(), "attended_on", "must be provided")
+	v.Check(!attendedOn.Before(nit.StartDate) && !attendedOn.After(nit.EndDate), "attended_on", "must be within the session dates")
+	v.Check(len(records) > 0, "records", "must contain at least one record")
+
+	seen := make(map[int64]bool, len(records))
+	for _, record := range records {
+		v.Check(record.SessionEnrollmentID > 0, "records", "must only contain positive session_enrollment_id values")
+		v.Check(!seen[record.SessionEnrollmentID], "records", "must not contain the same session_enrollment_id more than once")
+		v.Check(len(record.Note) <= 500, "records", "notes must not be more than 500 bytes long")
+		seen[record.SessionEnrollmentID] = true
+	}
+}
+
+// AttendanceModel wraps the database connection pool.
+type AttendanceModel struct {
+	DB *sql.DB
+}
+
+// Record saves the attendance for a session, replacing anything previously
+// recorded for the same officers on the same day. Every record must belong to an
+// enrollment in the session, otherwise nothing is saved and ErrRecordNotFound is
+// returned.
+func (m AttendanceModel) Record(sessionID int64, records []*AttendanceRecord, recordedBy int64) error {
+	query := `
+		INSERT INTO session_attendance (session_enrollment_id, attended_on, present, note, recorded_by)
+		SELECT se.id, $3, $4, $5, $6
+		FROM session_enrollment se
+		WHERE se.id = $1 AND se.session_id = $2
+		ON CONFLICT (session_enrollment_id, attended_on) DO UPDATE
+		SET present = EXCLUDED.present, note = EXCLUDED.note, recorded_by = EXCLUDED.recorded_by, recorded_at = NOW()
+		RETURNING id, recorded_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, record := range records {
+		args := []any{
+			record.SessionEnrollmentID,
+			sessionID,
+			record.AttendedOn,
+			record.Present,
+			record.Note,
+			recordedBy,
+		}
+
+		err := tx.QueryRowContext(ctx, query, args...).Scan(&record.ID, &record.RecordedAt)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrRecordNotFound
+			default:
+				return translatePgError(err)
+			}
+		}
+
+		record.RecordedBy = NullInt64{Int64: recordedBy, Valid: true}
+	}
+
+	return tx.Commit()
+}
+
+// GetAllForSession returns the attendance recorded for a session, optionally
+// limited to a single day, in date order.
+func (m AttendanceModel) GetAllForSession(sessionID int64, attendedOn *time.Time) ([]*AttendanceRecord, error) {
+	query := `
+		SELECT sa.id, sa.session_enrollment_id, sa.attended_on, sa.present, sa.note, sa.recorded_by, sa.recorded_at
+		FROM session_attendance sa
+		INNER JOIN session_enrollment se ON se.id = sa.session_enrollment_id
+		WHERE se.session_id = $1
+		AND ($2::date IS NULL OR sa.attended_on = $2::date)
+		ORDER BY sa.attended_on, sa.session_enrollment_id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, sessionID, attendedOn)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []*AttendanceRecord{}
+
+	for rows.Next() {
+		var record AttendanceRecord
+		err := rows.Scan(
+			&record.ID,
+			&record.SessionEnrollmentID,
+			&record.AttendedOn,
+			&record.Present,
+			&record.Note,
+			(*sql.NullInt64)(&record.RecordedBy),
+			&record.RecordedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, &record)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
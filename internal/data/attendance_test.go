@@ -0,0 +1,71 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+func TestValidateAttendance(t *testing.T) {
+	nit := &Nit{
+		StartDate: time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, 5, 9, 0, 0, 0, 0, time.UTC),
+	}
+	day := time.Date(2025, 5, 6, 0, 0, 0, 0, time.UTC)
+
+	t.Run("valid attendance", func(t *testing.T) {
+		v := validator.New()
+		ValidateAttendance(v, nit, day, []*AttendanceRecord{
+			{SessionEnrollmentID: 1, Present: true},
+			{SessionEnrollmentID: 2, Note: "sick"},
+		})
+		if !v.IsEmpty() {
+			t.Errorf("expected attendance to be valid, but got errors: %v", v.Errors)
+		}
+	})
+
+	t.Run("day outside the session", func(t *testing.T) {
+		v := validator.New()
+		ValidateAttendance(v, nit, nit.EndDate.AddDate(0, 0, 1), []*AttendanceRecord{{SessionEnrollmentID: 1}})
+		if _, exists := v.Errors["attended_on"]; !exists {
+			t.Error("expected error on attended_on field, but it was not found")
+		}
+	})
+
+	t.Run("enrollment recorded twice", func(t *testing.T) {
+		v := validator.New()
+		ValidateAttendance(v, nit, day, []*AttendanceRecord{{SessionEnrollmentID: 1}, {SessionEnrollmentID: 1}})
+		if _, exists := v.Errors["records"]; !exists {
+			t.Error("expected error on records field, but it was not found")
+		}
+	})
+}
+
+func TestValidateEnrollmentOutcome(t *testing.T) {
+	completed := time.Date(2025, 5, 9, 0, 0, 0, 0, time.UTC)
+
+	t.Run("completed with a date", func(t *testing.T) {
+		v := validator.New()
+		ValidateEnrollmentOutcome(v, &Enrollment{Status: "Completed", CompletionDate: &completed, Grade: "A"})
+		if !v.IsEmpty() {
+			t.Errorf("expected outcome to be valid, but got errors: %v", v.Errors)
+		}
+	})
+
+	t.Run("unknown status", func(t *testing.T) {
+		v := validator.New()
+		ValidateEnrollmentOutcome(v, &Enrollment{Status: "Passed"})
+		if _, exists := v.Errors["status"]; !exists {
+			t.Error("expected error on status field, but it was not found")
+		}
+	})
+
+	t.Run("completion date without completing", func(t *testing.T) {
+		v := validator.New()
+		ValidateEnrollmentOutcome(v, &Enrollment{Status: "Failed", CompletionDate: &completed})
+		if _, exists := v.Errors["completion_date"]; !exists {
+			t.Error("expected error on completion_date field, but it was not found")
+		}
+	})
+}
@@ -0,0 +1,353 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+// Availability is a window of dates in which a facilitator is either available
+// to teach or, for a blackout, must not be assigned to any session.
+type Availability struct {
+	ID            int64     `json:"id"`
+	FacilitatorID int64     `json:"facilitator_id"`
+	Kind          string    `json:"kind"`
+	StartsOn      time.Time `json:"starts_on"`
+	EndsOn        time.Time `json:"ends_on"`
+	Note          string    `json:"note,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// AvailabilityCheck explains whether a facilitator can take on a session.
+// Problems is empty when the facilitator is free.
+type AvailabilityCheck struct {
+	FacilitatorID     int64    `json:"facilitator_id"`
+	SessionID         int64    `json:"session_id"`
+	Available         bool     `json:"available"`
+	Problems          []string `json:"problems"`
+	Qualified         bool     `json:"qualified"`
+	SessionsThatMonth int      `json:"sessions_that_month"`
+}
+
+// FacilitatorSuggestion is a facilitator who is free to teach a session.
+// RecentRating is the average facilitator rating over the last 12 months.
+type FacilitatorSuggestion struct {
+	Facilitator        *Facilitator `json:"facilitator"`
+	SessionsThatMonth  int          `json:"sessions_that_month"`
+	RecentRating       *float64     `json:"recent_rating"`
+	RecentRatingsCount int          `json:"recent_ratings_count"`
+}
+
+// ValidateAvailability validates an Availability struct
+func ValidateAvailability(v *validator.Validator, availability *Availability) {
+	v.Check(availability.Kind == "available" || availability.Kind == "blackout", "kind", "must be either 'available' or 'blackout'")
+	v.Check(!availability.StartsOn.IsZero(), "starts_on", "must be provided")
+	v.Check(!availability.EndsOn.IsZero(), "ends_on", "must be provided")
+	v.Check(!availability.EndsOn.Before(availability.StartsOn), "ends_on", "must not be before starts_on")
+	v.Check(len(availability.Note) <= 500, "note", "must not be more than 500 bytes long")
+}
+
+// AvailabilityModel wraps the database connection pool.
+type AvailabilityModel struct {
+	DB *sql.DB
+}
+
+// Insert adds an availability window or blackout for a facilitator.
+func (m AvailabilityModel) Insert(availability *Availability) error {
+	query := `
+		INSERT INTO facilitator_availability (facilitator_id, kind, starts_on, ends_on, note)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+
+	args := []any{
+		availability.FacilitatorID,
+		availability.Kind,
+		availability.StartsOn,
+		availability.EndsOn,
+		availability.Note,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&availability.ID, &availability.CreatedAt)
+	return translatePgError(err)
+}
+
+// GetAllForFacilitator returns the availability windows and blackouts of a
+// specific facilitator in date order.
+func (m AvailabilityModel) GetAllForFacilitator(facilitatorID int64, filters Filters) ([]*Availability, Metadata, error) {
+	query := `
+		SELECT COUNT(*) OVER(), id, facilitator_id, kind, starts_on, ends_on, note, created_at
+		FROM facilitator_availability
+		WHERE facilitator_id = $1
+		ORDER BY starts_on, id
+		LIMIT $2 OFFSET $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, facilitatorID, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	windows := []*Availability{}
+
+	for rows.Next() {
+		var availability Availability
+		err := rows.Scan(
+			&totalRecords,
+			&availability.ID,
+			&availability.FacilitatorID,
+			&availability.Kind,
+			&availability.StartsOn,
+			&availability.EndsOn,
+			&availability.Note,
+			&availability.CreatedAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		windows = append(windows, &availability)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return windows, metadata, nil
+}
+
+// Delete removes an availability window belonging to a specific facilitator.
+func (m AvailabilityModel) Delete(facilitatorID, id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+		DELETE FROM facilitator_availability
+		WHERE id = $1 AND facilitator_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, facilitatorID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// facilitatorFitQuery works out, for every facilitator, how well they fit the
+// session given as $1:
+//   - within_availability: they have no availability windows at all, or one
+//     window covers the whole session
+//   - blacked_out: a blackout overlaps any day of the session
+//   - double_booked: they are assigned to another session on overlapping dates
+//   - sessions_that_month: other sessions they teach starting in the same month
+//   - qualified: they hold a qualification for the course which is valid for
+//     the whole session
+//   - assigned: they are already assigned to the session
+//
+// along with their average rating over the last 12 months.
+const facilitatorFitQuery = `
+	SELECT ` + facilitatorColumns + `,
+		(
+			NOT EXISTS (
+				SELECT 1 FROM facilitator_availability fa
+				WHERE fa.facilitator_id = f.id AND fa.kind = 'available'
+			)
+			OR EXISTS (
+				SELECT 1 FROM facilitator_availability fa
+				WHERE fa.facilitator_id = f.id AND fa.kind = 'available'
+				AND fa.starts_on <= ts.start_date AND fa.ends_on >= ts.end_date
+			)
+		) AS within_availability,
+		EXISTS (
+			SELECT 1 FROM facilitator_availability fa
+			WHERE fa.facilitator_id = f.id AND fa.kind = 'blackout'
+			AND fa.starts_on <= ts.end_date AND fa.ends_on >= ts.start_date
+		) AS blacked_out,
+		EXISTS (
+			SELECT 1 FROM session_facilitators sf
+			INNER JOIN training_sessions o ON o.id = sf.session_id
+			WHERE sf.facilitator_id = f.id AND o.id <> ts.id
+			AND o.start_date <= ts.end_date AND o.end_date >= ts.start_date
+		) AS double_booked,
+		(
+			SELECT COUNT(*) FROM session_facilitators sf
+			INNER JOIN training_sessions o ON o.id = sf.session_id
+			WHERE sf.facilitator_id = f.id AND o.id <> ts.id
+			AND date_trunc('month', o.start_date) = date_trunc('month', ts.start_date)
+		) AS sessions_that_month,
+		EXISTS (
+			SELECT 1 FROM facilitator_qualifications fq
+			WHERE fq.facilitator_id = f.id AND fq.course_id = ts.course_id
+			AND fq.qualified_since <= ts.start_date
+			AND (fq.expires_on IS NULL OR fq.expires_on >= ts.end_date)
+		) AS qualified,
+		EXISTS (
+			SELECT 1 FROM session_facilitators sf
+			WHERE sf.facilitator_id = f.id AND sf.session_id = ts.id
+		) AS assigned,
+		rr.average,
+		rr.total
+	FROM ` + facilitatorTables + `
+	CROSS JOIN training_sessions ts
+	LEFT JOIN LATERAL (
+		SELECT AVG(fr.score)::float8 AS average, COUNT(*) AS total
+		FROM facilitator_ratings fr
+		WHERE fr.facilitator_id = f.id AND fr.created_at >= NOW() - INTERVAL '12 months'
+	) rr ON true
+	WHERE ts.id = $1`
+
+// facilitatorFit is a row of facilitatorFitQuery.
+type facilitatorFit struct {
+	facilitator        Facilitator
+	withinAvailability bool
+	blackedOut         bool
+	doubleBooked       bool
+	sessionsThatMonth  int
+	qualified          bool
+	assigned           bool
+	recentRating       sql.NullFloat64
+	recentRatingsCount int
+}
+
+func (fit *facilitatorFit) dest() []any {
+	return append(fit.facilitator.dest(),
+		&fit.withinAvailability,
+		&fit.blackedOut,
+		&fit.doubleBooked,
+		&fit.sessionsThatMonth,
+		&fit.qualified,
+		&fit.assigned,
+		&fit.recentRating,
+		&fit.recentRatingsCount,
+	)
+}
+
+// problems lists the reasons the facilitator should not be assigned to the session.
+func (fit *facilitatorFit) problems() []string {
+	problems := []string{}
+	if !fit.withinAvailability {
+		problems = append(problems, "no availability window covers the session dates")
+	}
+	if fit.blackedOut {
+		problems = append(problems, "a blackout period overlaps the session dates")
+	}
+	if fit.doubleBooked {
+		problems = append(problems, "already assigned to another session on overlapping dates")
+	}
+	if fit.sessionsThatMonth >= int(fit.facilitator.MaxSessionsPerMonth) {
+		problems = append(problems, fmt.Sprintf("already assigned to %d sessions that month, the limit is %d",
+			fit.sessionsThatMonth, fit.facilitator.MaxSessionsPerMonth))
+	}
+	return problems
+}
+
+// CheckForSession reports whether a facilitator is free to teach a session.
+func (m AvailabilityModel) CheckForSession(facilitatorID, sessionID int64) (*AvailabilityCheck, error) {
+	if facilitatorID < 1 || sessionID < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := facilitatorFitQuery + ` AND f.id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var fit facilitatorFit
+
+	err := m.DB.QueryRowContext(ctx, query, sessionID, facilitatorID).Scan(fit.dest()...)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	problems := fit.problems()
+
+	return &AvailabilityCheck{
+		FacilitatorID:     facilitatorID,
+		SessionID:         sessionID,
+		Available:         len(problems) == 0,
+		Problems:          problems,
+		Qualified:         fit.qualified,
+		SessionsThatMonth: fit.sessionsThatMonth,
+	}, nil
+}
+
+// SuggestForSession returns the facilitators who are free for the whole of a
+// session, qualified for its course and under their monthly workload limit,
+// best rated first.
+func (m AvailabilityModel) SuggestForSession(sessionID int64, filters Filters) ([]*FacilitatorSuggestion, Metadata, error) {
+	query := `
+		SELECT COUNT(*) OVER(), fit.*
+		FROM (` + facilitatorFitQuery + `) fit
+		WHERE fit.within_availability AND NOT fit.blacked_out AND NOT fit.double_booked
+		AND fit.sessions_that_month < fit.max_sessions_per_month
+		AND fit.qualified AND NOT fit.assigned
+		ORDER BY fit.average DESC NULLS LAST, fit.total DESC, fit.sessions_that_month, fit.id
+		LIMIT $2 OFFSET $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, sessionID, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	suggestions := []*FacilitatorSuggestion{}
+
+	for rows.Next() {
+		var fit facilitatorFit
+		err := rows.Scan(append([]any{&totalRecords}, fit.dest()...)...)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		suggestion := &FacilitatorSuggestion{
+			Facilitator:        &fit.facilitator,
+			SessionsThatMonth:  fit.sessionsThatMonth,
+			RecentRatingsCount: fit.recentRatingsCount,
+		}
+		if fit.recentRating.Valid {
+			suggestion.RecentRating = &fit.recentRating.Float64
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return suggestions, metadata, nil
+}
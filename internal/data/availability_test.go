@@ -0,0 +1,70 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+func TestValidateAvailability(t *testing.T) {
+	availability := &Availability{
+		FacilitatorID: 1,
+		Kind:          "blackout",
+		StartsOn:      time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		EndsOn:        time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("single day window is valid", func(t *testing.T) {
+		v := validator.New()
+		ValidateAvailability(v, availability)
+		if !v.IsEmpty() {
+			t.Errorf("expected availability to be valid, but got errors: %v", v.Errors)
+		}
+	})
+
+	t.Run("kind is invalid", func(t *testing.T) {
+		a := *availability
+		a.Kind = "holiday"
+		v := validator.New()
+		ValidateAvailability(v, &a)
+		if _, exists := v.Errors["kind"]; !exists {
+			t.Error("expected error on kind field, but it was not found")
+		}
+	})
+
+	t.Run("ends before it starts", func(t *testing.T) {
+		a := *availability
+		a.EndsOn = a.StartsOn.AddDate(0, 0, -1)
+		v := validator.New()
+		ValidateAvailability(v, &a)
+		if _, exists := v.Errors["ends_on"]; !exists {
+			t.Error("expected error on ends_on field, but it was not found")
+		}
+	})
+}
+
+func TestFacilitatorFitProblems(t *testing.T) {
+	t.Run("free facilitator", func(t *testing.T) {
+		fit := facilitatorFit{
+			facilitator:        Facilitator{MaxSessionsPerMonth: 4},
+			withinAvailability: true,
+			sessionsThatMonth:  3,
+		}
+		if problems := fit.problems(); len(problems) != 0 {
+			t.Errorf("expected no problems, got %v", problems)
+		}
+	})
+
+	t.Run("every problem is reported", func(t *testing.T) {
+		fit := facilitatorFit{
+			facilitator:       Facilitator{MaxSessionsPerMonth: 2},
+			blackedOut:        true,
+			doubleBooked:      true,
+			sessionsThatMonth: 2,
+		}
+		if problems := fit.problems(); len(problems) != 4 {
+			t.Errorf("expected 4 problems, got %v", problems)
+		}
+	})
+}
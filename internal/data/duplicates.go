@@ -0,0 +1,510 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var ErrMergeConflict = errors.New("merge conflict")
+
+// DuplicateCandidate is a pair of officers which are likely to be the same person.
+type DuplicateCandidate struct {
+	Officer   *Officer `json:"officer"`
+	Duplicate *Officer `json:"duplicate"`
+	Reason    string   `json:"reason"` // 'regulation_number' or 'name'
+}
+
+// MergeSummary describes what was moved from the duplicate onto the surviving officer.
+type MergeSummary struct {
+	EnrollmentsMoved   int64 `json:"enrollments_moved"`
+	EnrollmentsMerged  int64 `json:"enrollments_merged"`
+	FacilitatorMoved   bool  `json:"facilitator_moved"`
+	FacilitatorMerged  bool  `json:"facilitator_merged"`
+	UserMoved          bool  `json:"user_moved"`
+	DuplicateDeletedID int64 `json:"duplicate_deleted_id"`
+}
+
+// GetDuplicateCandidates returns pairs of officers who share a normalised
+// regulation number, or who share a name but have different regulation numbers.
+// Regulation numbers are normalised by dropping punctuation, spacing and leading
+// zeros so that "PC-0101" and "pc 101" are treated as the same number.
+func (m OfficerModel) GetDuplicateCandidates(filters Filters) ([]*DuplicateCandidate, Metadata, error) {
+	query := `
+		WITH normalised AS (
+			SELECT id,
+				lower(trim(first_name)) AS first_name,
+				lower(trim(last_name)) AS last_name,
+				ltrim(upper(regexp_replace(regulation_number, '[^A-Za-z0-9]', '', 'g')), '0') AS regulation_number
+			FROM personnel
+		), pairs AS (
+			SELECT a.id AS officer_id, b.id AS duplicate_id, 'regulation_number' AS reason
+			FROM normalised a
+			INNER JOIN normalised b ON b.regulation_number = a.regulation_number AND b.id > a.id
+			UNION ALL
+			SELECT a.id, b.id, 'name'
+			FROM normalised a
+			INNER JOIN normalised b ON b.first_name = a.first_name AND b.last_name = a.last_name AND b.id > a.id
+			WHERE b.regulation_number <> a.regulation_number
+		)
+		SELECT COUNT(*) OVER(), officer_id, duplicate_id, reason
+		FROM pairs
+		ORDER BY officer_id, duplicate_id
+		LIMIT $1 OFFSET $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	type pair struct {
+		officerID, duplicateID int64
+		reason                 string
+	}
+
+	totalRecords := 0
+	pairs := []pair{}
+	ids := []int64{}
+
+	for rows.Next() {
+		var p pair
+		err := rows.Scan(&totalRecords, &p.officerID, &p.duplicateID, &p.reason)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		pairs = append(pairs, p)
+		ids = append(ids, p.officerID, p.duplicateID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	candidates := []*DuplicateCandidate{}
+
+	if len(pairs) > 0 {
+		officers, err := m.getAllByIDs(ctx, ids)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		for _, p := range pairs {
+			candidates = append(candidates, &DuplicateCandidate{
+				Officer:   officers[p.officerID],
+				Duplicate: officers[p.duplicateID],
+				Reason:    p.reason,
+			})
+		}
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return candidates, metadata, nil
+}
+
+// Merge moves the enrollments, ratings, facilitator link and user link of the
+// duplicate officer onto the surviving officer and then deletes the duplicate,
+// all within a single transaction. Where both officers are enrolled in the same
+// session the two enrollments are combined: ratings, survey answers and
+// attendance move across unless the survivor already has them, and the more
+// advanced status is kept.
+func (m OfficerModel) Merge(survivorID, duplicateID int64) (*MergeSummary, error) {
+	if survivorID < 1 || duplicateID < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock both officers so nothing else can change them while they are merged
+	var locked int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM (
+			SELECT id FROM personnel WHERE id IN ($1, $2) FOR UPDATE
+		) AS p`, survivorID, duplicateID).Scan(&locked)
+	if err != nil {
+		return nil, err
+	}
+	if locked != 2 {
+		return nil, ErrRecordNotFound
+	}
+
+	// Two separate user accounts cannot both be linked to the surviving officer
+	var users int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM users WHERE personnel_id IN ($1, $2)`,
+		survivorID, duplicateID).Scan(&users)
+	if err != nil {
+		return nil, err
+	}
+	if users > 1 {
+		return nil, ErrMergeConflict
+	}
+
+	summary := &MergeSummary{DuplicateDeletedID: duplicateID}
+
+	summary.EnrollmentsMerged, err = m.mergeConflictingEnrollments(ctx, tx, survivorID, duplicateID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE session_enrollment SET personnel_id = $1 WHERE personnel_id = $2`,
+		survivorID, duplicateID)
+	if err != nil {
+		return nil, err
+	}
+	summary.EnrollmentsMoved, err = result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	summary.FacilitatorMoved, summary.FacilitatorMerged, err = m.mergeFacilitators(ctx, tx, survivorID, duplicateID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err = tx.ExecContext(ctx, `
+		UPDATE users SET personnel_id = $1 WHERE personnel_id = $2`,
+		survivorID, duplicateID)
+	if err != nil {
+		return nil, err
+	}
+	moved, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	summary.UserMoved = moved > 0
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM personnel WHERE id = $1`, duplicateID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// mergeConflictingEnrollments combines each enrollment of the duplicate with the
+// survivor's enrollment in the same session and returns how many were combined.
+func (m OfficerModel) mergeConflictingEnrollments(ctx context.Context, tx *sql.Tx, survivorID, duplicateID int64) (int64, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT k.id, d.id
+		FROM session_enrollment k
+		INNER JOIN session_enrollment d ON d.session_id = k.session_id
+		WHERE k.personnel_id = $1 AND d.personnel_id = $2`,
+		survivorID, duplicateID)
+	if err != nil {
+		return 0, err
+	}
+
+	type pair struct{ keepID, dropID int64 }
+	pairs := []pair{}
+
+	for rows.Next() {
+		var p pair
+		err := rows.Scan(&p.keepID, &p.dropID)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		pairs = append(pairs, p)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, p := range pairs {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE course_ratings SET session_enrollment_id = $1
+			WHERE session_enrollment_id = $2
+			AND NOT EXISTS (SELECT 1 FROM course_ratings WHERE session_enrollment_id = $1)`,
+			p.keepID, p.dropID)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE facilitator_ratings fr SET session_enrollment_id = $1
+			WHERE fr.session_enrollment_id = $2
+			AND NOT EXISTS (
+				SELECT 1 FROM facilitator_ratings x
+				WHERE x.session_enrollment_id = $1 AND x.facilitator_id = fr.facilitator_id
+			)`,
+			p.keepID, p.dropID)
+		if err != nil {
+			return 0, err
+		}
+
+		// Where both answered the same survey, the duplicate's answers fill in the
+		// questions the survivor left unanswered; other submissions move across
+		_, err = tx.ExecContext(ctx, `
+			UPDATE survey_answers sa SET submission_id = k.id
+			FROM survey_submissions d, survey_submissions k
+			WHERE sa.submission_id = d.id
+			AND d.session_enrollment_id = $2
+			AND k.session_enrollment_id = $1 AND k.survey_id = d.survey_id
+			AND NOT EXISTS (
+				SELECT 1 FROM survey_answers x
+				WHERE x.submission_id = k.id AND x.question_id = sa.question_id
+			)`,
+			p.keepID, p.dropID)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE survey_submissions ss SET session_enrollment_id = $1
+			WHERE ss.session_enrollment_id = $2
+			AND NOT EXISTS (
+				SELECT 1 FROM survey_submissions x
+				WHERE x.session_enrollment_id = $1 AND x.survey_id = ss.survey_id
+			)`,
+			p.keepID, p.dropID)
+		if err != nil {
+			return 0, err
+		}
+
+		// Attendance moves across for the days the survivor has no record of
+		_, err = tx.ExecContext(ctx, `
+			UPDATE session_attendance sa SET session_enrollment_id = $1
+			WHERE sa.session_enrollment_id = $2
+			AND NOT EXISTS (
+				SELECT 1 FROM session_attendance x
+				WHERE x.session_enrollment_id = $1 AND x.attended_on = sa.attended_on
+			)`,
+			p.keepID, p.dropID)
+		if err != nil {
+			return 0, err
+		}
+
+		// Reminders already sent count against the survivor, so the officer is
+		// not reminded again sooner or more often than for a single enrollment
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO feedback_reminders (session_enrollment_id, reminders_sent, last_sent_at)
+			SELECT $1, reminders_sent, last_sent_at FROM feedback_reminders WHERE session_enrollment_id = $2
+			ON CONFLICT (session_enrollment_id) DO UPDATE
+			SET reminders_sent = GREATEST(feedback_reminders.reminders_sent, EXCLUDED.reminders_sent),
+				last_sent_at = GREATEST(feedback_reminders.last_sent_at, EXCLUDED.last_sent_at)`,
+			p.keepID, p.dropID)
+		if err != nil {
+			return 0, err
+		}
+
+		// Keep whichever outcome is furthest along
+		keep, err := getEnrollmentOutcome(ctx, tx, p.keepID)
+		if err != nil {
+			return 0, err
+		}
+		drop, err := getEnrollmentOutcome(ctx, tx, p.dropID)
+		if err != nil {
+			return 0, err
+		}
+
+		best := furthestOutcome(keep, drop)
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE session_enrollment
+			SET status = NULLIF($2, ''), completion_date = $3, grade = NULLIF($4, '')
+			WHERE id = $1`,
+			p.keepID, best.status, best.completionDate, best.grade)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM session_enrollment WHERE id = $1`, p.dropID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return int64(len(pairs)), nil
+}
+
+// enrollmentOutcome is the result recorded against an enrollment.
+type enrollmentOutcome struct {
+	status         string
+	completionDate *time.Time
+	grade          string
+}
+
+// getEnrollmentOutcome retrieves the outcome recorded against an enrollment.
+func getEnrollmentOutcome(ctx context.Context, tx *sql.Tx, id int64) (enrollmentOutcome, error) {
+	var outcome enrollmentOutcome
+
+	err := tx.QueryRowContext(ctx, `
+		SELECT COALESCE(status, ''), completion_date, COALESCE(grade, '')
+		FROM session_enrollment
+		WHERE id = $1`, id).Scan(&outcome.status, &outcome.completionDate, &outcome.grade)
+
+	return outcome, err
+}
+
+// enrollmentProgress ranks enrollment statuses by how far along they are.
+// Unknown statuses rank alongside Withdrew.
+func enrollmentProgress(status string) int {
+	switch status {
+	case "Completed":
+		return 4
+	case "Failed":
+		return 3
+	case "Enrolled":
+		return 2
+	default:
+		return 1
+	}
+}
+
+// furthestOutcome picks the outcome of two enrollments in the same session to
+// keep when they are combined. The more advanced status wins, then the later
+// completion date, and otherwise the survivor's outcome is kept.
+func furthestOutcome(keep, drop enrollmentOutcome) enrollmentOutcome {
+	switch {
+	case enrollmentProgress(drop.status) > enrollmentProgress(keep.status):
+		return drop
+	case enrollmentProgress(drop.status) < enrollmentProgress(keep.status):
+		return keep
+	case drop.completionDate == nil:
+		return keep
+	case keep.completionDate == nil || drop.completionDate.After(*keep.completionDate):
+		return drop
+	default:
+		return keep
+	}
+}
+
+// mergeFacilitators moves the duplicate's facilitator record onto the survivor.
+// If both officers are facilitators, the duplicate's session assignments,
+// ratings, availability, qualifications and invitations are folded into the
+// survivor's facilitator record instead.
+func (m OfficerModel) mergeFacilitators(ctx context.Context, tx *sql.Tx, survivorID, duplicateID int64) (moved, merged bool, err error) {
+	var keepID, dropID sql.NullInt64
+
+	err = tx.QueryRowContext(ctx, `SELECT id FROM facilitators WHERE personnel_id = $1`, duplicateID).Scan(&dropID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, false, err
+	}
+	if !dropID.Valid {
+		return false, false, nil
+	}
+
+	err = tx.QueryRowContext(ctx, `SELECT id FROM facilitators WHERE personnel_id = $1`, survivorID).Scan(&keepID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, false, err
+	}
+
+	if !keepID.Valid {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE facilitators SET personnel_id = $1, version = version + 1 WHERE id = $2`,
+			survivorID, dropID.Int64)
+		return err == nil, false, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO session_facilitators (session_id, facilitator_id)
+		SELECT session_id, $1 FROM session_facilitators WHERE facilitator_id = $2
+		ON CONFLICT DO NOTHING`,
+		keepID.Int64, dropID.Int64)
+	if err != nil {
+		return false, false, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE facilitator_ratings fr SET facilitator_id = $1
+		WHERE fr.facilitator_id = $2
+		AND NOT EXISTS (
+			SELECT 1 FROM facilitator_ratings x
+			WHERE x.session_enrollment_id = fr.session_enrollment_id AND x.facilitator_id = $1
+		)`,
+		keepID.Int64, dropID.Int64)
+	if err != nil {
+		return false, false, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE facilitator_availability SET facilitator_id = $1 WHERE facilitator_id = $2`,
+		keepID.Int64, dropID.Int64)
+	if err != nil {
+		return false, false, err
+	}
+
+	// Qualifications for courses the survivor is not yet qualified in move across
+	_, err = tx.ExecContext(ctx, `
+		UPDATE facilitator_qualifications fq SET facilitator_id = $1
+		WHERE fq.facilitator_id = $2
+		AND NOT EXISTS (
+			SELECT 1 FROM facilitator_qualifications x
+			WHERE x.facilitator_id = $1 AND x.course_id = fq.course_id
+		)`,
+		keepID.Int64, dropID.Int64)
+	if err != nil {
+		return false, false, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE facilitator_assignment_overrides SET facilitator_id = $1 WHERE facilitator_id = $2`,
+		keepID.Int64, dropID.Int64)
+	if err != nil {
+		return false, false, err
+	}
+
+	// A facilitator can only be linked to one user account
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users SET facilitator_id = $1
+		WHERE facilitator_id = $2
+		AND NOT EXISTS (SELECT 1 FROM users WHERE facilitator_id = $1)`,
+		keepID.Int64, dropID.Int64)
+	if err != nil {
+		return false, false, err
+	}
+
+	// Invitations move across with their history. Those still open are revoked
+	// unless their account moved with them, since accepting one would otherwise
+	// activate an account no longer linked to any facilitator.
+	_, err = tx.ExecContext(ctx, `
+		UPDATE facilitator_invitations fi
+		SET facilitator_id = $1,
+			revoked_at = CASE
+				WHEN fi.accepted_at IS NULL AND fi.revoked_at IS NULL
+					AND NOT EXISTS (SELECT 1 FROM users u WHERE u.id = fi.user_id AND u.facilitator_id = $1)
+				THEN NOW()
+				ELSE fi.revoked_at
+			END
+		WHERE fi.facilitator_id = $2`,
+		keepID.Int64, dropID.Int64)
+	if err != nil {
+		return false, false, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM tokens
+		WHERE scope = $1 AND user_id IN (SELECT id FROM users WHERE facilitator_id = $2)`,
+		ScopeInvitation, dropID.Int64)
+	if err != nil {
+		return false, false, err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM facilitators WHERE id = $1`, dropID.Int64)
+	if err != nil {
+		return false, false, err
+	}
+
+	return false, true, nil
+}
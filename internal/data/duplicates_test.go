@@ -0,0 +1,317 @@
+package data
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/testdb"
+)
+
+func TestFurthestOutcome(t *testing.T) {
+	earlier := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	later := earlier.AddDate(0, 0, 7)
+
+	tests := []struct {
+		name string
+		keep enrollmentOutcome
+		drop enrollmentOutcome
+		want enrollmentOutcome
+	}{
+		{
+			name: "duplicate further along",
+			keep: enrollmentOutcome{status: "Enrolled"},
+			drop: enrollmentOutcome{status: "Completed", completionDate: &earlier, grade: "B"},
+			want: enrollmentOutcome{status: "Completed", completionDate: &earlier, grade: "B"},
+		},
+		{
+			name: "survivor further along",
+			keep: enrollmentOutcome{status: "Failed"},
+			drop: enrollmentOutcome{status: "Withdrew"},
+			want: enrollmentOutcome{status: "Failed"},
+		},
+		{
+			name: "unknown status ranks with withdrew",
+			keep: enrollmentOutcome{status: ""},
+			drop: enrollmentOutcome{status: "Withdrew"},
+			want: enrollmentOutcome{status: ""},
+		},
+		{
+			name: "later completion wins a tie",
+			keep: enrollmentOutcome{status: "Completed", completionDate: &earlier, grade: "C"},
+			drop: enrollmentOutcome{status: "Completed", completionDate: &later, grade: "A"},
+			want: enrollmentOutcome{status: "Completed", completionDate: &later, grade: "A"},
+		},
+		{
+			name: "completion date beats none",
+			keep: enrollmentOutcome{status: "Completed"},
+			drop: enrollmentOutcome{status: "Completed", completionDate: &earlier},
+			want: enrollmentOutcome{status: "Completed", completionDate: &earlier},
+		},
+		{
+			name: "survivor kept on a full tie",
+			keep: enrollmentOutcome{status: "Completed", completionDate: &later, grade: "A"},
+			drop: enrollmentOutcome{status: "Completed", completionDate: &later, grade: "B"},
+			want: enrollmentOutcome{status: "Completed", completionDate: &later, grade: "A"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := furthestOutcome(tt.keep, tt.drop)
+			if got.status != tt.want.status || got.grade != tt.want.grade {
+				t.Errorf("expected %s/%q, got %s/%q", tt.want.status, tt.want.grade, got.status, got.grade)
+			}
+			if (got.completionDate == nil) != (tt.want.completionDate == nil) ||
+				(got.completionDate != nil && !got.completionDate.Equal(*tt.want.completionDate)) {
+				t.Errorf("expected completion date %v, got %v", tt.want.completionDate, got.completionDate)
+			}
+		})
+	}
+}
+
+func TestOfficerModelMerge(t *testing.T) {
+	db := testdb.Open(t)
+	m := OfficerModel{DB: db}
+
+	start := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+
+	t.Run("enrollments", func(t *testing.T) {
+		survivor, duplicate := newTestOfficer(t, db), newTestOfficer(t, db)
+		shared := newTestSession(t, db, start, start.AddDate(0, 0, 1))
+		separate := newTestSession(t, db, start, start)
+
+		keepID := newTestEnrollment(t, db, survivor, shared, "Enrolled")
+		dropID := newTestEnrollment(t, db, duplicate, shared, "Completed")
+		mustExec(t, db, `UPDATE session_enrollment SET completion_date = $1, grade = 'A' WHERE id = $2`, start, dropID)
+		movedID := newTestEnrollment(t, db, duplicate, separate, "Enrolled")
+
+		summary, err := m.Merge(survivor, duplicate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if summary.EnrollmentsMerged != 1 || summary.EnrollmentsMoved != 1 {
+			t.Errorf("expected 1 enrollment merged and 1 moved, got %d and %d", summary.EnrollmentsMerged, summary.EnrollmentsMoved)
+		}
+
+		var status, grade string
+		err = db.QueryRow(`SELECT status, grade FROM session_enrollment WHERE id = $1`, keepID).Scan(&status, &grade)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != "Completed" || grade != "A" {
+			t.Errorf("expected the completed outcome to be kept, got %s/%s", status, grade)
+		}
+
+		if n := queryInt(t, db, `SELECT COUNT(*) FROM session_enrollment WHERE id = $1`, dropID); n != 0 {
+			t.Error("expected the duplicate's enrollment to be deleted")
+		}
+		if n := queryInt(t, db, `SELECT personnel_id FROM session_enrollment WHERE id = $1`, movedID); n != survivor {
+			t.Errorf("expected the separate enrollment to move to %d, got %d", survivor, n)
+		}
+		if n := queryInt(t, db, `SELECT COUNT(*) FROM personnel WHERE id = $1`, duplicate); n != 0 {
+			t.Error("expected the duplicate officer to be deleted")
+		}
+	})
+
+	t.Run("attendance", func(t *testing.T) {
+		survivor, duplicate := newTestOfficer(t, db), newTestOfficer(t, db)
+		session := newTestSession(t, db, start, start.AddDate(0, 0, 2))
+
+		keepID := newTestEnrollment(t, db, survivor, session, "Enrolled")
+		dropID := newTestEnrollment(t, db, duplicate, session, "Enrolled")
+
+		// The survivor was marked on the first day, the duplicate on all three
+		mustExec(t, db, `
+			INSERT INTO session_attendance (session_enrollment_id, attended_on, present)
+			VALUES ($1, $3, true), ($2, $3, false), ($2, $4, true), ($2, $5, false)`,
+			keepID, dropID, start, start.AddDate(0, 0, 1), start.AddDate(0, 0, 2))
+
+		_, err := m.Merge(survivor, duplicate)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rows, err := db.Query(`
+			SELECT attended_on, present FROM session_attendance
+			WHERE session_enrollment_id = $1
+			ORDER BY attended_on`, keepID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		got := []bool{}
+		for rows.Next() {
+			var day time.Time
+			var present bool
+			if err := rows.Scan(&day, &present); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, present)
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatal(err)
+		}
+
+		// The survivor's own record wins on the day both were marked
+		want := []bool{true, true, false}
+		if !slices.Equal(got, want) {
+			t.Errorf("expected attendance %v, got %v", want, got)
+		}
+	})
+
+	t.Run("survey submissions", func(t *testing.T) {
+		survivor, duplicate := newTestOfficer(t, db), newTestOfficer(t, db)
+		session := newTestSession(t, db, start, start)
+
+		keepID := newTestEnrollment(t, db, survivor, session, "Completed")
+		dropID := newTestEnrollment(t, db, duplicate, session, "Completed")
+
+		// Both answered the first survey, only the duplicate answered the second
+		shared, sharedQuestions := newTestSurvey(t, db, 2)
+		other, otherQuestions := newTestSurvey(t, db, 1)
+
+		keepSubmission := newTestSubmission(t, db, shared, keepID, map[int64]int{sharedQuestions[0]: 5})
+		newTestSubmission(t, db, shared, dropID, map[int64]int{sharedQuestions[0]: 1, sharedQuestions[1]: 2})
+		otherSubmission := newTestSubmission(t, db, other, dropID, map[int64]int{otherQuestions[0]: 4})
+
+		_, err := m.Merge(survivor, duplicate)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for question, want := range map[int64]int64{sharedQuestions[0]: 5, sharedQuestions[1]: 2} {
+			got := queryInt(t, db, `SELECT score FROM survey_answers WHERE submission_id = $1 AND question_id = $2`,
+				keepSubmission, question)
+			if got != want {
+				t.Errorf("expected question %d to have score %d, got %d", question, want, got)
+			}
+		}
+
+		if n := queryInt(t, db, `SELECT session_enrollment_id FROM survey_submissions WHERE id = $1`, otherSubmission); n != keepID {
+			t.Errorf("expected the second submission to move to enrollment %d, got %d", keepID, n)
+		}
+	})
+
+	t.Run("feedback reminders", func(t *testing.T) {
+		survivor, duplicate := newTestOfficer(t, db), newTestOfficer(t, db)
+		session := newTestSession(t, db, start, start)
+
+		keepID := newTestEnrollment(t, db, survivor, session, "Completed")
+		dropID := newTestEnrollment(t, db, duplicate, session, "Completed")
+
+		lastSent := time.Now().Add(-time.Hour).Truncate(time.Second)
+		mustExec(t, db, `
+			INSERT INTO feedback_reminders (session_enrollment_id, reminders_sent, last_sent_at)
+			VALUES ($1, 2, $2)`, dropID, lastSent)
+
+		_, err := m.Merge(survivor, duplicate)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var sent int
+		var sentAt time.Time
+		err = db.QueryRow(`SELECT reminders_sent, last_sent_at FROM feedback_reminders WHERE session_enrollment_id = $1`,
+			keepID).Scan(&sent, &sentAt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sent != 2 || !sentAt.Equal(lastSent) {
+			t.Errorf("expected 2 reminders last sent at %v, got %d at %v", lastSent, sent, sentAt)
+		}
+	})
+
+	t.Run("two user accounts", func(t *testing.T) {
+		survivor, duplicate := newTestOfficer(t, db), newTestOfficer(t, db)
+		for _, officer := range []int64{survivor, duplicate} {
+			user := newTestUser(t, db, "pa55word1234", "System User")
+			mustExec(t, db, `UPDATE users SET personnel_id = $1 WHERE id = $2`, officer, user.ID)
+		}
+
+		_, err := m.Merge(survivor, duplicate)
+		if !errors.Is(err, ErrMergeConflict) {
+			t.Errorf("expected ErrMergeConflict, got %v", err)
+		}
+	})
+
+	t.Run("pending invitation follows its account", func(t *testing.T) {
+		survivor, duplicate := newTestOfficer(t, db), newTestOfficer(t, db)
+		keepID, dropID := newTestFacilitator(t, db, survivor), newTestFacilitator(t, db, duplicate)
+		invitationID := newTestInvitation(t, db, dropID)
+
+		summary, err := m.Merge(survivor, duplicate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !summary.FacilitatorMerged {
+			t.Error("expected the facilitators to be merged")
+		}
+
+		var facilitatorID int64
+		var revoked bool
+		err = db.QueryRow(`SELECT facilitator_id, revoked_at IS NOT NULL FROM facilitator_invitations WHERE id = $1`,
+			invitationID).Scan(&facilitatorID, &revoked)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if facilitatorID != keepID || revoked {
+			t.Errorf("expected a pending invitation for facilitator %d, got facilitator %d, revoked %t", keepID, facilitatorID, revoked)
+		}
+	})
+
+	t.Run("pending invitation revoked when its account cannot move", func(t *testing.T) {
+		survivor, duplicate := newTestOfficer(t, db), newTestOfficer(t, db)
+		keepID, dropID := newTestFacilitator(t, db, survivor), newTestFacilitator(t, db, duplicate)
+		keeper := newTestUser(t, db, "pa55word1234", RoleFacilitator)
+		mustExec(t, db, `UPDATE users SET facilitator_id = $1 WHERE id = $2`, keepID, keeper.ID)
+		invitationID := newTestInvitation(t, db, dropID)
+
+		_, err := m.Merge(survivor, duplicate)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var facilitatorID int64
+		var revoked bool
+		err = db.QueryRow(`SELECT facilitator_id, revoked_at IS NOT NULL FROM facilitator_invitations WHERE id = $1`,
+			invitationID).Scan(&facilitatorID, &revoked)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if facilitatorID != keepID || !revoked {
+			t.Errorf("expected a revoked invitation for facilitator %d, got facilitator %d, revoked %t", keepID, facilitatorID, revoked)
+		}
+		n := queryInt(t, db, `
+			SELECT COUNT(*) FROM tokens
+			WHERE scope = $1 AND user_id = (SELECT user_id FROM facilitator_invitations WHERE id = $2)`,
+			ScopeInvitation, invitationID)
+		if n != 0 {
+			t.Errorf("expected the invitation token to be deleted, found %d", n)
+		}
+	})
+}
+
+// newTestInvitation invites a facilitator, creating their pending user account
+// and an invitation token.
+func newTestInvitation(t *testing.T, db *sql.DB, facilitatorID int64) int64 {
+	t.Helper()
+
+	user := newTestUser(t, db, "pa55word1234", RoleFacilitator)
+	mustExec(t, db, `UPDATE users SET activated = false, facilitator_id = $1 WHERE id = $2`, facilitatorID, user.ID)
+
+	token, err := generateToken(user.ID, time.Hour, ScopeInvitation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustExec(t, db, `INSERT INTO tokens (hash, user_id, expiry, scope) VALUES ($1, $2, $3, $4)`,
+		token.Hash, token.UserID, token.Expiry, token.Scope)
+
+	return insertID(t, db, `
+		INSERT INTO facilitator_invitations (facilitator_id, user_id, email, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`, facilitatorID, user.ID, user.Email, token.Expiry)
+}
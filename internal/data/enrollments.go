@@ -0,0 +1,309 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+// Enrollment defines the structure for an officer's enrollment in a training session.
+type Enrollment struct {
+	ID             int64      `json:"id"`
+	SessionID      int64      `json:"session_id"`
+	PersonnelID    int64      `json:"personnel_id"`
+	Status         string     `json:"status"`
+	CompletionDate *time.Time `json:"completion_date,omitempty"`
+	Grade          string     `json:"grade,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	Session        *Nit       `json:"session,omitempty"`
+}
+
+// EnrollmentStatuses lists the accepted values of Enrollment.Status.
+var EnrollmentStatuses = []string{"Enrolled", "Completed", "Failed", "Withdrew"}
+
+// RosterEntry is an officer enrolled in a session along with their outcome and
+// how many days of the session they have been marked present or absent.
+type RosterEntry struct {
+	EnrollmentID   int64      `json:"enrollment_id"`
+	Status         string     `json:"status"`
+	CompletionDate *time.Time `json:"completion_date,omitempty"`
+	Grade          string     `json:"grade,omitempty"`
+	DaysPresent    int        `json:"days_present"`
+	DaysAbsent     int        `json:"days_absent"`
+	Officer        *Officer   `json:"officer"`
+}
+
+// TranscriptEntry is a single completed training session on an officer's transcript.
+type TranscriptEntry struct {
+	EnrollmentID   int64      `json:"enrollment_id"`
+	SessionID      int64      `json:"session_id"`
+	CourseID       int64      `json:"course_id"`
+	CourseTitle    string     `json:"course_title"`
+	Category       string     `json:"category"`
+	CreditHours    float64    `json:"credit_hours"`
+	Location       string     `json:"location"`
+	StartDate      time.Time  `json:"start_date"`
+	EndDate        time.Time  `json:"end_date"`
+	CompletionDate *time.Time `json:"completion_date,omitempty"`
+}
+
+// Transcript lists the completed training for an officer along with the credit hours earned.
+type Transcript struct {
+	PersonnelID      int64              `json:"personnel_id"`
+	Entries          []*TranscriptEntry `json:"entries"`
+	TotalCreditHours float64            `json:"total_credit_hours"`
+}
+
+// ValidateEnrollmentOutcome validates the outcome recorded for an enrollment
+func ValidateEnrollmentOutcome(v *validator.Validator, enrollment *Enrollment) {
+	v.Check(slices.Contains(EnrollmentStatuses, enrollment.Status), "status", "must be one of Enrolled, Completed, Failed or Withdrew")
+	v.Check(len(enrollment.Grade) <= 20, "grade", "must not be more than 20 bytes long")
+	if enrollment.Status != "Completed" {
+		v.Check(enrollment.CompletionDate == nil, "completion_date", "must only be provided for completed enrollments")
+	}
+}
+
+// EnrollmentModel wraps the database connection pool.
+type EnrollmentModel struct {
+	DB *sql.DB
+}
+
+// GetAllForPersonnel returns a slice of the enrollments belonging to a specific
+// officer along with the related resources requested in expand.
+func (m EnrollmentModel) GetAllForPersonnel(personnelID int64, filters Filters, expand Expand) ([]*Enrollment, Metadata, error) {
+	query := `
+		SELECT COUNT(*) OVER(), se.id, se.session_id, se.personnel_id, se.status, se.completion_date, COALESCE(se.grade, ''), se.created_at,
+			` + nitColumns + `
+		FROM session_enrollment se
+		INNER JOIN training_sessions ts ON ts.id = se.session_id
+		INNER JOIN courses c ON c.id = ts.course_id
+		WHERE se.personnel_id = $1
+		ORDER BY ts.start_date DESC, se.id
+		LIMIT $2 OFFSET $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, personnelID, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	enrollments := []*Enrollment{}
+
+	for rows.Next() {
+		var enrollment Enrollment
+		var nit Nit
+		var course Course
+		dest := []any{
+			&totalRecords,
+			&enrollment.ID,
+			&enrollment.SessionID,
+			&enrollment.PersonnelID,
+			&enrollment.Status,
+			&enrollment.CompletionDate,
+			&enrollment.Grade,
+			&enrollment.CreatedAt,
+		}
+		err := rows.Scan(append(dest, nit.dest(&course)...)...)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		if expand.Has("session") {
+			enrollment.Session = &nit
+			if expand.Has("session.course") {
+				course.ID = nit.CourseID
+				nit.Course = &course
+			}
+		}
+		enrollments = append(enrollments, &enrollment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return enrollments, metadata, nil
+}
+
+// GetTranscript returns every session a specific officer has completed.
+func (m EnrollmentModel) GetTranscript(personnelID int64) (*Transcript, error) {
+	query := `
+		SELECT se.id, ts.id, c.id, c.title, c.category, c.credit_hours, COALESCE(ts.location, ''), ts.start_date, ts.end_date, se.completion_date
+		FROM session_enrollment se
+		INNER JOIN training_sessions ts ON ts.id = se.session_id
+		INNER JOIN courses c ON c.id = ts.course_id
+		WHERE se.personnel_id = $1 AND se.status = 'Completed'
+		ORDER BY COALESCE(se.completion_date, ts.end_date), se.id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, personnelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transcript := &Transcript{
+		PersonnelID: personnelID,
+		Entries:     []*TranscriptEntry{},
+	}
+
+	for rows.Next() {
+		var entry TranscriptEntry
+		err := rows.Scan(
+			&entry.EnrollmentID,
+			&entry.SessionID,
+			&entry.CourseID,
+			&entry.CourseTitle,
+			&entry.Category,
+			&entry.CreditHours,
+			&entry.Location,
+			&entry.StartDate,
+			&entry.EndDate,
+			&entry.CompletionDate,
+		)
+		if err != nil {
+			return nil, err
+		}
+		transcript.Entries = append(transcript.Entries, &entry)
+		transcript.TotalCreditHours += entry.CreditHours
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return transcript, nil
+}
+
+// GetForSession retrieves a specific enrollment in a specific session.
+func (m EnrollmentModel) GetForSession(sessionID, id int64) (*Enrollment, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT id, session_id, personnel_id, status, completion_date, COALESCE(grade, ''), created_at
+		FROM session_enrollment
+		WHERE id = $1 AND session_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var enrollment Enrollment
+
+	err := m.DB.QueryRowContext(ctx, query, id, sessionID).Scan(
+		&enrollment.ID,
+		&enrollment.SessionID,
+		&enrollment.PersonnelID,
+		&enrollment.Status,
+		&enrollment.CompletionDate,
+		&enrollment.Grade,
+		&enrollment.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &enrollment, nil
+}
+
+// UpdateOutcome records the status, completion date and grade of an enrollment.
+func (m EnrollmentModel) UpdateOutcome(enrollment *Enrollment) error {
+	query := `
+		UPDATE session_enrollment
+		SET status = $1, completion_date = $2, grade = NULLIF($3, '')
+		WHERE id = $4`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, enrollment.Status, enrollment.CompletionDate, enrollment.Grade, enrollment.ID)
+	if err != nil {
+		return translatePgError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetRoster returns every officer enrolled in a session, ordered by name, with
+// their rank and formation embedded.
+func (m EnrollmentModel) GetRoster(sessionID int64) ([]*RosterEntry, error) {
+	query := `
+		SELECT se.id, se.status, se.completion_date, COALESCE(se.grade, ''),
+			COUNT(sa.id) FILTER (WHERE sa.present),
+			COUNT(sa.id) FILTER (WHERE NOT sa.present),
+			` + officerColumns + `
+		FROM ` + officerTables + `
+		INNER JOIN session_enrollment se ON se.personnel_id = p.id
+		LEFT JOIN session_attendance sa ON sa.session_enrollment_id = se.id
+		WHERE se.session_id = $1
+		GROUP BY se.id, p.id, rk.id, fm.id, rg.id, po.id
+		ORDER BY p.last_name, p.first_name, se.id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	expand := NewExpand([]string{"rank", "formation"})
+	roster := []*RosterEntry{}
+
+	for rows.Next() {
+		var entry RosterEntry
+		var officer Officer
+		var rel officerRelations
+
+		dest := []any{
+			&entry.EnrollmentID,
+			&entry.Status,
+			&entry.CompletionDate,
+			&entry.Grade,
+			&entry.DaysPresent,
+			&entry.DaysAbsent,
+		}
+		err := rows.Scan(append(dest, rel.dest(&officer)...)...)
+		if err != nil {
+			return nil, err
+		}
+
+		rel.embed(&officer, expand)
+		entry.Officer = &officer
+
+		roster = append(roster, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return roster, nil
+}
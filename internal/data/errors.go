@@ -8,4 +8,9 @@ var (
 	ErrRecordNotFound  = errors.New("record not found")
 	ErrEditConflict    = errors.New("edit conflict")
 	ErrDuplicateRecord = errors.New("duplicate record")
+
+	// Constraint violations which translatePgError can report alongside ErrDuplicateRecord
+	ErrInvalidReference = errors.New("invalid reference")
+	ErrRecordInUse      = errors.New("record in use")
+	ErrCheckViolation   = errors.New("check violation")
 )
@@ -0,0 +1,386 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+	"github.com/lib/pq"
+)
+
+// EvaluationFilters restricts a course evaluation to sessions starting within a
+// date range, and optionally to sessions held in certain locations.
+type EvaluationFilters struct {
+	From      *time.Time
+	To        *time.Time
+	Locations []string
+}
+
+// ValidateEvaluationFilters validates an EvaluationFilters struct
+func ValidateEvaluationFilters(v *validator.Validator, f EvaluationFilters) {
+	v.Check(f.From == nil || f.To == nil || !f.To.Before(*f.From), "to", "must not be before from")
+	v.Check(len(f.Locations) <= 20, "location", "must not contain more than 20 locations")
+}
+
+// NetPromoter summarises how likely officers are to recommend a course, on a
+// scale of 0 to 10. Promoters answer 9 or 10, passives 7 or 8 and detractors 6
+// or below. Score is the percentage of promoters less that of detractors, from
+// -100 to 100, and is only reported once there are enough responses.
+type NetPromoter struct {
+	Responses  int      `json:"responses"`
+	Promoters  int      `json:"promoters"`
+	Passives   int      `json:"passives"`
+	Detractors int      `json:"detractors"`
+	Score      *float64 `json:"score"`
+}
+
+// EvaluationSummary describes the course ratings given for one or more
+// sessions. ResponseRate is the number of ratings divided by the number of
+// completed enrollments. The mean, median and distribution of scores are only
+// reported once there are at least the minimum number of ratings.
+type EvaluationSummary struct {
+	Responses            int          `json:"responses"`
+	CompletedEnrollments int          `json:"completed_enrollments"`
+	ResponseRate         *float64     `json:"response_rate"`
+	Reportable           bool         `json:"reportable"`
+	Mean                 *float64     `json:"mean"`
+	Median               *float64     `json:"median"`
+	Distribution         map[int]int  `json:"distribution"`
+	Recommend            *NetPromoter `json:"recommend"`
+}
+
+// SessionEvaluation summarises the course ratings given for a single session.
+type SessionEvaluation struct {
+	Session *Nit `json:"session"`
+	EvaluationSummary
+	tally *evaluationTally
+}
+
+// LocationEvaluation summarises the course ratings given for every session of a
+// course held in the same location.
+type LocationEvaluation struct {
+	Location string `json:"location"`
+	Sessions int    `json:"sessions"`
+	EvaluationSummary
+}
+
+// CourseEvaluation summarises the course ratings of a course overall, by
+// location and by session, so its deliveries can be compared side by side.
+type CourseEvaluation struct {
+	CourseID  int64                 `json:"course_id"`
+	Overall   EvaluationSummary     `json:"overall"`
+	Locations []*LocationEvaluation `json:"locations"`
+	Sessions  []*SessionEvaluation  `json:"sessions"`
+}
+
+// evaluationTally counts the ratings given each score and each recommendation,
+// and the enrollments they could have come from.
+type evaluationTally struct {
+	scores     map[int]int
+	recommends map[int]int
+	completed  int
+}
+
+func newEvaluationTally() *evaluationTally {
+	return &evaluationTally{scores: map[int]int{}, recommends: map[int]int{}}
+}
+
+// add records count ratings of the given score and recommendation. A nil
+// recommendation means the question was not answered.
+func (t *evaluationTally) add(score int, recommend *int, count int) {
+	t.scores[score] += count
+	if recommend != nil {
+		t.recommends[*recommend] += count
+	}
+}
+
+// merge adds the ratings and enrollments of other to the tally.
+func (t *evaluationTally) merge(other *evaluationTally) {
+	for score, count := range other.scores {
+		t.scores[score] += count
+	}
+	for recommend, count := range other.recommends {
+		t.recommends[recommend] += count
+	}
+	t.completed += other.completed
+}
+
+// summary works out the statistics of the tally.
+func (t *evaluationTally) summary(minResponses int) EvaluationSummary {
+	summary := EvaluationSummary{
+		Responses:            countOf(t.scores),
+		CompletedEnrollments: t.completed,
+		Recommend:            netPromoter(t.recommends, minResponses),
+	}
+
+	if t.completed > 0 {
+		rate := float64(summary.Responses) / float64(t.completed)
+		summary.ResponseRate = &rate
+	}
+
+	summary.Reportable = summary.Responses > 0 && summary.Responses >= minResponses
+	if summary.Reportable {
+		mean, median := meanOf(t.scores), medianOf(t.scores)
+		summary.Mean = &mean
+		summary.Median = &median
+		summary.Distribution = map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}
+		for score, count := range t.scores {
+			summary.Distribution[score] = count
+		}
+	}
+
+	return summary
+}
+
+// countOf returns the number of values counted.
+func countOf(counts map[int]int) int {
+	n := 0
+	for _, count := range counts {
+		n += count
+	}
+	return n
+}
+
+// meanOf returns the mean of the values counted, or zero if there are none.
+func meanOf(counts map[int]int) float64 {
+	n, total := 0, 0
+	for value, count := range counts {
+		n += count
+		total += value * count
+	}
+	if n == 0 {
+		return 0
+	}
+	return float64(total) / float64(n)
+}
+
+// medianOf returns the median of the values counted, averaging the middle two
+// when there is an even number of them, or zero if there are none.
+func medianOf(counts map[int]int) float64 {
+	n := countOf(counts)
+	if n == 0 {
+		return 0
+	}
+
+	values := make([]int, 0, len(counts))
+	for value := range counts {
+		values = append(values, value)
+	}
+	sort.Ints(values)
+
+	// valueAt returns the value at a position in the sorted values, counting from zero
+	valueAt := func(position int) int {
+		for _, value := range values {
+			if position < counts[value] {
+				return value
+			}
+			position -= counts[value]
+		}
+		return values[len(values)-1]
+	}
+
+	if n%2 == 1 {
+		return float64(valueAt(n / 2))
+	}
+	return float64(valueAt(n/2-1)+valueAt(n/2)) / 2
+}
+
+// netPromoter summarises the recommendations counted.
+func netPromoter(counts map[int]int, minResponses int) *NetPromoter {
+	nps := &NetPromoter{}
+
+	for value, count := range counts {
+		nps.Responses += count
+		switch {
+		case value >= 9:
+			nps.Promoters += count
+		case value >= 7:
+			nps.Passives += count
+		default:
+			nps.Detractors += count
+		}
+	}
+
+	if nps.Responses > 0 && nps.Responses >= minResponses {
+		score := float64(nps.Promoters-nps.Detractors) * 100 / float64(nps.Responses)
+		nps.Score = &score
+	}
+
+	return nps
+}
+
+// buildCourseEvaluation summarises each session and totals them by location
+// and overall. Sessions are expected in the order they are to be listed.
+func buildCourseEvaluation(courseID int64, sessions []*SessionEvaluation, minResponses int) *CourseEvaluation {
+	evaluation := &CourseEvaluation{
+		CourseID:  courseID,
+		Locations: []*LocationEvaluation{},
+		Sessions:  sessions,
+	}
+
+	overall := newEvaluationTally()
+	locations := map[string]*evaluationTally{}
+	counts := map[string]int{}
+	names := []string{}
+
+	for _, session := range sessions {
+		session.EvaluationSummary = session.tally.summary(minResponses)
+		overall.merge(session.tally)
+
+		location := strings.TrimSpace(session.Session.Location)
+		tally, ok := locations[location]
+		if !ok {
+			tally = newEvaluationTally()
+			locations[location] = tally
+			names = append(names, location)
+		}
+		tally.merge(session.tally)
+		counts[location]++
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		evaluation.Locations = append(evaluation.Locations, &LocationEvaluation{
+			Location:          name,
+			Sessions:          counts[name],
+			EvaluationSummary: locations[name].summary(minResponses),
+		})
+	}
+
+	evaluation.Overall = overall.summary(minResponses)
+
+	return evaluation
+}
+
+// getSessionEvaluations tallies the published course ratings of the sessions
+// matching the condition, in order of their start date.
+func (m FeedbackModel) getSessionEvaluations(ctx context.Context, condition string, args ...any) ([]*SessionEvaluation, error) {
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT ts.id, ts.course_id, ts.start_date, ts.end_date, COALESCE(ts.location, ''), ts.version,
+			COUNT(se.id) FILTER (WHERE se.status = 'Completed')
+		FROM training_sessions ts
+		LEFT JOIN session_enrollment se ON se.session_id = ts.id
+		WHERE `+condition+`
+		GROUP BY ts.id
+		ORDER BY ts.start_date, ts.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*SessionEvaluation{}
+	byID := map[int64]*SessionEvaluation{}
+
+	for rows.Next() {
+		var session Nit
+		evaluation := &SessionEvaluation{Session: &session, tally: newEvaluationTally()}
+
+		err := rows.Scan(
+			&session.ID,
+			&session.CourseID,
+			&session.StartDate,
+			&session.EndDate,
+			&session.Location,
+			&session.Version,
+			&evaluation.tally.completed,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, evaluation)
+		byID[session.ID] = evaluation
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ratings, err := m.DB.QueryContext(ctx, `
+		SELECT ts.id, cr.score, cr.would_recommend, COUNT(*)
+		FROM course_ratings cr
+		INNER JOIN session_enrollment se ON se.id = cr.session_enrollment_id
+		INNER JOIN training_sessions ts ON ts.id = se.session_id
+		WHERE cr.status = 'published' AND `+condition+`
+		GROUP BY ts.id, cr.score, cr.would_recommend`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer ratings.Close()
+
+	for ratings.Next() {
+		var sessionID int64
+		var score, count int
+		var recommend sql.NullInt64
+
+		err := ratings.Scan(&sessionID, &score, &recommend, &count)
+		if err != nil {
+			return nil, err
+		}
+
+		session, ok := byID[sessionID]
+		if !ok {
+			continue
+		}
+
+		var r *int
+		if recommend.Valid {
+			value := int(recommend.Int64)
+			r = &value
+		}
+		session.tally.add(score, r, count)
+	}
+
+	if err = ratings.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// GetCourseEvaluation summarises the course ratings of a course overall, by
+// location and by session.
+func (m FeedbackModel) GetCourseEvaluation(courseID int64, filters EvaluationFilters, minResponses int) (*CourseEvaluation, error) {
+	locations := make([]string, len(filters.Locations))
+	for i, location := range filters.Locations {
+		locations[i] = strings.ToLower(strings.TrimSpace(location))
+	}
+
+	condition := `ts.course_id = $1
+		AND ($2::date IS NULL OR ts.start_date >= $2::date)
+		AND ($3::date IS NULL OR ts.start_date <= $3::date)
+		AND (cardinality($4::text[]) = 0 OR lower(trim(COALESCE(ts.location, ''))) = ANY($4))`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sessions, err := m.getSessionEvaluations(ctx, condition, courseID, filters.From, filters.To, pq.Array(locations))
+	if err != nil {
+		return nil, err
+	}
+
+	return buildCourseEvaluation(courseID, sessions, minResponses), nil
+}
+
+// GetSessionEvaluation summarises the course ratings of a single session.
+func (m FeedbackModel) GetSessionEvaluation(sessionID int64, minResponses int) (*SessionEvaluation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	sessions, err := m.getSessionEvaluations(ctx, `ts.id = $1`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sessions) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	session := sessions[0]
+	session.EvaluationSummary = session.tally.summary(minResponses)
+
+	return session, nil
+}
@@ -0,0 +1,100 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+func TestMedianOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		counts map[int]int
+		want   float64
+	}{
+		{"empty", map[int]int{}, 0},
+		{"odd", map[int]int{1: 1, 4: 1, 5: 1}, 4},
+		{"even", map[int]int{2: 1, 3: 1, 5: 2}, 4},
+		{"repeated", map[int]int{3: 4, 5: 1}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := medianOf(tt.counts); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNetPromoter(t *testing.T) {
+	nps := netPromoter(map[int]int{10: 3, 9: 2, 8: 2, 6: 2, 0: 1}, 5)
+
+	if nps.Promoters != 5 || nps.Passives != 2 || nps.Detractors != 3 {
+		t.Errorf("expected 5 promoters, 2 passives and 3 detractors, got %+v", nps)
+	}
+	if nps.Score == nil || *nps.Score != 20 {
+		t.Errorf("expected a score of 20, got %v", nps.Score)
+	}
+
+	if nps := netPromoter(map[int]int{10: 2}, 5); nps.Score != nil {
+		t.Errorf("expected the score to be withheld, got %v", *nps.Score)
+	}
+}
+
+func TestBuildCourseEvaluation(t *testing.T) {
+	session := func(id int64, location string, completed int, scores map[int]int, recommend int) *SessionEvaluation {
+		tally := newEvaluationTally()
+		tally.completed = completed
+		for score, count := range scores {
+			tally.add(score, &recommend, count)
+		}
+		return &SessionEvaluation{Session: &Nit{ID: id, Location: location}, tally: tally}
+	}
+
+	sessions := []*SessionEvaluation{
+		session(1, "San Ignacio", 10, map[int]int{5: 4, 4: 2}, 10),
+		session(2, "Belize City", 8, map[int]int{2: 2, 3: 1}, 5),
+		session(3, "San Ignacio ", 4, map[int]int{4: 1}, 8),
+	}
+
+	evaluation := buildCourseEvaluation(7, sessions, 3)
+
+	if evaluation.Overall.Responses != 10 || evaluation.Overall.CompletedEnrollments != 22 {
+		t.Errorf("expected 10 responses from 22 enrollments, got %+v", evaluation.Overall)
+	}
+	if len(evaluation.Locations) != 2 || evaluation.Locations[0].Location != "Belize City" {
+		t.Fatalf("expected Belize City and San Ignacio, got %+v", evaluation.Locations)
+	}
+
+	sanIgnacio := evaluation.Locations[1]
+	if sanIgnacio.Sessions != 2 || sanIgnacio.Responses != 7 {
+		t.Errorf("expected 7 responses over 2 sessions in San Ignacio, got %+v", sanIgnacio)
+	}
+	if sanIgnacio.ResponseRate == nil || *sanIgnacio.ResponseRate != 0.5 {
+		t.Errorf("expected a response rate of 0.5, got %v", sanIgnacio.ResponseRate)
+	}
+	if sanIgnacio.Median == nil || *sanIgnacio.Median != 5 {
+		t.Errorf("expected a median of 5, got %v", sanIgnacio.Median)
+	}
+
+	if sessions[2].Reportable || sessions[2].Mean != nil || sessions[2].Distribution != nil {
+		t.Errorf("expected a session below the minimum to be withheld, got %+v", sessions[2].EvaluationSummary)
+	}
+	if sessions[1].Recommend.Score == nil || *sessions[1].Recommend.Score != -100 {
+		t.Errorf("expected a score of -100, got %v", sessions[1].Recommend.Score)
+	}
+}
+
+func TestValidateEvaluationFilters(t *testing.T) {
+	from := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, -1, 0)
+
+	v := validator.New()
+	ValidateEvaluationFilters(v, EvaluationFilters{From: &from, To: &to})
+
+	if _, ok := v.Errors["to"]; !ok {
+		t.Errorf("expected an error for to before from")
+	}
+}
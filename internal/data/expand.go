@@ -0,0 +1,51 @@
+package data
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+// Safelists of the related resources which can be embedded in each response.
+var (
+	OfficerExpandSafelist     = []string{"rank", "formation", "formation.region", "posting"}
+	NitExpandSafelist         = []string{"course", "facilitators"}
+	FacilitatorExpandSafelist = []string{"personnel"}
+	EnrollmentExpandSafelist  = []string{"session", "session.course"}
+)
+
+// Expand holds the related resources a client asked to have embedded in a response.
+type Expand struct {
+	requested []string
+	paths     map[string]bool
+}
+
+// NewExpand builds an Expand from a list of dotted paths such as "formation.region".
+// Asking for a nested path also expands each of its parents.
+func NewExpand(paths []string) Expand {
+	e := Expand{paths: make(map[string]bool)}
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		e.requested = append(e.requested, path)
+		parts := strings.Split(path, ".")
+		for i := range parts {
+			e.paths[strings.Join(parts[:i+1], ".")] = true
+		}
+	}
+	return e
+}
+
+// Has reports whether the related resource at path should be embedded.
+func (e Expand) Has(path string) bool {
+	return e.paths[path]
+}
+
+func ValidateExpand(v *validator.Validator, e Expand, safelist []string) {
+	for _, path := range e.requested {
+		v.Check(slices.Contains(safelist, path), "expand", "must only contain: "+strings.Join(safelist, ", "))
+	}
+}
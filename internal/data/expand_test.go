@@ -0,0 +1,94 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+func TestNewExpand(t *testing.T) {
+	t.Run("nested path expands its parents", func(t *testing.T) {
+		e := NewExpand([]string{"formation.region"})
+
+		if !e.Has("formation") {
+			t.Error("expected formation to be expanded, but it was not")
+		}
+		if !e.Has("formation.region") {
+			t.Error("expected formation.region to be expanded, but it was not")
+		}
+		if e.Has("rank") {
+			t.Error("expected rank not to be expanded, but it was")
+		}
+	})
+
+	t.Run("blank entries are ignored", func(t *testing.T) {
+		e := NewExpand([]string{"", " rank "})
+
+		if !e.Has("rank") {
+			t.Error("expected rank to be expanded, but it was not")
+		}
+
+		v := validator.New()
+		ValidateExpand(v, e, OfficerExpandSafelist)
+		if !v.IsEmpty() {
+			t.Errorf("expected expand to be valid, but got errors: %v", v.Errors)
+		}
+	})
+
+	t.Run("zero value expands nothing", func(t *testing.T) {
+		var e Expand
+
+		if e.Has("course") {
+			t.Error("expected course not to be expanded, but it was")
+		}
+	})
+}
+
+func TestValidateExpand(t *testing.T) {
+	tests := []struct {
+		name      string
+		paths     []string
+		safelist  []string
+		wantValid bool
+	}{
+		{
+			name:      "officer relations",
+			paths:     []string{"rank", "formation.region", "posting"},
+			safelist:  OfficerExpandSafelist,
+			wantValid: true,
+		},
+		{
+			name:      "nit relations",
+			paths:     []string{"course", "facilitators"},
+			safelist:  NitExpandSafelist,
+			wantValid: true,
+		},
+		{
+			name:      "unknown relation",
+			paths:     []string{"rank", "salary"},
+			safelist:  OfficerExpandSafelist,
+			wantValid: false,
+		},
+		{
+			name:      "relation from another resource",
+			paths:     []string{"course"},
+			safelist:  FacilitatorExpandSafelist,
+			wantValid: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			ValidateExpand(v, NewExpand(tt.paths), tt.safelist)
+
+			if tt.wantValid && !v.IsEmpty() {
+				t.Errorf("expected expand to be valid, but got errors: %v", v.Errors)
+			}
+
+			if !tt.wantValid && v.IsEmpty() {
+				t.Error("expected expand to be invalid, but it was valid")
+			}
+		})
+	}
+}
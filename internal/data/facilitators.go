@@ -5,6 +5,9 @@ import (
 	"database/sql"
 	"errors"
 	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+	"github.com/lib/pq"
 )
 
 // Facilitator defines the structure for a facilitator.
@@ -14,7 +17,57 @@ type Facilitator struct {
 	LastName    string    `json:"last_name"`
 	Email       string    `json:"email"`
 	PersonnelID NullInt64 `json:"personnel_id,omitempty"`
-	Version     int32     `json:"version"`
+	// MaxSessionsPerMonth is the most sessions the facilitator should be
+	// assigned to in a single calendar month.
+	MaxSessionsPerMonth int32    `json:"max_sessions_per_month"`
+	Version             int32    `json:"version"`
+	Personnel           *Officer `json:"personnel,omitempty"`
+}
+
+// DefaultMaxSessionsPerMonth is the workload limit given to new facilitators.
+const DefaultMaxSessionsPerMonth = 4
+
+// facilitatorColumns and facilitatorTables are shared by the facilitator queries
+// and match the destinations returned by Facilitator.dest. A facilitator linked
+// to an officer takes their name from the personnel record, so it follows any
+// change to the officer; guest facilitators keep the name stored against them.
+const (
+	facilitatorColumns = `f.id, COALESCE(fp.first_name, f.first_name) AS first_name, COALESCE(fp.last_name, f.last_name) AS last_name,
+		COALESCE(f.email, '') AS email, f.personnel_id, f.max_sessions_per_month, f.version`
+
+	facilitatorTables = `facilitators f
+		LEFT JOIN personnel fp ON fp.id = f.personnel_id`
+)
+
+// dest returns the scan destinations for facilitatorColumns.
+func (facilitator *Facilitator) dest() []any {
+	return []any{
+		&facilitator.ID,
+		&facilitator.FirstName,
+		&facilitator.LastName,
+		&facilitator.Email,
+		(*sql.NullInt64)(&facilitator.PersonnelID),
+		&facilitator.MaxSessionsPerMonth,
+		&facilitator.Version,
+	}
+}
+
+// ValidateFacilitator validates a Facilitator struct
+func ValidateFacilitator(v *validator.Validator, facilitator *Facilitator) {
+	v.Check(facilitator.FirstName != "", "first_name", "must be provided")
+	v.Check(len(facilitator.FirstName) <= 100, "first_name", "must not be more than 100 bytes long")
+	v.Check(facilitator.LastName != "", "last_name", "must be provided")
+	v.Check(len(facilitator.LastName) <= 100, "last_name", "must not be more than 100 bytes long")
+	v.Check(len(facilitator.Email) <= 255, "email", "must not be more than 255 bytes long")
+	v.Check(facilitator.MaxSessionsPerMonth >= 0, "max_sessions_per_month", "must not be negative")
+}
+
+// ValidateOfficerFacilitatorName checks that a facilitator linked to an officer
+// is not given a name of its own, as it always takes the officer's. Empty names
+// are treated as not given.
+func ValidateOfficerFacilitatorName(v *validator.Validator, officer *Officer, firstName, lastName string) {
+	v.Check(firstName == "" || firstName == officer.FirstName, "first_name", "is taken from the linked officer and must be changed on the officer record")
+	v.Check(lastName == "" || lastName == officer.LastName, "last_name", "is taken from the linked officer and must be changed on the officer record")
 }
 
 // FacilitatorModel wraps the database connection pool.
@@ -24,28 +77,27 @@ type FacilitatorModel struct {
 
 // Get retrieves a specific facilitator by ID.
 func (m FacilitatorModel) Get(id int64) (*Facilitator, error) {
+	return m.GetExpanded(id, Expand{})
+}
+
+// GetExpanded retrieves a specific facilitator by ID along with the related
+// resources requested in expand.
+func (m FacilitatorModel) GetExpanded(id int64, expand Expand) (*Facilitator, error) {
 	if id < 1 {
 		return nil, ErrRecordNotFound
 	}
 
 	query := `
-		SELECT id, first_name, last_name, email, personnel_id, version
-		FROM facilitators
-		WHERE id = $1`
+		SELECT ` + facilitatorColumns + `
+		FROM ` + facilitatorTables + `
+		WHERE f.id = $1`
 
 	var facilitator Facilitator
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	err := m.DB.QueryRowContext(ctx, query, id).Scan(
-		&facilitator.ID,
-		&facilitator.FirstName,
-		&facilitator.LastName,
-		&facilitator.Email,
-		(*sql.NullInt64)(&facilitator.PersonnelID),
-		&facilitator.Version,
-	)
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(facilitator.dest()...)
 
 	if err != nil {
 		switch {
@@ -56,9 +108,45 @@ func (m FacilitatorModel) Get(id int64) (*Facilitator, error) {
 		}
 	}
 
+	err = m.embed(ctx, []*Facilitator{&facilitator}, expand)
+	if err != nil {
+		return nil, err
+	}
+
 	return &facilitator, nil
 }
 
+// embed attaches the related resources requested in expand to the facilitators.
+// Linked officers for every facilitator are loaded with a single query.
+func (m FacilitatorModel) embed(ctx context.Context, facilitators []*Facilitator, expand Expand) error {
+	if !expand.Has("personnel") {
+		return nil
+	}
+
+	ids := []int64{}
+	for _, facilitator := range facilitators {
+		if facilitator.PersonnelID.Valid {
+			ids = append(ids, facilitator.PersonnelID.Int64)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	officers, err := OfficerModel{DB: m.DB}.getAllByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, facilitator := range facilitators {
+		if facilitator.PersonnelID.Valid {
+			facilitator.Personnel = officers[facilitator.PersonnelID.Int64]
+		}
+	}
+
+	return nil
+}
+
 // GetByPersonnelID retrieves a specific facilitator by personnel ID.
 func (m FacilitatorModel) GetByPersonnelID(id int64) (*Facilitator, error) {
 	if id < 1 {
@@ -66,23 +154,16 @@ func (m FacilitatorModel) GetByPersonnelID(id int64) (*Facilitator, error) {
 	}
 
 	query := `
-		SELECT id, first_name, last_name, email, personnel_id, version
-		FROM facilitators
-		WHERE personnel_id = $1`
+		SELECT ` + facilitatorColumns + `
+		FROM ` + facilitatorTables + `
+		WHERE f.personnel_id = $1`
 
 	var facilitator Facilitator
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	err := m.DB.QueryRowContext(ctx, query, id).Scan(
-		&facilitator.ID,
-		&facilitator.FirstName,
-		&facilitator.LastName,
-		&facilitator.Email,
-		(*sql.NullInt64)(&facilitator.PersonnelID),
-		&facilitator.Version,
-	)
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(facilitator.dest()...)
 
 	if err != nil {
 		switch {
@@ -100,8 +181,8 @@ func (m FacilitatorModel) GetByPersonnelID(id int64) (*Facilitator, error) {
 func (m FacilitatorModel) Update(facilitator *Facilitator) error {
 	query := `
 		UPDATE facilitators
-		SET first_name = $1, last_name = $2, email = $3, personnel_id = $4, version = version + 1
-		WHERE id = $5 AND version = $6
+		SET first_name = $1, last_name = $2, email = NULLIF($3, ''), personnel_id = $4, max_sessions_per_month = $5, version = version + 1
+		WHERE id = $6 AND version = $7
 		RETURNING version`
 
 	args := []any{
@@ -109,6 +190,7 @@ func (m FacilitatorModel) Update(facilitator *Facilitator) error {
 		facilitator.LastName,
 		facilitator.Email,
 		sql.NullInt64(facilitator.PersonnelID),
+		facilitator.MaxSessionsPerMonth,
 		facilitator.ID,
 		facilitator.Version,
 	}
@@ -122,7 +204,7 @@ func (m FacilitatorModel) Update(facilitator *Facilitator) error {
 		case errors.Is(err, sql.ErrNoRows):
 			return ErrEditConflict
 		default:
-			return err
+			return translatePgError(err)
 		}
 	}
 	return nil
@@ -131,8 +213,8 @@ func (m FacilitatorModel) Update(facilitator *Facilitator) error {
 // Create creates a new facilitator.
 func (m FacilitatorModel) Create(facilitator *Facilitator) error {
 	query := `
-		INSERT INTO facilitators (first_name, last_name, email, personnel_id)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO facilitators (first_name, last_name, email, personnel_id, max_sessions_per_month)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5)
 		RETURNING id, version`
 
 	args := []any{
@@ -140,20 +222,61 @@ func (m FacilitatorModel) Create(facilitator *Facilitator) error {
 		facilitator.LastName,
 		facilitator.Email,
 		sql.NullInt64(facilitator.PersonnelID),
+		facilitator.MaxSessionsPerMonth,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&facilitator.ID, &facilitator.Version)
+	return translatePgError(err)
+}
+
+// CreateFromOfficer creates a facilitator from an existing officer, taking their
+// name from the personnel record. ErrRecordNotFound is returned if the officer
+// does not exist.
+func (m FacilitatorModel) CreateFromOfficer(facilitator *Facilitator) error {
+	query := `
+		INSERT INTO facilitators (first_name, last_name, email, personnel_id, max_sessions_per_month)
+		SELECT p.first_name, p.last_name, NULLIF($2, ''), p.id, $3
+		FROM personnel p
+		WHERE p.id = $1
+		RETURNING id, first_name, last_name, version`
+
+	args := []any{
+		facilitator.PersonnelID.Int64,
+		facilitator.Email,
+		facilitator.MaxSessionsPerMonth,
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	return m.DB.QueryRowContext(ctx, query, args...).Scan(&facilitator.ID, &facilitator.Version)
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(
+		&facilitator.ID,
+		&facilitator.FirstName,
+		&facilitator.LastName,
+		&facilitator.Version,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return translatePgError(err)
+		}
+	}
+
+	return nil
 }
 
-// GetAll returns a slice of all facilitators.
-func (m FacilitatorModel) GetAll(filters Filters) ([]*Facilitator, Metadata, error) {
+// GetAll returns a slice of all facilitators along with the related resources
+// requested in expand.
+func (m FacilitatorModel) GetAll(filters Filters, expand Expand) ([]*Facilitator, Metadata, error) {
 	query := `
-		SELECT COUNT(*) OVER(), id, first_name, last_name, email, personnel_id, version
-		FROM facilitators
-		ORDER BY id
+		SELECT COUNT(*) OVER(), ` + facilitatorColumns + `
+		FROM ` + facilitatorTables + `
+		ORDER BY f.id
 		LIMIT $1 OFFSET $2`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -170,15 +293,7 @@ func (m FacilitatorModel) GetAll(filters Filters) ([]*Facilitator, Metadata, err
 
 	for rows.Next() {
 		var facilitator Facilitator
-		err := rows.Scan(
-			&totalRecords,
-			&facilitator.ID,
-			&facilitator.FirstName,
-			&facilitator.LastName,
-			&facilitator.Email,
-			(*sql.NullInt64)(&facilitator.PersonnelID),
-			&facilitator.Version,
-		)
+		err := rows.Scan(append([]any{&totalRecords}, facilitator.dest()...)...)
 		if err != nil {
 			return nil, Metadata{}, err
 		}
@@ -189,6 +304,11 @@ func (m FacilitatorModel) GetAll(filters Filters) ([]*Facilitator, Metadata, err
 		return nil, Metadata{}, err
 	}
 
+	err = m.embed(ctx, facilitators, expand)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
 	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
 
 	return facilitators, metadata, nil
@@ -209,7 +329,7 @@ func (m FacilitatorModel) Delete(id int64) error {
 
 	result, err := m.DB.ExecContext(ctx, query, id)
 	if err != nil {
-		return err
+		return translatePgError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -224,11 +344,12 @@ func (m FacilitatorModel) Delete(id int64) error {
 	return nil
 }
 
-// GetAllForSession returns a slice of all facilitators for a specific session.
-func (m FacilitatorModel) GetAllForSession(sessionID int64, filters Filters) ([]*Facilitator, Metadata, error) {
+// GetAllForSession returns a slice of all facilitators for a specific session
+// along with the related resources requested in expand.
+func (m FacilitatorModel) GetAllForSession(sessionID int64, filters Filters, expand Expand) ([]*Facilitator, Metadata, error) {
 	query := `
-		SELECT COUNT(*) OVER(), f.id, f.first_name, f.last_name, f.email, f.personnel_id, f.version
-		FROM facilitators f
+		SELECT COUNT(*) OVER(), ` + facilitatorColumns + `
+		FROM ` + facilitatorTables + `
 		INNER JOIN session_facilitators sf ON f.id = sf.facilitator_id
 		WHERE sf.session_id = $1
 		ORDER BY f.id
@@ -248,15 +369,7 @@ func (m FacilitatorModel) GetAllForSession(sessionID int64, filters Filters) ([]
 
 	for rows.Next() {
 		var facilitator Facilitator
-		err := rows.Scan(
-			&totalRecords,
-			&facilitator.ID,
-			&facilitator.FirstName,
-			&facilitator.LastName,
-			&facilitator.Email,
-			(*sql.NullInt64)(&facilitator.PersonnelID),
-			&facilitator.Version,
-		)
+		err := rows.Scan(append([]any{&totalRecords}, facilitator.dest()...)...)
 		if err != nil {
 			return nil, Metadata{}, err
 		}
@@ -267,13 +380,55 @@ func (m FacilitatorModel) GetAllForSession(sessionID int64, filters Filters) ([]
 		return nil, Metadata{}, err
 	}
 
+	err = m.embed(ctx, facilitators, expand)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
 	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
 
 	return facilitators, metadata, nil
 }
 
-// AssignToSession assigns a facilitator to a session.
-func (m FacilitatorModel) AssignToSession(sessionID, facilitatorID int64) error {
+// getAllForSessions retrieves the facilitators assigned to each of the given
+// sessions in a single query, keyed by session ID.
+func (m FacilitatorModel) getAllForSessions(ctx context.Context, sessionIDs []int64) (map[int64][]*Facilitator, error) {
+	query := `
+		SELECT sf.session_id, ` + facilitatorColumns + `
+		FROM ` + facilitatorTables + `
+		INNER JOIN session_facilitators sf ON f.id = sf.facilitator_id
+		WHERE sf.session_id = ANY($1)
+		ORDER BY sf.session_id, f.id`
+
+	rows, err := m.DB.QueryContext(ctx, query, pq.Array(sessionIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	facilitators := make(map[int64][]*Facilitator)
+
+	for rows.Next() {
+		var sessionID int64
+		var facilitator Facilitator
+		err := rows.Scan(append([]any{&sessionID}, facilitator.dest()...)...)
+		if err != nil {
+			return nil, err
+		}
+		facilitators[sessionID] = append(facilitators[sessionID], &facilitator)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return facilitators, nil
+}
+
+// AssignToSession assigns a facilitator to a session. When override is not nil
+// it is recorded in the same transaction, auditing an assignment made without a
+// valid qualification.
+func (m FacilitatorModel) AssignToSession(sessionID, facilitatorID int64, override *AssignmentOverride) error {
 	query := `
 		INSERT INTO session_facilitators (session_id, facilitator_id)
 		SELECT $1, $2
@@ -286,21 +441,64 @@ func (m FacilitatorModel) AssignToSession(sessionID, facilitatorID int64) error
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	result, err := m.DB.ExecContext(ctx, query, sessionID, facilitatorID)
+	tx, err := m.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, sessionID, facilitatorID)
+	if err != nil {
+		return translatePgError(err)
+	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
 
+	// The facilitator is already assigned to this session
 	if rowsAffected == 0 {
-		return errors.New("facilitator already assigned to this session")
+		return &ConstraintError{
+			Err:        ErrDuplicateRecord,
+			Constraint: "session_facilitators_pkey",
+			Table:      "session_facilitators",
+			Column:     "facilitator_id",
+		}
 	}
 
-	return nil
+	if override != nil {
+		query = `
+			INSERT INTO facilitator_assignment_overrides (session_id, facilitator_id, user_id, reason)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, created_at`
+
+		override.SessionID = sessionID
+		override.FacilitatorID = facilitatorID
+
+		err = tx.QueryRowContext(ctx, query, sessionID, facilitatorID, override.UserID, override.Reason).Scan(&override.ID, &override.CreatedAt)
+		if err != nil {
+			return translatePgError(err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// IsAssignedToSession reports whether a facilitator is assigned to a session.
+func (m FacilitatorModel) IsAssignedToSession(facilitatorID, sessionID int64) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM session_facilitators
+			WHERE facilitator_id = $1 AND session_id = $2
+		)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var assigned bool
+	err := m.DB.QueryRowContext(ctx, query, facilitatorID, sessionID).Scan(&assigned)
+	return assigned, err
 }
 
 // RemoveFromSession removes a facilitator from a session.
@@ -0,0 +1,30 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+func TestValidateOfficerFacilitatorName(t *testing.T) {
+	officer := &Officer{FirstName: "Maria", LastName: "Gonzalez"}
+
+	t.Run("names omitted or matching", func(t *testing.T) {
+		v := validator.New()
+		ValidateOfficerFacilitatorName(v, officer, "", "Gonzalez")
+		if !v.IsEmpty() {
+			t.Errorf("expected name to be valid, but got errors: %v", v.Errors)
+		}
+	})
+
+	t.Run("name differs from the officer", func(t *testing.T) {
+		v := validator.New()
+		ValidateOfficerFacilitatorName(v, officer, "Mary", "")
+		if _, exists := v.Errors["first_name"]; !exists {
+			t.Error("expected error on first_name field, but it was not found")
+		}
+		if _, exists := v.Errors["last_name"]; exists {
+			t.Error("expected no error on last_name field")
+		}
+	})
+}
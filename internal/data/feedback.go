@@ -1,29 +1,211 @@
 package data
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"slices"
 	"time"
 
+	"github.com/Syha-01/national-inservice-training/internal/validator"
 	"github.com/lib/pq"
 )
 
+// Reasons a rating cannot be accepted, reported by FeedbackEligibility.Check.
+var (
+	ErrNotEnrollmentOwner     = errors.New("enrollment belongs to another officer")
+	ErrCourseMismatch         = errors.New("enrollment is for another course")
+	ErrSessionNotStarted      = errors.New("session has not started")
+	ErrFacilitatorNotAssigned = errors.New("facilitator is not assigned to the session")
+)
+
+// FeedbackEligibility holds what decides whether an enrollment may be rated:
+// who it belongs to, the course and start of its session, and whether the
+// facilitator being rated is assigned to that session.
+type FeedbackEligibility struct {
+	EnrollmentID        int64
+	PersonnelID         int64
+	SessionID           int64
+	CourseID            int64
+	SessionStart        time.Time
+	FacilitatorAssigned bool
+}
+
+// Check reports why the officer with personnelID may not rate the enrollment,
+// or nil if they may. A courseID or facilitatorID of zero is not checked.
+func (e *FeedbackEligibility) Check(personnelID, courseID, facilitatorID int64, now time.Time) error {
+	switch {
+	case e.PersonnelID != personnelID:
+		return ErrNotEnrollmentOwner
+	case courseID != 0 && e.CourseID != courseID:
+		return ErrCourseMismatch
+	case e.SessionStart.After(now):
+		return ErrSessionNotStarted
+	case facilitatorID != 0 && !e.FacilitatorAssigned:
+		return ErrFacilitatorNotAssigned
+	default:
+		return nil
+	}
+}
+
+// FacilitatorFeedback is a rating given to a facilitator. The enrollment of an
+// anonymous rating is never listed, only returned to the officer who gave it.
 type FacilitatorFeedback struct {
-	ID                  int64     `json:"id"`
-	FacilitatorID       int64     `json:"facilitator_id"`
-	SessionEnrollmentID int64     `json:"session_enrollment_id"`
-	Score               int       `json:"score"`
-	Comment             string    `json:"comment"`
-	CreatedAt           time.Time `json:"created_at"`
+	ID                  int64      `json:"id"`
+	FacilitatorID       int64      `json:"facilitator_id"`
+	SessionEnrollmentID int64      `json:"session_enrollment_id,omitempty"`
+	Score               int        `json:"score"`
+	Comment             string     `json:"comment"`
+	Anonymous           bool       `json:"anonymous"`
+	Status              string     `json:"status"`
+	ModerationReason    string     `json:"moderation_reason,omitempty"`
+	ModeratedBy         NullInt64  `json:"moderated_by,omitempty"`
+	ModeratedAt         *time.Time `json:"moderated_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
 }
 
+// CourseFeedback is a rating given to a course. The enrollment of an anonymous
+// rating is never listed, only returned to the officer who gave it.
 type CourseFeedback struct {
-	ID                  int64     `json:"id"`
-	CourseID            int64     `json:"course_id"`
-	SessionEnrollmentID int64     `json:"session_enrollment_id"`
-	Score               int       `json:"score"`
-	Comment             string    `json:"comment"`
-	CreatedAt           time.Time `json:"created_at"`
+	ID                  int64      `json:"id"`
+	CourseID            int64      `json:"course_id"`
+	SessionEnrollmentID int64      `json:"session_enrollment_id,omitempty"`
+	Score               int        `json:"score"`
+	WouldRecommend      *int       `json:"would_recommend,omitempty"`
+	Comment             string     `json:"comment"`
+	Anonymous           bool       `json:"anonymous"`
+	Status              string     `json:"status"`
+	ModerationReason    string     `json:"moderation_reason,omitempty"`
+	ModeratedBy         NullInt64  `json:"moderated_by,omitempty"`
+	ModeratedAt         *time.Time `json:"moderated_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+// dest returns the scan destinations of facilitatorFeedbackOwner followed by feedbackColumns.
+func (f *FacilitatorFeedback) dest() []any {
+	return []any{
+		&f.FacilitatorID, &f.ID, &f.SessionEnrollmentID, &f.Score, &f.Comment, &f.Anonymous,
+		&f.Status, &f.ModerationReason, (*sql.NullInt64)(&f.ModeratedBy), &f.ModeratedAt, &f.CreatedAt,
+	}
+}
+
+// dest returns the scan destinations of courseFeedbackOwner followed by feedbackColumns.
+func (f *CourseFeedback) dest() []any {
+	return []any{
+		&f.CourseID, &f.WouldRecommend, &f.ID, &f.SessionEnrollmentID, &f.Score, &f.Comment, &f.Anonymous,
+		&f.Status, &f.ModerationReason, (*sql.NullInt64)(&f.ModeratedBy), &f.ModeratedAt, &f.CreatedAt,
+	}
+}
+
+// ValidateRating checks the score of a rating and, for a course rating, the
+// optional would_recommend answer.
+func ValidateRating(v *validator.Validator, score int, wouldRecommend *int) {
+	v.Check(score >= 1 && score <= 5, "score", "must be between 1 and 5")
+	v.Check(wouldRecommend == nil || (*wouldRecommend >= 0 && *wouldRecommend <= 10), "would_recommend", "must be between 0 and 10")
+}
+
+// feedbackExportTimeout bounds how long a feedback export may take to stream.
+const feedbackExportTimeout = 2 * time.Minute
+
+// FeedbackFilters narrows a feedback listing to ratings given within a date
+// range, within a score range, for one session, by officers of one formation or
+// in one of the moderation statuses. Zero values are not filtered on.
+type FeedbackFilters struct {
+	From        *time.Time
+	To          *time.Time
+	MinScore    int
+	MaxScore    int
+	SessionID   int64
+	FormationID int64
+	Statuses    []string
+	Filters
+}
+
+// ValidateFeedbackFilters validates a FeedbackFilters struct
+func ValidateFeedbackFilters(v *validator.Validator, f FeedbackFilters) {
+	ValidateFilters(v, f.Filters)
+	v.Check(f.MinScore >= 0 && f.MinScore <= 5, "min_score", "must be between 1 and 5, or 0 to leave unset")
+	v.Check(f.MaxScore >= 0 && f.MaxScore <= 5, "max_score", "must be between 1 and 5, or 0 to leave unset")
+	v.Check(f.MaxScore == 0 || f.MinScore <= f.MaxScore, "max_score", "must not be less than min_score")
+	v.Check(f.From == nil || f.To == nil || !f.To.Before(*f.From), "to", "must not be before from")
+	v.Check(f.SessionID >= 0, "session_id", "must not be negative")
+	v.Check(f.FormationID >= 0, "formation_id", "must not be negative")
+	for _, status := range f.Statuses {
+		v.Check(slices.Contains(FeedbackStatuses, status), "status", "must only contain pending, published or hidden")
+	}
+}
+
+// args returns the values of $2 to $8 in feedbackFilterConditions.
+func (f FeedbackFilters) args() []any {
+	return []any{f.SessionID, f.From, f.To, f.MinScore, f.MaxScore, f.FormationID, pq.Array(f.Statuses)}
+}
+
+// feedbackFilterConditions applies FeedbackFilters to ratings r given for
+// enrollments se by personnel p. The to date is inclusive.
+const feedbackFilterConditions = `
+	AND ($2 = 0 OR se.session_id = $2)
+	AND ($3::date IS NULL OR r.created_at >= $3::date)
+	AND ($4::date IS NULL OR r.created_at < $4::date + 1)
+	AND ($5 = 0 OR r.score >= $5)
+	AND ($6 = 0 OR r.score <= $6)
+	AND ($7 = 0 OR p.formation_id = $7)
+	AND (cardinality($8::text[]) = 0 OR r.status = ANY($8))`
+
+// facilitatorFeedbackSource and courseFeedbackSource select the ratings of the
+// facilitator or course $1, filtered by FeedbackFilters.
+const (
+	facilitatorFeedbackSource = `
+		FROM facilitator_ratings r
+		INNER JOIN session_enrollment se ON se.id = r.session_enrollment_id
+		INNER JOIN personnel p ON p.id = se.personnel_id
+		WHERE r.facilitator_id = $1` + feedbackFilterConditions
+
+	courseFeedbackSource = `
+		FROM course_ratings r
+		INNER JOIN session_enrollment se ON se.id = r.session_enrollment_id
+		INNER JOIN training_sessions ts ON ts.id = se.session_id
+		INNER JOIN personnel p ON p.id = se.personnel_id
+		WHERE ts.course_id = $1` + feedbackFilterConditions
+)
+
+// facilitatorFeedbackOwner and courseFeedbackOwner select the fields which
+// only facilitator or course ratings have.
+const (
+	facilitatorFeedbackOwner = "r.facilitator_id"
+	courseFeedbackOwner      = "ts.course_id, r.would_recommend"
+)
+
+// feedbackColumns selects the fields shared by facilitator and course ratings.
+// The enrollment of an anonymous rating is not selected and the time it was
+// given is truncated to the day.
+const feedbackColumns = `
+	r.id,
+	CASE WHEN r.anonymous THEN 0 ELSE r.session_enrollment_id END,
+	r.score, COALESCE(r.comment, ''), r.anonymous,
+	r.status, r.moderation_reason, r.moderated_by, r.moderated_at,
+	CASE WHEN r.anonymous THEN date_trunc('day', r.created_at) ELSE r.created_at END`
+
+// FeedbackSummary aggregates every rating matching a feedback listing's filters,
+// not just those on the page. Nothing is reported until there are at least the
+// minimum number of responses, and until then anonymous ratings are withheld
+// from the listing so none can be singled out.
+type FeedbackSummary struct {
+	ScoreSummary
+	Withheld int `json:"withheld"`
+}
+
+// buildFeedbackSummary aggregates the number of ratings given each score, of
+// which anonymous were given anonymously.
+func buildFeedbackSummary(counts map[int]int, anonymous, minResponses int) *FeedbackSummary {
+	summary := &FeedbackSummary{ScoreSummary: newScoreSummary()}
+	for score, count := range counts {
+		summary.add(score, count)
+	}
+	summary.finish(minResponses)
+	if !summary.Reportable {
+		summary.Withheld = anonymous
+	}
+	return summary
 }
 
 type FeedbackModel struct {
@@ -32,75 +214,160 @@ type FeedbackModel struct {
 
 func (m FeedbackModel) InsertFacilitatorFeedback(feedback *FacilitatorFeedback) error {
 	query := `
-		INSERT INTO facilitator_ratings (facilitator_id, session_enrollment_id, score, comment)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO facilitator_ratings (facilitator_id, session_enrollment_id, score, comment, anonymous, status, moderation_reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id, created_at`
 
-	args := []interface{}{feedback.FacilitatorID, feedback.SessionEnrollmentID, feedback.Score, feedback.Comment}
+	args := []interface{}{feedback.FacilitatorID, feedback.SessionEnrollmentID, feedback.Score, feedback.Comment, feedback.Anonymous, feedback.Status, feedback.ModerationReason}
 
 	err := m.DB.QueryRow(query, args...).Scan(&feedback.ID, &feedback.CreatedAt)
 	if err != nil {
-		var pqError *pq.Error
-		if errors.As(err, &pqError) {
-			if pqError.Code.Name() == "unique_violation" {
-				return ErrDuplicateRecord
-			}
-		}
-		return err
+		return translatePgError(err)
 	}
 
 	return nil
 }
 
-func (m FeedbackModel) GetAllForFacilitator(facilitatorID int64) ([]*FacilitatorFeedback, error) {
+// summarise aggregates the ratings selected by source, which is one of
+// facilitatorFeedbackSource or courseFeedbackSource.
+func (m FeedbackModel) summarise(ctx context.Context, source string, ownerID int64, filters FeedbackFilters, minResponses int) (*FeedbackSummary, error) {
 	query := `
-		SELECT id, facilitator_id, session_enrollment_id, score, comment, created_at
-		FROM facilitator_ratings
-		WHERE facilitator_id = $1
-		ORDER BY id`
+		SELECT r.score, COUNT(*), COUNT(*) FILTER (WHERE r.anonymous)` + source + `
+		GROUP BY r.score`
 
-	rows, err := m.DB.Query(query, facilitatorID)
+	rows, err := m.DB.QueryContext(ctx, query, append([]any{ownerID}, filters.args()...)...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	counts := map[int]int{}
+	anonymous := 0
+
+	for rows.Next() {
+		var score, count, anonymousCount int
+		err := rows.Scan(&score, &count, &anonymousCount)
+		if err != nil {
+			return nil, err
+		}
+		counts[score] = count
+		anonymous += anonymousCount
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return buildFeedbackSummary(counts, anonymous, minResponses), nil
+}
+
+// query selects the owner columns and feedbackColumns from the ratings selected by source,
+// leaving out anonymous ratings while the summary is not reportable. The first
+// column is the total number of rows when paginated, or zero otherwise, when
+// every matching rating is selected whatever page the filters ask for.
+func (m FeedbackModel) query(ctx context.Context, owner, source string, ownerID int64, filters FeedbackFilters, summary *FeedbackSummary, paginate bool) (*sql.Rows, error) {
+	total := "0"
+	limit, offset := any(nil), 0
+	if paginate {
+		total = "COUNT(*) OVER()"
+		limit, offset = filters.limit(), filters.offset()
+	}
+
+	query := `
+		SELECT ` + total + `, ` + owner + `,` + feedbackColumns + source + `
+		AND ($9 OR NOT r.anonymous)
+		ORDER BY r.id
+		LIMIT $10 OFFSET $11`
+
+	args := append([]any{ownerID}, filters.args()...)
+	args = append(args, summary.Reportable, limit, offset)
+
+	return m.DB.QueryContext(ctx, query, args...)
+}
+
+// GetAllForFacilitator returns a page of the ratings given to a facilitator
+// which match the filters, along with a summary of all of them.
+func (m FeedbackModel) GetAllForFacilitator(facilitatorID int64, filters FeedbackFilters, minResponses int) ([]*FacilitatorFeedback, *FeedbackSummary, Metadata, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	summary, err := m.summarise(ctx, facilitatorFeedbackSource, facilitatorID, filters, minResponses)
+	if err != nil {
+		return nil, nil, Metadata{}, err
+	}
+
+	rows, err := m.query(ctx, facilitatorFeedbackOwner, facilitatorFeedbackSource, facilitatorID, filters, summary, true)
+	if err != nil {
+		return nil, nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
 	feedbacks := []*FacilitatorFeedback{}
 
 	for rows.Next() {
 		var feedback FacilitatorFeedback
-		err := rows.Scan(
-			&feedback.ID,
-			&feedback.FacilitatorID,
-			&feedback.SessionEnrollmentID,
-			&feedback.Score,
-			&feedback.Comment,
-			&feedback.CreatedAt,
-		)
+		err := rows.Scan(append([]any{&totalRecords}, feedback.dest()...)...)
 		if err != nil {
-			return nil, err
+			return nil, nil, Metadata{}, err
 		}
 		feedbacks = append(feedbacks, &feedback)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, nil, Metadata{}, err
 	}
 
-	return feedbacks, nil
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return feedbacks, summary, metadata, nil
+}
+
+// StreamForFacilitator calls fn with each rating given to a facilitator which
+// matches the filters, reading them one at a time rather than all at once.
+// Pagination is ignored. Anonymous ratings are left out while the summary of
+// the ratings is not reportable.
+func (m FeedbackModel) StreamForFacilitator(facilitatorID int64, filters FeedbackFilters, minResponses int, fn func(*FacilitatorFeedback) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), feedbackExportTimeout)
+	defer cancel()
+
+	summary, err := m.summarise(ctx, facilitatorFeedbackSource, facilitatorID, filters, minResponses)
+	if err != nil {
+		return err
+	}
+
+	rows, err := m.query(ctx, facilitatorFeedbackOwner, facilitatorFeedbackSource, facilitatorID, filters, summary, false)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var total int
+		var feedback FacilitatorFeedback
+		err := rows.Scan(append([]any{&total}, feedback.dest()...)...)
+		if err != nil {
+			return err
+		}
+		if err = fn(&feedback); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
 }
 
 func (m FeedbackModel) InsertCourseFeedback(feedback *CourseFeedback) error {
 	query := `
-		INSERT INTO course_ratings (session_enrollment_id, score, comment)
-		VALUES ($1, $2, $3)
+		INSERT INTO course_ratings (session_enrollment_id, score, comment, anonymous, status, moderation_reason, would_recommend)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id, created_at`
 
-	args := []interface{}{feedback.SessionEnrollmentID, feedback.Score, feedback.Comment}
+	args := []interface{}{feedback.SessionEnrollmentID, feedback.Score, feedback.Comment, feedback.Anonymous, feedback.Status, feedback.ModerationReason, feedback.WouldRecommend}
 
 	err := m.DB.QueryRow(query, args...).Scan(&feedback.ID, &feedback.CreatedAt)
 	if err != nil {
-		return err
+		return translatePgError(err)
 	}
 
 	// Get the course_id from the training_sessions table
@@ -113,42 +380,174 @@ func (m FeedbackModel) InsertCourseFeedback(feedback *CourseFeedback) error {
 	return m.DB.QueryRow(query, feedback.SessionEnrollmentID).Scan(&feedback.CourseID)
 }
 
-func (m FeedbackModel) GetAllForCourse(courseID int64) ([]*CourseFeedback, error) {
+// GetAllForCourse returns a page of the ratings given to a course which match
+// the filters, along with a summary of all of them.
+func (m FeedbackModel) GetAllForCourse(courseID int64, filters FeedbackFilters, minResponses int) ([]*CourseFeedback, *FeedbackSummary, Metadata, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	summary, err := m.summarise(ctx, courseFeedbackSource, courseID, filters, minResponses)
+	if err != nil {
+		return nil, nil, Metadata{}, err
+	}
+
+	rows, err := m.query(ctx, courseFeedbackOwner, courseFeedbackSource, courseID, filters, summary, true)
+	if err != nil {
+		return nil, nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	feedbacks := []*CourseFeedback{}
+
+	for rows.Next() {
+		var feedback CourseFeedback
+		err := rows.Scan(append([]any{&totalRecords}, feedback.dest()...)...)
+		if err != nil {
+			return nil, nil, Metadata{}, err
+		}
+		feedbacks = append(feedbacks, &feedback)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return feedbacks, summary, metadata, nil
+}
+
+// StreamForCourse calls fn with each rating given to a course which matches the
+// filters, reading them one at a time rather than all at once. Pagination is
+// ignored. Anonymous ratings are left out while the summary of the ratings is
+// not reportable.
+func (m FeedbackModel) StreamForCourse(courseID int64, filters FeedbackFilters, minResponses int, fn func(*CourseFeedback) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), feedbackExportTimeout)
+	defer cancel()
+
+	summary, err := m.summarise(ctx, courseFeedbackSource, courseID, filters, minResponses)
+	if err != nil {
+		return err
+	}
+
+	rows, err := m.query(ctx, courseFeedbackOwner, courseFeedbackSource, courseID, filters, summary, false)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var total int
+		var feedback CourseFeedback
+		err := rows.Scan(append([]any{&total}, feedback.dest()...)...)
+		if err != nil {
+			return err
+		}
+		if err = fn(&feedback); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// GetEligibility retrieves what decides whether an enrollment may be rated,
+// including whether facilitatorID is assigned to its session.
+func (m FeedbackModel) GetEligibility(enrollmentID, facilitatorID int64) (*FeedbackEligibility, error) {
+	if enrollmentID < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT se.id, se.personnel_id, ts.id, ts.course_id, ts.start_date,
+			EXISTS (
+				SELECT 1 FROM session_facilitators sf
+				WHERE sf.session_id = ts.id AND sf.facilitator_id = $2
+			)
+		FROM session_enrollment se
+		INNER JOIN training_sessions ts ON ts.id = se.session_id
+		WHERE se.id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var e FeedbackEligibility
+
+	err := m.DB.QueryRowContext(ctx, query, enrollmentID, facilitatorID).Scan(
+		&e.EnrollmentID,
+		&e.PersonnelID,
+		&e.SessionID,
+		&e.CourseID,
+		&e.SessionStart,
+		&e.FacilitatorAssigned,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &e, nil
+}
+
+// GetCommentsForFacilitator returns the comments left with the ratings of a
+// facilitator, optionally only for one session.
+func (m FeedbackModel) GetCommentsForFacilitator(facilitatorID, sessionID int64) ([]string, error) {
 	query := `
-		SELECT cr.id, ts.course_id, cr.session_enrollment_id, cr.score, cr.comment, cr.created_at
+		SELECT fr.comment
+		FROM facilitator_ratings fr
+		INNER JOIN session_enrollment se ON se.id = fr.session_enrollment_id
+		WHERE fr.facilitator_id = $1 AND ($2 = 0 OR se.session_id = $2)
+		AND COALESCE(fr.comment, '') <> '' AND fr.status = 'published'
+		ORDER BY fr.id`
+
+	return m.getComments(query, facilitatorID, sessionID)
+}
+
+// GetCommentsForCourse returns the comments left with the ratings of a course,
+// optionally only for one session.
+func (m FeedbackModel) GetCommentsForCourse(courseID, sessionID int64) ([]string, error) {
+	query := `
+		SELECT cr.comment
 		FROM course_ratings cr
-		JOIN session_enrollment se ON cr.session_enrollment_id = se.id
-		JOIN training_sessions ts ON se.session_id = ts.id
-		WHERE ts.course_id = $1
+		INNER JOIN session_enrollment se ON se.id = cr.session_enrollment_id
+		INNER JOIN training_sessions ts ON ts.id = se.session_id
+		WHERE ts.course_id = $1 AND ($2 = 0 OR ts.id = $2)
+		AND COALESCE(cr.comment, '') <> '' AND cr.status = 'published'
 		ORDER BY cr.id`
 
-	rows, err := m.DB.Query(query, courseID)
+	return m.getComments(query, courseID, sessionID)
+}
+
+// getComments runs a query selecting a single comment column.
+func (m FeedbackModel) getComments(query string, args ...any) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	feedbacks := []*CourseFeedback{}
+	comments := []string{}
 
 	for rows.Next() {
-		var feedback CourseFeedback
-		err := rows.Scan(
-			&feedback.ID,
-			&feedback.CourseID,
-			&feedback.SessionEnrollmentID,
-			&feedback.Score,
-			&feedback.Comment,
-			&feedback.CreatedAt,
-		)
+		var comment string
+		err := rows.Scan(&comment)
 		if err != nil {
 			return nil, err
 		}
-		feedbacks = append(feedbacks, &feedback)
+		comments = append(comments, comment)
 	}
 
 	if err = rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return feedbacks, nil
+	return comments, nil
 }
@@ -0,0 +1,196 @@
+package data
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/testdb"
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+func TestFeedbackEligibilityCheck(t *testing.T) {
+	now := time.Date(2025, 6, 10, 9, 0, 0, 0, time.UTC)
+
+	eligibility := FeedbackEligibility{
+		EnrollmentID:        12,
+		PersonnelID:         7,
+		SessionID:           3,
+		CourseID:            2,
+		SessionStart:        time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC),
+		FacilitatorAssigned: true,
+	}
+
+	tests := []struct {
+		name          string
+		personnelID   int64
+		courseID      int64
+		facilitatorID int64
+		modify        func(e *FeedbackEligibility)
+		want          error
+	}{
+		{name: "owner rating an assigned facilitator on the first day", personnelID: 7, courseID: 2, facilitatorID: 4},
+		{name: "another officer's enrollment", personnelID: 8, want: ErrNotEnrollmentOwner},
+		{name: "different course", personnelID: 7, courseID: 5, want: ErrCourseMismatch},
+		{
+			name:        "session not yet started",
+			personnelID: 7,
+			modify:      func(e *FeedbackEligibility) { e.SessionStart = now.AddDate(0, 0, 1) },
+			want:        ErrSessionNotStarted,
+		},
+		{
+			name:          "facilitator not assigned",
+			personnelID:   7,
+			facilitatorID: 4,
+			modify:        func(e *FeedbackEligibility) { e.FacilitatorAssigned = false },
+			want:          ErrFacilitatorNotAssigned,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := eligibility
+			if tt.modify != nil {
+				tt.modify(&e)
+			}
+			err := e.Check(tt.personnelID, tt.courseID, tt.facilitatorID, now)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestBuildFeedbackSummary(t *testing.T) {
+	counts := map[int]int{4: 1, 2: 1, 3: 1}
+
+	t.Run("anonymous ratings are withheld below the minimum", func(t *testing.T) {
+		summary := buildFeedbackSummary(counts, 2, 5)
+
+		if summary.Withheld != 2 {
+			t.Errorf("expected 2 withheld ratings, got %d", summary.Withheld)
+		}
+		if summary.Reportable || summary.Average != nil || summary.Distribution != nil {
+			t.Errorf("expected no aggregate below the minimum, got %+v", summary.ScoreSummary)
+		}
+	})
+
+	t.Run("nothing is withheld once the minimum is reached", func(t *testing.T) {
+		summary := buildFeedbackSummary(counts, 2, 3)
+
+		if summary.Withheld != 0 || summary.Responses != 3 {
+			t.Errorf("expected 3 responses and none withheld, got %+v", summary)
+		}
+		if summary.Average == nil || *summary.Average != 3 {
+			t.Errorf("expected an average of 3, got %v", summary.Average)
+		}
+	})
+}
+
+func TestValidateFeedbackFilters(t *testing.T) {
+	from := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	page := Filters{Page: 1, PageSize: 20}
+
+	tests := []struct {
+		name    string
+		filters FeedbackFilters
+		valid   bool
+	}{
+		{"no filters", FeedbackFilters{Filters: page}, true},
+		{"score range", FeedbackFilters{MinScore: 2, MaxScore: 4, Filters: page}, true},
+		{"only a minimum score", FeedbackFilters{MinScore: 4, Filters: page}, true},
+		{"score out of range", FeedbackFilters{MaxScore: 6, Filters: page}, false},
+		{"negative score", FeedbackFilters{MinScore: -1, Filters: page}, false},
+		{"inverted score range", FeedbackFilters{MinScore: 4, MaxScore: 2, Filters: page}, false},
+		{"to before from", FeedbackFilters{From: &from, To: &to, Filters: page}, false},
+		{"invalid page", FeedbackFilters{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			ValidateFeedbackFilters(v, tt.filters)
+
+			if v.IsEmpty() != tt.valid {
+				t.Errorf("expected valid to be %t, got errors %v", tt.valid, v.Errors)
+			}
+		})
+	}
+}
+
+func TestValidateRating(t *testing.T) {
+	recommend := func(n int) *int { return &n }
+
+	tests := []struct {
+		name           string
+		score          int
+		wouldRecommend *int
+		wantErrors     []string
+	}{
+		{name: "lowest score", score: 1},
+		{name: "highest score with a recommendation", score: 5, wouldRecommend: recommend(10)},
+		{name: "no recommendation of zero", score: 3, wouldRecommend: recommend(0)},
+		{name: "missing score", score: 0, wantErrors: []string{"score"}},
+		{name: "score too high", score: 6, wantErrors: []string{"score"}},
+		{name: "recommendation too high", score: 4, wouldRecommend: recommend(11), wantErrors: []string{"would_recommend"}},
+		{name: "negative recommendation", score: 4, wouldRecommend: recommend(-1), wantErrors: []string{"would_recommend"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			ValidateRating(v, tt.score, tt.wouldRecommend)
+
+			if len(v.Errors) != len(tt.wantErrors) {
+				t.Fatalf("expected errors for %v, got %v", tt.wantErrors, v.Errors)
+			}
+			for _, key := range tt.wantErrors {
+				if _, ok := v.Errors[key]; !ok {
+					t.Errorf("expected an error for %q, got %v", key, v.Errors)
+				}
+			}
+		})
+	}
+}
+
+func TestFeedbackModelStreamIgnoresPagination(t *testing.T) {
+	db := testdb.Open(t)
+	m := FeedbackModel{DB: db}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	sessionID := newTestSession(t, db, today.AddDate(0, 0, -2), today.AddDate(0, 0, -1))
+	courseID := queryInt(t, db, `SELECT course_id FROM training_sessions WHERE id = $1`, sessionID)
+	facilitatorID := newTestFacilitator(t, db, newTestOfficer(t, db))
+
+	const ratings = 5
+	for range ratings {
+		enrollmentID := newTestEnrollment(t, db, newTestOfficer(t, db), sessionID, "Completed")
+		mustExec(t, db, `INSERT INTO course_ratings (session_enrollment_id, score) VALUES ($1, 4)`, enrollmentID)
+		mustExec(t, db, `INSERT INTO facilitator_ratings (facilitator_id, session_enrollment_id, score) VALUES ($1, $2, 4)`, facilitatorID, enrollmentID)
+	}
+
+	filters := FeedbackFilters{Filters: Filters{Page: 3, PageSize: 2}}
+
+	courseRatings := 0
+	err := m.StreamForCourse(courseID, filters, 1, func(*CourseFeedback) error {
+		courseRatings++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	facilitatorRatings := 0
+	err = m.StreamForFacilitator(facilitatorID, filters, 1, func(*FacilitatorFeedback) error {
+		facilitatorRatings++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if courseRatings != ratings || facilitatorRatings != ratings {
+		t.Errorf("expected every rating to be exported from page 3, got %d course and %d facilitator ratings", courseRatings, facilitatorRatings)
+	}
+}
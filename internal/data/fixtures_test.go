@@ -0,0 +1,149 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fixtureSeq keeps the unique values of fixture rows apart.
+var fixtureSeq atomic.Int64
+
+func nextFixture() int64 {
+	return fixtureSeq.Add(1)
+}
+
+// insertID runs an INSERT ... RETURNING id and returns the id.
+func insertID(t *testing.T, db *sql.DB, query string, args ...any) int64 {
+	t.Helper()
+
+	var id int64
+	err := db.QueryRow(query, args...).Scan(&id)
+	if err != nil {
+		t.Fatalf("inserting fixture: %v", err)
+	}
+	return id
+}
+
+// mustExec runs a statement which is expected to succeed.
+func mustExec(t *testing.T, db *sql.DB, query string, args ...any) {
+	t.Helper()
+
+	_, err := db.Exec(query, args...)
+	if err != nil {
+		t.Fatalf("executing %q: %v", query, err)
+	}
+}
+
+// queryInt runs a query returning a single integer.
+func queryInt(t *testing.T, db *sql.DB, query string, args ...any) int64 {
+	t.Helper()
+
+	var n int64
+	err := db.QueryRow(query, args...).Scan(&n)
+	if err != nil {
+		t.Fatalf("querying %q: %v", query, err)
+	}
+	return n
+}
+
+func newTestOfficer(t *testing.T, db *sql.DB) int64 {
+	t.Helper()
+	return insertID(t, db, `
+		INSERT INTO personnel (regulation_number, first_name, last_name, sex)
+		VALUES ($1, 'Test', 'Officer', 'Female')
+		RETURNING id`, fmt.Sprintf("T-%d", nextFixture()))
+}
+
+// newTestSession creates a course and a session of it running between the dates given.
+func newTestSession(t *testing.T, db *sql.DB, start, end time.Time) int64 {
+	t.Helper()
+
+	courseID := insertID(t, db, `
+		INSERT INTO courses (title, category, credit_hours)
+		VALUES ($1, 'Elective', 8)
+		RETURNING id`, fmt.Sprintf("Test Course %d", nextFixture()))
+
+	return insertID(t, db, `
+		INSERT INTO training_sessions (course_id, start_date, end_date, location)
+		VALUES ($1, $2, $3, 'Belmopan')
+		RETURNING id`, courseID, start, end)
+}
+
+func newTestEnrollment(t *testing.T, db *sql.DB, personnelID, sessionID int64, status string) int64 {
+	t.Helper()
+	return insertID(t, db, `
+		INSERT INTO session_enrollment (personnel_id, session_id, status)
+		VALUES ($1, $2, $3)
+		RETURNING id`, personnelID, sessionID, status)
+}
+
+func newTestFacilitator(t *testing.T, db *sql.DB, personnelID int64) int64 {
+	t.Helper()
+	return insertID(t, db, `
+		INSERT INTO facilitators (first_name, last_name, email, personnel_id)
+		VALUES ('Test', 'Facilitator', $1, $2)
+		RETURNING id`, fmt.Sprintf("facilitator%d@example.com", nextFixture()), personnelID)
+}
+
+// newTestUser creates an activated user with the given password and role.
+func newTestUser(t *testing.T, db *sql.DB, plaintextPassword, role string) *User {
+	t.Helper()
+
+	user := &User{
+		Email:     fmt.Sprintf("user%d@example.com", nextFixture()),
+		Activated: true,
+	}
+
+	err := user.Password.Set(plaintextPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user.ID = insertID(t, db, `
+		INSERT INTO users (email, password_hash, activated, role_id)
+		SELECT $1, $2, true, id FROM roles WHERE name = $3
+		RETURNING id`, user.Email, user.Password.hash, role)
+
+	return user
+}
+
+// newTestSurvey creates a survey of likert questions and returns its id and the
+// ids of its questions.
+func newTestSurvey(t *testing.T, db *sql.DB, questions int) (int64, []int64) {
+	t.Helper()
+
+	surveyID := insertID(t, db, `
+		INSERT INTO surveys (title) VALUES ($1) RETURNING id`,
+		fmt.Sprintf("Test Survey %d", nextFixture()))
+
+	ids := []int64{}
+	for position := 1; position <= questions; position++ {
+		ids = append(ids, insertID(t, db, `
+			INSERT INTO survey_questions (survey_id, position, kind, prompt)
+			VALUES ($1, $2, 'likert', 'How useful was it?')
+			RETURNING id`, surveyID, position))
+	}
+
+	return surveyID, ids
+}
+
+// newTestSubmission submits the scores given for each question of a survey.
+func newTestSubmission(t *testing.T, db *sql.DB, surveyID, enrollmentID int64, scores map[int64]int) int64 {
+	t.Helper()
+
+	id := insertID(t, db, `
+		INSERT INTO survey_submissions (survey_id, session_enrollment_id)
+		VALUES ($1, $2)
+		RETURNING id`, surveyID, enrollmentID)
+
+	for question, score := range scores {
+		mustExec(t, db, `
+			INSERT INTO survey_answers (submission_id, question_id, score)
+			VALUES ($1, $2, $3)`, id, question, score)
+	}
+
+	return id
+}
@@ -0,0 +1,305 @@
+package data
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// RoleFacilitator is the name of the role given to invited facilitators.
+const RoleFacilitator = "Facilitator"
+
+// ErrAccountActivated is returned when inviting a facilitator whose user
+// account has already been activated.
+var ErrAccountActivated = errors.New("account already activated")
+
+// Invitation is an email inviting a facilitator to set a password for the
+// user account linked to their facilitator record.
+type Invitation struct {
+	ID            int64      `json:"id"`
+	FacilitatorID int64      `json:"facilitator_id"`
+	UserID        int64      `json:"user_id"`
+	Email         string     `json:"email"`
+	InvitedBy     NullInt64  `json:"invited_by,omitempty"`
+	Status        string     `json:"status"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	AcceptedAt    *time.Time `json:"accepted_at,omitempty"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+}
+
+// status reports whether the invitation is pending, accepted, revoked or expired.
+func (i *Invitation) status(now time.Time) string {
+	switch {
+	case i.AcceptedAt != nil:
+		return "accepted"
+	case i.RevokedAt != nil:
+		return "revoked"
+	case !i.ExpiresAt.After(now):
+		return "expired"
+	default:
+		return "pending"
+	}
+}
+
+// InvitationModel wraps the database connection pool.
+type InvitationModel struct {
+	DB *sql.DB
+}
+
+// Invite invites a facilitator to sign in, replacing any invitation still open.
+// The facilitator's pending user account is created if they do not have one yet.
+// ErrAccountActivated is returned if their account is already in use.
+func (m InvitationModel) Invite(facilitator *Facilitator, invitedBy int64, ttl time.Duration) (*Invitation, *Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	var userID int64
+	var activated bool
+
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, activated FROM users WHERE facilitator_id = $1 FOR UPDATE`,
+		facilitator.ID).Scan(&userID, &activated)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// The account cannot be signed in to until the invitation sets a password
+		var placeholder password
+		err = placeholder.Set(rand.Text())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO users (email, password_hash, activated, role_id, facilitator_id)
+			SELECT $1, $2, false, id, $3 FROM roles WHERE name = $4
+			RETURNING id`,
+			facilitator.Email, placeholder.hash, facilitator.ID, RoleFacilitator).Scan(&userID)
+		if err != nil {
+			return nil, nil, translatePgError(err)
+		}
+	case err != nil:
+		return nil, nil, err
+	case activated:
+		return nil, nil, ErrAccountActivated
+	default:
+		_, err = tx.ExecContext(ctx, `UPDATE users SET email = $1 WHERE id = $2`, facilitator.Email, userID)
+		if err != nil {
+			return nil, nil, translatePgError(err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE facilitator_invitations SET revoked_at = NOW()
+		WHERE facilitator_id = $1 AND accepted_at IS NULL AND revoked_at IS NULL`,
+		facilitator.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM tokens WHERE scope = $1 AND user_id = $2`, ScopeInvitation, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	token, err := generateToken(userID, ttl, ScopeInvitation)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO tokens (hash, user_id, expiry, scope)
+		VALUES ($1, $2, $3, $4)`,
+		token.Hash, token.UserID, token.Expiry, token.Scope)
+	if err != nil {
+		return nil, nil, translatePgError(err)
+	}
+
+	invitation := &Invitation{
+		FacilitatorID: facilitator.ID,
+		UserID:        userID,
+		Email:         facilitator.Email,
+		InvitedBy:     NullInt64{Int64: invitedBy, Valid: invitedBy > 0},
+		ExpiresAt:     token.Expiry,
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO facilitator_invitations (facilitator_id, user_id, email, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, expires_at, created_at`,
+		invitation.FacilitatorID, invitation.UserID, invitation.Email, sql.NullInt64(invitation.InvitedBy), invitation.ExpiresAt,
+	).Scan(&invitation.ID, &invitation.ExpiresAt, &invitation.CreatedAt)
+	if err != nil {
+		return nil, nil, translatePgError(err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	invitation.Status = invitation.status(time.Now())
+
+	return invitation, token, nil
+}
+
+// GetLatestForFacilitator retrieves the most recent invitation sent to a facilitator.
+func (m InvitationModel) GetLatestForFacilitator(facilitatorID int64) (*Invitation, error) {
+	query := `
+		SELECT id, facilitator_id, user_id, email, invited_by, expires_at, created_at, accepted_at, revoked_at
+		FROM facilitator_invitations
+		WHERE facilitator_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var invitation Invitation
+
+	err := m.DB.QueryRowContext(ctx, query, facilitatorID).Scan(
+		&invitation.ID,
+		&invitation.FacilitatorID,
+		&invitation.UserID,
+		&invitation.Email,
+		(*sql.NullInt64)(&invitation.InvitedBy),
+		&invitation.ExpiresAt,
+		&invitation.CreatedAt,
+		&invitation.AcceptedAt,
+		&invitation.RevokedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	invitation.Status = invitation.status(time.Now())
+
+	return &invitation, nil
+}
+
+// Revoke withdraws the open invitation of a facilitator so its token can no
+// longer be used. ErrRecordNotFound is returned if there is none.
+func (m InvitationModel) Revoke(facilitatorID int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var userID int64
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE facilitator_invitations SET revoked_at = NOW()
+		WHERE facilitator_id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+		RETURNING user_id`,
+		facilitatorID).Scan(&userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM tokens WHERE scope = $1 AND user_id = $2`, ScopeInvitation, userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Accept sets the password of the account an invitation token belongs to and
+// activates it. The token is single use. ErrRecordNotFound is returned if the
+// token is invalid, expired or its invitation has been revoked.
+func (m InvitationModel) Accept(tokenPlaintext, plaintextPassword string) (*User, error) {
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+
+	var user User
+
+	err := user.Password.Set(plaintextPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var invitationID int64
+
+	err = tx.QueryRowContext(ctx, `
+		SELECT fi.id, u.id, u.created_at, u.email, u.personnel_id, u.role_id, u.facilitator_id
+		FROM users u
+		INNER JOIN tokens t ON t.user_id = u.id
+		INNER JOIN facilitator_invitations fi ON fi.user_id = u.id
+		WHERE t.hash = $1 AND t.scope = $2 AND t.expiry > NOW()
+		AND fi.accepted_at IS NULL AND fi.revoked_at IS NULL AND fi.expires_at > NOW()
+		FOR UPDATE OF u, fi`,
+		tokenHash[:], ScopeInvitation).Scan(
+		&invitationID,
+		&user.ID,
+		&user.CreatedAt,
+		&user.Email,
+		&user.PersonnelID,
+		&user.RoleID,
+		(*sql.NullInt64)(&user.FacilitatorID),
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users SET password_hash = $1, activated = true WHERE id = $2`,
+		user.Password.hash, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE facilitator_invitations SET accepted_at = NOW() WHERE id = $1`, invitationID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM tokens WHERE scope = $1 AND user_id = $2`, ScopeInvitation, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	user.Activated = true
+
+	return &user, nil
+}
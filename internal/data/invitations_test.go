@@ -0,0 +1,30 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInvitationStatus(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Hour)
+
+	tests := []struct {
+		name       string
+		invitation Invitation
+		want       string
+	}{
+		{"pending", Invitation{ExpiresAt: now.Add(time.Hour)}, "pending"},
+		{"expired", Invitation{ExpiresAt: earlier}, "expired"},
+		{"accepted before expiring", Invitation{ExpiresAt: earlier, AcceptedAt: &earlier}, "accepted"},
+		{"revoked", Invitation{ExpiresAt: now.Add(time.Hour), RevokedAt: &earlier}, "revoked"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.invitation.status(now); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// JobModel records when each periodic job last ran and is next due, so a job
+// runs once per period however many instances of the API are running.
+type JobModel struct {
+	DB *sql.DB
+}
+
+// Register adds a job, due straight away, unless it is already known.
+func (m JobModel) Register(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `
+		INSERT INTO scheduled_jobs (name)
+		VALUES ($1)
+		ON CONFLICT (name) DO NOTHING`, name)
+	return err
+}
+
+// Claim reports whether the job is due and, if so, marks it as next due after
+// interval. Only one caller can claim a due job; any other skips it rather
+// than waiting for the first to finish.
+func (m JobModel) Claim(ctx context.Context, name string, interval time.Duration) (bool, error) {
+	query := `
+		UPDATE scheduled_jobs
+		SET next_run_at = NOW() + make_interval(secs => $2), last_started_at = NOW()
+		WHERE name = (
+			SELECT name FROM scheduled_jobs
+			WHERE name = $1 AND next_run_at <= NOW()
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING name`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, name, interval.Seconds()).Scan(&name)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return false, nil
+		default:
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// Finish records that a run of the job has ended, and the error it ended with
+// if any.
+func (m JobModel) Finish(name string, jobErr error) error {
+	message := ""
+	if jobErr != nil {
+		message = jobErr.Error()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `
+		UPDATE scheduled_jobs
+		SET last_finished_at = NOW(), last_error = $2
+		WHERE name = $1`, name, message)
+	return err
+}
@@ -0,0 +1,87 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/testdb"
+)
+
+func TestJobModelClaim(t *testing.T) {
+	db := testdb.Open(t)
+	m := JobModel{DB: db}
+	ctx := context.Background()
+
+	const name = "test-job"
+
+	err := m.Register(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claimed, err := m.Claim(ctx, name, time.Hour)
+	if err != nil || !claimed {
+		t.Fatalf("expected a newly registered job to be claimed, got %t, %v", claimed, err)
+	}
+
+	claimed, err = m.Claim(ctx, name, time.Hour)
+	if err != nil || claimed {
+		t.Fatalf("expected the job not to be claimed again within its interval, got %t, %v", claimed, err)
+	}
+
+	// Registering again leaves the schedule alone
+	err = m.Register(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claimed, err = m.Claim(ctx, name, time.Hour)
+	if err != nil || claimed {
+		t.Fatalf("expected registering again not to make the job due, got %t, %v", claimed, err)
+	}
+
+	mustExec(t, db, `UPDATE scheduled_jobs SET next_run_at = NOW() - INTERVAL '1 second' WHERE name = $1`, name)
+
+	t.Run("locked by another instance", func(t *testing.T) {
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tx.Rollback()
+
+		var locked string
+		err = tx.QueryRow(`SELECT name FROM scheduled_jobs WHERE name = $1 FOR UPDATE`, name).Scan(&locked)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The claim must skip the locked row rather than wait for it
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+
+		claimed, err := m.Claim(ctx, name, time.Hour)
+		if err != nil || claimed {
+			t.Errorf("expected a locked job to be skipped, got %t, %v", claimed, err)
+		}
+	})
+
+	claimed, err = m.Claim(ctx, name, time.Hour)
+	if err != nil || !claimed {
+		t.Fatalf("expected a due job to be claimed once unlocked, got %t, %v", claimed, err)
+	}
+
+	err = m.Finish(name, sql.ErrConnDone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lastError string
+	err = db.QueryRow(`SELECT last_error FROM scheduled_jobs WHERE name = $1`, name).Scan(&lastError)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastError != sql.ErrConnDone.Error() {
+		t.Errorf("expected the job error to be recorded, got %q", lastError)
+	}
+}
@@ -0,0 +1,261 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+// Results of a login attempt. LoginLockedOut is a wrong password which locked
+// the account, and LoginLocked an attempt made while it was locked, whose
+// password is not checked.
+const (
+	LoginSucceeded       = "success"
+	LoginUnknownEmail    = "unknown_email"
+	LoginInvalidPassword = "invalid_password"
+	LoginLockedOut       = "locked_out"
+	LoginLocked          = "locked"
+)
+
+// LoginResults lists the results a login attempt can have.
+var LoginResults = []string{LoginSucceeded, LoginUnknownEmail, LoginInvalidPassword, LoginLockedOut, LoginLocked}
+
+// ErrAccountLocked is returned when logging in to an account which is locked.
+var ErrAccountLocked = errors.New("account locked")
+
+// LockoutPolicy decides how long an account is locked after consecutive
+// failed logins. The account is locked for Lockout once there have been
+// MaxAttempts failures, and the lockout doubles with each further failure up
+// to MaxLockout.
+type LockoutPolicy struct {
+	MaxAttempts int
+	Lockout     time.Duration
+	MaxLockout  time.Duration
+}
+
+// Duration returns how long an account is locked after the given number of
+// consecutive failed logins, or zero if it is not locked.
+func (p LockoutPolicy) Duration(failures int) time.Duration {
+	if p.MaxAttempts < 1 || failures < p.MaxAttempts {
+		return 0
+	}
+
+	lockout := p.Lockout
+	for i := p.MaxAttempts; i < failures && lockout < p.MaxLockout; i++ {
+		lockout *= 2
+	}
+
+	return min(lockout, p.MaxLockout)
+}
+
+// LoginAttempt is a record of an attempt to log in. UserID is only set when
+// the email address belongs to an account.
+type LoginAttempt struct {
+	ID        int64     `json:"id"`
+	UserID    NullInt64 `json:"user_id,omitempty"`
+	Email     string    `json:"email"`
+	Result    string    `json:"result"`
+	ClientIP  string    `json:"client_ip"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LoginAttemptFilters narrows a listing of login attempts. Zero values are not
+// filtered on.
+type LoginAttemptFilters struct {
+	UserID   int64
+	Email    string
+	Result   string
+	ClientIP string
+	From     *time.Time
+	To       *time.Time
+	Filters
+}
+
+// ValidateLoginAttemptFilters validates a LoginAttemptFilters struct
+func ValidateLoginAttemptFilters(v *validator.Validator, f LoginAttemptFilters) {
+	ValidateFilters(v, f.Filters)
+	v.Check(f.UserID >= 0, "user_id", "must not be negative")
+	v.Check(f.Result == "" || slices.Contains(LoginResults, f.Result), "result", "must be one of success, unknown_email, invalid_password, locked_out or locked")
+	v.Check(f.From == nil || f.To == nil || !f.To.Before(*f.From), "to", "must not be before from")
+}
+
+// LoginModel locks accounts after failed logins and keeps the login audit trail.
+type LoginModel struct {
+	DB *sql.DB
+}
+
+// ClaimAttempt counts a login attempt against a user's account before its
+// password is checked, and returns when the account is locked until should the
+// password turn out to be wrong, or nil if it would not be locked. The account
+// is locked straight away, so concurrent attempts cannot get past the limit
+// while the password is checked; a successful login unlocks it again with
+// ResetFailures. ErrAccountLocked is returned if the account is already locked,
+// in which case the attempt is not counted.
+func (m LoginModel) ClaimAttempt(userID int64, policy LockoutPolicy) (*time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Concurrent claims wait on the row lock, then find the account locked
+	var failures int
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE users
+		SET failed_login_attempts = failed_login_attempts + 1
+		WHERE id = $1 AND (locked_until IS NULL OR locked_until <= NOW())
+		RETURNING failed_login_attempts`, userID).Scan(&failures)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrAccountLocked
+		default:
+			return nil, err
+		}
+	}
+
+	var lockedUntil *time.Time
+
+	if lockout := policy.Duration(failures); lockout > 0 {
+		until := time.Now().Add(lockout).Truncate(time.Second)
+		lockedUntil = &until
+
+		_, err = tx.ExecContext(ctx, `UPDATE users SET locked_until = $1 WHERE id = $2`, until, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return lockedUntil, nil
+}
+
+// Unlock clears the failed logins and any lockout of a user's account.
+func (m LoginModel) Unlock(userID int64) error {
+	query := `
+		UPDATE users
+		SET failed_login_attempts = 0, locked_until = NULL
+		WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// ResetFailures clears the failed logins of a user after a successful login.
+func (m LoginModel) ResetFailures(userID int64) error {
+	query := `
+		UPDATE users
+		SET failed_login_attempts = 0, locked_until = NULL
+		WHERE id = $1 AND (failed_login_attempts > 0 OR locked_until IS NOT NULL)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, userID)
+	return err
+}
+
+// RecordAttempt adds a login attempt to the audit trail.
+func (m LoginModel) RecordAttempt(attempt *LoginAttempt) error {
+	if len(attempt.UserAgent) > maxUserAgentLength {
+		attempt.UserAgent = strings.ToValidUTF8(attempt.UserAgent[:maxUserAgentLength], "")
+	}
+
+	query := `
+		INSERT INTO login_attempts (user_id, email, result, client_ip, user_agent)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+
+	args := []any{sql.NullInt64(attempt.UserID), attempt.Email, attempt.Result, attempt.ClientIP, attempt.UserAgent}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&attempt.ID, &attempt.CreatedAt)
+	return translatePgError(err)
+}
+
+// GetAttempts returns a page of login attempts, most recent first.
+func (m LoginModel) GetAttempts(filters LoginAttemptFilters) ([]*LoginAttempt, Metadata, error) {
+	query := `
+		SELECT COUNT(*) OVER(), id, user_id, email, result, client_ip, user_agent, created_at
+		FROM login_attempts
+		WHERE ($1 = 0 OR user_id = $1)
+		AND ($2 = '' OR lower(email) = lower($2))
+		AND ($3 = '' OR result = $3)
+		AND ($4 = '' OR client_ip = $4)
+		AND ($5::date IS NULL OR created_at >= $5::date)
+		AND ($6::date IS NULL OR created_at < $6::date + 1)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $7 OFFSET $8`
+
+	args := []any{filters.UserID, filters.Email, filters.Result, filters.ClientIP, filters.From, filters.To, filters.limit(), filters.offset()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	attempts := []*LoginAttempt{}
+
+	for rows.Next() {
+		var attempt LoginAttempt
+		err := rows.Scan(
+			&totalRecords,
+			&attempt.ID,
+			(*sql.NullInt64)(&attempt.UserID),
+			&attempt.Email,
+			&attempt.Result,
+			&attempt.ClientIP,
+			&attempt.UserAgent,
+			&attempt.CreatedAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		attempts = append(attempts, &attempt)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return attempts, metadata, nil
+}
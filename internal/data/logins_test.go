@@ -0,0 +1,129 @@
+package data
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/testdb"
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+func TestLockoutPolicyDuration(t *testing.T) {
+	policy := LockoutPolicy{MaxAttempts: 5, Lockout: time.Minute, MaxLockout: 10 * time.Minute}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{4, 0},
+		{5, time.Minute},
+		{6, 2 * time.Minute},
+		{8, 8 * time.Minute},
+		{9, 10 * time.Minute},
+		{1000, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := policy.Duration(tt.failures); got != tt.want {
+			t.Errorf("expected %v after %d failures, got %v", tt.want, tt.failures, got)
+		}
+	}
+}
+
+func TestLockoutPolicyDisabled(t *testing.T) {
+	policy := LockoutPolicy{MaxAttempts: 0, Lockout: time.Minute, MaxLockout: time.Hour}
+
+	if got := policy.Duration(100); got != 0 {
+		t.Errorf("expected no lockout, got %v", got)
+	}
+}
+
+func TestValidateLoginAttemptFilters(t *testing.T) {
+	v := validator.New()
+	ValidateLoginAttemptFilters(v, LoginAttemptFilters{Result: "denied", Filters: Filters{Page: 1, PageSize: 20}})
+
+	if _, ok := v.Errors["result"]; !ok {
+		t.Errorf("expected an error for an unknown result")
+	}
+
+	v = validator.New()
+	ValidateLoginAttemptFilters(v, LoginAttemptFilters{Result: LoginLockedOut, Filters: Filters{Page: 1, PageSize: 20}})
+
+	if !v.IsEmpty() {
+		t.Errorf("expected no errors, got %v", v.Errors)
+	}
+}
+
+func TestLoginModelClaimAttempt(t *testing.T) {
+	db := testdb.Open(t)
+	m := LoginModel{DB: db}
+	policy := LockoutPolicy{MaxAttempts: 3, Lockout: time.Minute, MaxLockout: time.Hour}
+
+	user := newTestUser(t, db, "pa55word1234", "System User")
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		lockedUntil, err := m.ClaimAttempt(user.ID, policy)
+		if err != nil || lockedUntil != nil {
+			t.Fatalf("expected attempt %d to be allowed without a lock, got %v, %v", attempt, lockedUntil, err)
+		}
+	}
+
+	lockedUntil, err := m.ClaimAttempt(user.ID, policy)
+	if err != nil || lockedUntil == nil {
+		t.Fatalf("expected the last attempt to be allowed and lock the account, got %v, %v", lockedUntil, err)
+	}
+
+	_, err = m.ClaimAttempt(user.ID, policy)
+	if !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("expected ErrAccountLocked, got %v", err)
+	}
+	if n := queryInt(t, db, `SELECT failed_login_attempts FROM users WHERE id = $1`, user.ID); n != 3 {
+		t.Errorf("expected attempts on a locked account not to be counted, got %d", n)
+	}
+
+	// A successful login lifts the lock its own attempt took
+	err = m.ResetFailures(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = m.ClaimAttempt(user.ID, policy)
+	if err != nil {
+		t.Errorf("expected the account to be unlocked, got %v", err)
+	}
+
+	t.Run("concurrent attempts", func(t *testing.T) {
+		user := newTestUser(t, db, "pa55word1234", "System User")
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		allowed, locked := 0, 0
+
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := m.ClaimAttempt(user.ID, policy)
+
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case err == nil:
+					allowed++
+				case errors.Is(err, ErrAccountLocked):
+					locked++
+				default:
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if allowed != policy.MaxAttempts || locked != 10-policy.MaxAttempts {
+			t.Errorf("expected %d attempts allowed and the rest refused, got %d allowed and %d refused",
+				policy.MaxAttempts, allowed, locked)
+		}
+	})
+}
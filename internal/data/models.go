@@ -5,25 +5,47 @@ import (
 )
 
 type Models struct {
-	Officers     OfficerModel
-	Courses      CourseModel
-	Facilitators FacilitatorModel
-	Feedback     FeedbackModel
-	Nits         NitModel
-	Users        UserModel
-	Tokens       TokenModel
-	Permissions PermissionModel
+	Officers       OfficerModel
+	Courses        CourseModel
+	Facilitators   FacilitatorModel
+	Feedback       FeedbackModel
+	Nits           NitModel
+	Users          UserModel
+	Tokens         TokenModel
+	Permissions    PermissionModel
+	Enrollments    EnrollmentModel
+	Roles          RoleModel
+	Organisation   OrganisationModel
+	Availability   AvailabilityModel
+	Qualifications QualificationModel
+	Attendance     AttendanceModel
+	Invitations    InvitationModel
+	Surveys        SurveyModel
+	Jobs           JobModel
+	Reminders      ReminderModel
+	Logins         LoginModel
 }
 
 func NewModels(db *sql.DB) Models {
 	return Models{
-		Officers:     OfficerModel{DB: db},
-		Courses:      CourseModel{DB: db},
-		Facilitators: FacilitatorModel{DB: db},
-		Feedback:     FeedbackModel{DB: db},
-		Nits:         NitModel{DB: db},
-		Users:        UserModel{DB: db},
-		Tokens:       TokenModel{DB: db},
-		Permissions: PermissionModel{DB: db},
+		Officers:       OfficerModel{DB: db},
+		Courses:        CourseModel{DB: db},
+		Facilitators:   FacilitatorModel{DB: db},
+		Feedback:       FeedbackModel{DB: db},
+		Nits:           NitModel{DB: db},
+		Users:          UserModel{DB: db},
+		Tokens:         TokenModel{DB: db},
+		Permissions:    PermissionModel{DB: db},
+		Enrollments:    EnrollmentModel{DB: db},
+		Roles:          RoleModel{DB: db},
+		Organisation:   OrganisationModel{DB: db},
+		Availability:   AvailabilityModel{DB: db},
+		Qualifications: QualificationModel{DB: db},
+		Attendance:     AttendanceModel{DB: db},
+		Invitations:    InvitationModel{DB: db},
+		Surveys:        SurveyModel{DB: db},
+		Jobs:           JobModel{DB: db},
+		Reminders:      ReminderModel{DB: db},
+		Logins:         LoginModel{DB: db},
 	}
 }
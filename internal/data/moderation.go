@@ -0,0 +1,187 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/textanalysis"
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+// Moderation statuses of a rating. Only published ratings are listed to users
+// without the feedback:moderate permission.
+const (
+	FeedbackPending   = "pending"
+	FeedbackPublished = "published"
+	FeedbackHidden    = "hidden"
+)
+
+// FeedbackStatuses lists the accepted moderation statuses.
+var FeedbackStatuses = []string{FeedbackPending, FeedbackPublished, FeedbackHidden}
+
+// FeedbackKinds lists the kinds of rating which can be moderated.
+var FeedbackKinds = []string{"course", "facilitator"}
+
+// ModerationStatus decides whether a new comment is published straight away or
+// held for a moderator because it contains a term from the blocklist, and why.
+func ModerationStatus(comment string, blocklist []string) (string, string) {
+	matched := textanalysis.Matches(comment, blocklist)
+	if len(matched) == 0 {
+		return FeedbackPublished, ""
+	}
+	return FeedbackPending, "held automatically for containing: " + strings.Join(matched, ", ")
+}
+
+// ValidateModeration validates a moderator's decision on a rating.
+func ValidateModeration(v *validator.Validator, status, reason string) {
+	v.Check(slices.Contains(FeedbackStatuses, status), "status", "must be one of pending, published or hidden")
+	v.Check(status != FeedbackHidden || reason != "", "reason", "must be provided when hiding feedback")
+	v.Check(len(reason) <= 500, "reason", "must not be more than 500 bytes long")
+}
+
+// ModerationItem is a course or facilitator rating as seen by a moderator. The
+// enrollment is left out so moderating does not reveal who wrote it.
+type ModerationItem struct {
+	Kind             string     `json:"kind"`
+	ID               int64      `json:"id"`
+	CourseID         int64      `json:"course_id,omitempty"`
+	FacilitatorID    int64      `json:"facilitator_id,omitempty"`
+	Score            int        `json:"score"`
+	Comment          string     `json:"comment"`
+	Anonymous        bool       `json:"anonymous"`
+	Status           string     `json:"status"`
+	ModerationReason string     `json:"moderation_reason,omitempty"`
+	ModeratedBy      NullInt64  `json:"moderated_by,omitempty"`
+	ModeratedAt      *time.Time `json:"moderated_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// moderationItems selects both kinds of rating as ModerationItem rows.
+const moderationItems = `
+	SELECT 'course' AS kind, r.id, ts.course_id, 0 AS facilitator_id, r.score,
+		COALESCE(r.comment, '') AS comment, r.anonymous, r.status, r.moderation_reason,
+		r.moderated_by, r.moderated_at, r.created_at
+	FROM course_ratings r
+	INNER JOIN session_enrollment se ON se.id = r.session_enrollment_id
+	INNER JOIN training_sessions ts ON ts.id = se.session_id
+	UNION ALL
+	SELECT 'facilitator', r.id, 0, r.facilitator_id, r.score,
+		COALESCE(r.comment, ''), r.anonymous, r.status, r.moderation_reason,
+		r.moderated_by, r.moderated_at, r.created_at
+	FROM facilitator_ratings r`
+
+// dest returns the scan destinations of moderationItems.
+func (i *ModerationItem) dest() []any {
+	return []any{
+		&i.Kind, &i.ID, &i.CourseID, &i.FacilitatorID, &i.Score, &i.Comment, &i.Anonymous,
+		&i.Status, &i.ModerationReason, (*sql.NullInt64)(&i.ModeratedBy), &i.ModeratedAt, &i.CreatedAt,
+	}
+}
+
+// GetModerationQueue returns a page of the course and facilitator ratings in a
+// moderation status, oldest first.
+func (m FeedbackModel) GetModerationQueue(status string, filters Filters) ([]*ModerationItem, Metadata, error) {
+	query := `
+		SELECT COUNT(*) OVER(), q.*
+		FROM (` + moderationItems + `) q
+		WHERE q.status = $1
+		ORDER BY q.created_at, q.kind, q.id
+		LIMIT $2 OFFSET $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, status, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	items := []*ModerationItem{}
+
+	for rows.Next() {
+		var item ModerationItem
+		err := rows.Scan(append([]any{&totalRecords}, item.dest()...)...)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		items = append(items, &item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return items, metadata, nil
+}
+
+// Moderate records a moderator's decision on a course or facilitator rating
+// and returns the rating as it now stands.
+func (m FeedbackModel) Moderate(kind string, id int64, status, reason string, moderatorID int64) (*ModerationItem, error) {
+	var table string
+	switch kind {
+	case "course":
+		table = "course_ratings"
+	case "facilitator":
+		table = "facilitator_ratings"
+	default:
+		return nil, ErrRecordNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE `+table+`
+		SET status = $1, moderation_reason = $2, moderated_by = $3, moderated_at = NOW()
+		WHERE id = $4`,
+		status, reason, moderatorID, id)
+	if err != nil {
+		return nil, translatePgError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if rowsAffected == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	var item ModerationItem
+
+	err = tx.QueryRowContext(ctx, `
+		SELECT q.*
+		FROM (`+moderationItems+`) q
+		WHERE q.kind = $1 AND q.id = $2`,
+		kind, id).Scan(item.dest()...)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
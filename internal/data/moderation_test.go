@@ -0,0 +1,61 @@
+package data
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+func TestModerationStatus(t *testing.T) {
+	blocklist := []string{"idiot", "waste of time"}
+
+	t.Run("clean comments are published", func(t *testing.T) {
+		status, reason := ModerationStatus("Clear and well paced", blocklist)
+		if status != FeedbackPublished || reason != "" {
+			t.Errorf("expected published with no reason, got %q and %q", status, reason)
+		}
+	})
+
+	t.Run("blocked terms hold the comment", func(t *testing.T) {
+		status, reason := ModerationStatus("A waste of time, the trainer is an IDIOT", blocklist)
+		if status != FeedbackPending {
+			t.Errorf("expected pending, got %q", status)
+		}
+		if !strings.Contains(reason, "idiot") || !strings.Contains(reason, "waste of time") {
+			t.Errorf("expected the reason to name both terms, got %q", reason)
+		}
+	})
+
+	t.Run("an empty blocklist holds nothing", func(t *testing.T) {
+		if status, _ := ModerationStatus("idiot", nil); status != FeedbackPublished {
+			t.Errorf("expected published, got %q", status)
+		}
+	})
+}
+
+func TestValidateModeration(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		reason string
+		valid  bool
+	}{
+		{"publish", FeedbackPublished, "", true},
+		{"hide with a reason", FeedbackHidden, "personal attack", true},
+		{"hide without a reason", FeedbackHidden, "", false},
+		{"unknown status", "deleted", "", false},
+		{"reason too long", FeedbackPending, strings.Repeat("x", 501), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			ValidateModeration(v, tt.status, tt.reason)
+
+			if v.IsEmpty() != tt.valid {
+				t.Errorf("expected valid to be %t, got errors %v", tt.valid, v.Errors)
+			}
+		})
+	}
+}
@@ -7,32 +7,38 @@ import (
 	"time"
 
 	"github.com/Syha-01/national-inservice-training/internal/validator"
+	"github.com/lib/pq"
 )
 
 // Nit defines the structure for a national inservice training session.
 type Nit struct {
-	ID        int64     `json:"id"`
-	CourseID  int64     `json:"course_id"`
-	StartDate time.Time `json:"start_date"`
-	EndDate   time.Time `json:"end_date"`
-	Location  string    `json:"location"`
-	CreatedAt time.Time `json:"-"`
-	Version   int32     `json:"version"`
+	ID           int64          `json:"id"`
+	CourseID     int64          `json:"course_id"`
+	StartDate    time.Time      `json:"start_date"`
+	EndDate      time.Time      `json:"end_date"`
+	Location     string         `json:"location"`
+	CreatedAt    time.Time      `json:"-"`
+	Version      int32          `json:"version"`
+	Course       *Course        `json:"course,omitempty"`
+	Facilitators []*Facilitator `json:"facilitators,omitempty"`
 }
 
 // Officer defines the structure for a police officer.
 type Officer struct {
-	ID               int64     `json:"id"`
-	RegulationNumber string    `json:"regulation_number"`
-	FirstName        string    `json:"first_name"`
-	LastName         string    `json:"last_name"`
-	Sex              string    `json:"sex"`
-	RankID           int64     `json:"rank_id,omitempty"`
-	FormationID      int64     `json:"formation_id,omitempty"`
-	PostingID        int64     `json:"posting_id,omitempty"`
-	IsActive         bool      `json:"is_active"`
-	CreatedAt        time.Time `json:"-"`
-	UpdatedAt        time.Time `json:"-"`
+	ID               int64      `json:"id"`
+	RegulationNumber string     `json:"regulation_number"`
+	FirstName        string     `json:"first_name"`
+	LastName         string     `json:"last_name"`
+	Sex              string     `json:"sex"`
+	RankID           int64      `json:"rank_id,omitempty"`
+	FormationID      int64      `json:"formation_id,omitempty"`
+	PostingID        int64      `json:"posting_id,omitempty"`
+	IsActive         bool       `json:"is_active"`
+	CreatedAt        time.Time  `json:"-"`
+	UpdatedAt        time.Time  `json:"-"`
+	Rank             *Rank      `json:"rank,omitempty"`
+	Formation        *Formation `json:"formation,omitempty"`
+	Posting          *Posting   `json:"posting,omitempty"`
 }
 
 // Course defines the structure for a training course.
@@ -91,32 +97,56 @@ type NitModel struct {
 	DB *sql.DB
 }
 
+// nitColumns and nitTables are shared by the training session queries so that
+// the course can be embedded without a separate lookup.
+const (
+	nitColumns = `ts.id, ts.course_id, ts.start_date, ts.end_date, COALESCE(ts.location, ''), ts.created_at, ts.version,
+		c.title, COALESCE(c.description, ''), c.category, c.credit_hours`
+	nitTables = `training_sessions ts
+		INNER JOIN courses c ON c.id = ts.course_id`
+)
+
+// dest returns the scan destinations matching nitColumns.
+func (nit *Nit) dest(course *Course) []any {
+	return []any{
+		&nit.ID,
+		&nit.CourseID,
+		&nit.StartDate,
+		&nit.EndDate,
+		&nit.Location,
+		&nit.CreatedAt,
+		&nit.Version,
+		&course.Title,
+		&course.Description,
+		&course.Category,
+		&course.CreditHours,
+	}
+}
+
 // Get retrieves a specific training session by ID.
 func (m NitModel) Get(id int64) (*Nit, error) {
+	return m.GetExpanded(id, Expand{})
+}
+
+// GetExpanded retrieves a specific training session by ID along with the related
+// resources requested in expand.
+func (m NitModel) GetExpanded(id int64, expand Expand) (*Nit, error) {
 	if id < 1 {
 		return nil, ErrRecordNotFound
 	}
 
 	query := `
-		SELECT id, course_id, start_date, end_date, location, created_at, version
-		FROM training_sessions
-		WHERE id = $1`
+		SELECT ` + nitColumns + `
+		FROM ` + nitTables + `
+		WHERE ts.id = $1`
 
 	var nit Nit
+	var course Course
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	err := m.DB.QueryRowContext(ctx, query, id).Scan(
-		&nit.ID,
-		&nit.CourseID,
-		&nit.StartDate,
-		&nit.EndDate,
-		&nit.Location,
-		&nit.CreatedAt,
-		&nit.Version,
-	)
-
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(nit.dest(&course)...)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
@@ -126,9 +156,43 @@ func (m NitModel) Get(id int64) (*Nit, error) {
 		}
 	}
 
+	err = m.embed(ctx, []*Nit{&nit}, []*Course{&course}, expand)
+	if err != nil {
+		return nil, err
+	}
+
 	return &nit, nil
 }
 
+// embed attaches the related resources requested in expand to the training
+// sessions. Facilitators for every session are loaded with a single query.
+func (m NitModel) embed(ctx context.Context, nits []*Nit, courses []*Course, expand Expand) error {
+	if expand.Has("course") {
+		for i, nit := range nits {
+			courses[i].ID = nit.CourseID
+			nit.Course = courses[i]
+		}
+	}
+
+	if expand.Has("facilitators") && len(nits) > 0 {
+		ids := make([]int64, len(nits))
+		for i, nit := range nits {
+			ids[i] = nit.ID
+		}
+
+		facilitators, err := FacilitatorModel{DB: m.DB}.getAllForSessions(ctx, ids)
+		if err != nil {
+			return err
+		}
+
+		for _, nit := range nits {
+			nit.Facilitators = facilitators[nit.ID]
+		}
+	}
+
+	return nil
+}
+
 // Update updates a specific training session.
 func (m NitModel) Update(nit *Nit) error {
 	query := `
@@ -155,18 +219,19 @@ func (m NitModel) Update(nit *Nit) error {
 		case errors.Is(err, sql.ErrNoRows):
 			return ErrEditConflict
 		default:
-			return err
+			return translatePgError(err)
 		}
 	}
 	return nil
 }
 
-// GetAll returns a slice of all training sessions.
-func (m NitModel) GetAll(filters Filters) ([]*Nit, Metadata, error) {
+// GetAll returns a slice of all training sessions along with the related
+// resources requested in expand.
+func (m NitModel) GetAll(filters Filters, expand Expand) ([]*Nit, Metadata, error) {
 	query := `
-		SELECT COUNT(*) OVER(), id, course_id, start_date, end_date, location, created_at, version
-		FROM training_sessions
-		ORDER BY id
+		SELECT COUNT(*) OVER(), ` + nitColumns + `
+		FROM ` + nitTables + `
+		ORDER BY ts.id
 		LIMIT $1 OFFSET $2`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -180,29 +245,28 @@ func (m NitModel) GetAll(filters Filters) ([]*Nit, Metadata, error) {
 
 	totalRecords := 0
 	nits := []*Nit{}
+	courses := []*Course{}
 
 	for rows.Next() {
 		var nit Nit
-		err := rows.Scan(
-			&totalRecords,
-			&nit.ID,
-			&nit.CourseID,
-			&nit.StartDate,
-			&nit.EndDate,
-			&nit.Location,
-			&nit.CreatedAt,
-			&nit.Version,
-		)
+		var course Course
+		err := rows.Scan(append([]any{&totalRecords}, nit.dest(&course)...)...)
 		if err != nil {
 			return nil, Metadata{}, err
 		}
 		nits = append(nits, &nit)
+		courses = append(courses, &course)
 	}
 
 	if err = rows.Err(); err != nil {
 		return nil, Metadata{}, err
 	}
 
+	err = m.embed(ctx, nits, courses, expand)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
 	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
 
 	return nits, metadata, nil
@@ -225,7 +289,8 @@ func (m NitModel) Create(nit *Nit) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	return m.DB.QueryRowContext(ctx, query, args...).Scan(&nit.ID, &nit.CreatedAt, &nit.Version)
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&nit.ID, &nit.CreatedAt, &nit.Version)
+	return translatePgError(err)
 }
 
 // Delete deletes a specific training session.
@@ -243,7 +308,7 @@ func (m NitModel) Delete(id int64) error {
 
 	result, err := m.DB.ExecContext(ctx, query, id)
 	if err != nil {
-		return err
+		return translatePgError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -258,6 +323,55 @@ func (m NitModel) Delete(id int64) error {
 	return nil
 }
 
+// GetAllForFacilitator returns the training sessions a specific facilitator is
+// assigned to, most recent first, along with the related resources requested in expand.
+func (m NitModel) GetAllForFacilitator(facilitatorID int64, filters Filters, expand Expand) ([]*Nit, Metadata, error) {
+	query := `
+		SELECT COUNT(*) OVER(), ` + nitColumns + `
+		FROM ` + nitTables + `
+		INNER JOIN session_facilitators sf ON sf.session_id = ts.id
+		WHERE sf.facilitator_id = $1
+		ORDER BY ts.start_date DESC, ts.id
+		LIMIT $2 OFFSET $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, facilitatorID, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	nits := []*Nit{}
+	courses := []*Course{}
+
+	for rows.Next() {
+		var nit Nit
+		var course Course
+		err := rows.Scan(append([]any{&totalRecords}, nit.dest(&course)...)...)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		nits = append(nits, &nit)
+		courses = append(courses, &course)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	err = m.embed(ctx, nits, courses, expand)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return nits, metadata, nil
+}
+
 // EnrollPersonnel enrolls a personnel in a training session.
 func (m NitModel) EnrollPersonnel(sessionID, personnelID int64) (int64, error) {
 	query := `
@@ -271,41 +385,115 @@ func (m NitModel) EnrollPersonnel(sessionID, personnelID int64) (int64, error) {
 	var id int64
 	err := m.DB.QueryRowContext(ctx, query, sessionID, personnelID).Scan(&id)
 	if err != nil {
-		return 0, err
+		return 0, translatePgError(err)
 	}
 	return id, nil
 }
 
+// officerColumns and officerTables are shared by the officer queries so that the
+// related rank, formation, region and posting come back in the same query rather
+// than needing a lookup for every row.
+const (
+	officerColumns = `p.id, p.regulation_number, p.first_name, p.last_name, p.sex,
+		COALESCE(p.rank_id, 0), COALESCE(p.formation_id, 0), COALESCE(p.posting_id, 0),
+		p.is_active, p.created_at, p.updated_at,
+		rk.name, rk.abbreviation, fm.name, fm.region_id, rg.name, po.name`
+	officerTables = `personnel p
+		LEFT JOIN ranks rk ON rk.id = p.rank_id
+		LEFT JOIN formations fm ON fm.id = p.formation_id
+		LEFT JOIN regions rg ON rg.id = fm.region_id
+		LEFT JOIN postings po ON po.id = p.posting_id`
+)
+
+// officerRelations holds the related columns returned alongside an officer,
+// all of which are NULL when the officer has no rank, formation or posting.
+type officerRelations struct {
+	rankName         sql.NullString
+	rankAbbreviation sql.NullString
+	formationName    sql.NullString
+	regionID         sql.NullInt64
+	regionName       sql.NullString
+	postingName      sql.NullString
+}
+
+// dest returns the scan destinations matching officerColumns.
+func (rel *officerRelations) dest(officer *Officer) []any {
+	return []any{
+		&officer.ID,
+		&officer.RegulationNumber,
+		&officer.FirstName,
+		&officer.LastName,
+		&officer.Sex,
+		&officer.RankID,
+		&officer.FormationID,
+		&officer.PostingID,
+		&officer.IsActive,
+		&officer.CreatedAt,
+		&officer.UpdatedAt,
+		&rel.rankName,
+		&rel.rankAbbreviation,
+		&rel.formationName,
+		&rel.regionID,
+		&rel.regionName,
+		&rel.postingName,
+	}
+}
+
+// embed attaches the related resources requested in expand to the officer.
+func (rel *officerRelations) embed(officer *Officer, expand Expand) {
+	if expand.Has("rank") && rel.rankName.Valid {
+		officer.Rank = &Rank{
+			ID:           officer.RankID,
+			Name:         rel.rankName.String,
+			Abbreviation: rel.rankAbbreviation.String,
+		}
+	}
+	if expand.Has("formation") && rel.formationName.Valid {
+		officer.Formation = &Formation{
+			ID:       officer.FormationID,
+			Name:     rel.formationName.String,
+			RegionID: rel.regionID.Int64,
+		}
+		if expand.Has("formation.region") && rel.regionName.Valid {
+			officer.Formation.Region = &Region{
+				ID:   rel.regionID.Int64,
+				Name: rel.regionName.String,
+			}
+		}
+	}
+	if expand.Has("posting") && rel.postingName.Valid {
+		officer.Posting = &Posting{
+			ID:   officer.PostingID,
+			Name: rel.postingName.String,
+		}
+	}
+}
+
 // GetOfficer retrieves a specific officer by ID.
 func (m OfficerModel) GetOfficer(id int64) (*Officer, error) {
+	return m.GetOfficerExpanded(id, Expand{})
+}
+
+// GetOfficerExpanded retrieves a specific officer by ID along with the related
+// resources requested in expand.
+func (m OfficerModel) GetOfficerExpanded(id int64, expand Expand) (*Officer, error) {
 	// check if the id is valid
 	if id < 1 {
 		return nil, ErrRecordNotFound
 	}
 	// the SQL query to be executed against the database table
 	query := `
-		SELECT id, regulation_number, first_name, last_name, sex, rank_id, formation_id, posting_id, is_active, created_at, updated_at
-		FROM personnel
-		WHERE id = $1
+		SELECT ` + officerColumns + `
+		FROM ` + officerTables + `
+		WHERE p.id = $1
 `
 	// declare a variable of type Officer to store the returned officer
 	var officer Officer
+	var relations officerRelations
 	// Set a 3-second context/timer
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
-	err := m.DB.QueryRowContext(ctx, query, id).Scan(
-		&officer.ID,
-		&officer.RegulationNumber,
-		&officer.FirstName,
-		&officer.LastName,
-		&officer.Sex,
-		&officer.RankID,
-		&officer.FormationID,
-		&officer.PostingID,
-		&officer.IsActive,
-		&officer.CreatedAt,
-		&officer.UpdatedAt,
-	)
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(relations.dest(&officer)...)
 	// check for which type of error
 	if err != nil {
 		switch {
@@ -315,15 +503,58 @@ func (m OfficerModel) GetOfficer(id int64) (*Officer, error) {
 			return nil, err
 		}
 	}
+	relations.embed(&officer, expand)
 	return &officer, nil
 }
 
-// UpdateOfficer updates a specific officer's details.
+// getAllByIDs retrieves the officers with the given IDs in a single query,
+// keyed by officer ID.
+func (m OfficerModel) getAllByIDs(ctx context.Context, ids []int64) (map[int64]*Officer, error) {
+	query := `
+		SELECT ` + officerColumns + `
+		FROM ` + officerTables + `
+		WHERE p.id = ANY($1)`
+
+	rows, err := m.DB.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	officers := make(map[int64]*Officer)
+
+	for rows.Next() {
+		var officer Officer
+		var relations officerRelations
+		err := rows.Scan(relations.dest(&officer)...)
+		if err != nil {
+			return nil, err
+		}
+		officers[officer.ID] = &officer
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return officers, nil
+}
+
+// UpdateOfficer updates a specific officer's details. The name stored against
+// the officer's facilitator record is kept in step, so it is still correct if
+// the officer is later removed.
 func (m OfficerModel) UpdateOfficer(officer *Officer) error {
 	query := `
-		UPDATE personnel
-		SET regulation_number = $1, first_name = $2, last_name = $3, sex = $4, rank_id = $5, formation_id = $6, posting_id = $7, is_active = $8, updated_at = NOW()
-		WHERE id = $9
+		WITH updated AS (
+			UPDATE personnel
+			SET regulation_number = $1, first_name = $2, last_name = $3, sex = $4, rank_id = $5, formation_id = $6, posting_id = $7, is_active = $8, updated_at = NOW()
+			WHERE id = $9
+			RETURNING id, first_name, last_name
+		)
+		UPDATE facilitators f
+		SET first_name = updated.first_name, last_name = updated.last_name
+		FROM updated
+		WHERE f.personnel_id = updated.id
 `
 	args := []any{
 		officer.RegulationNumber,
@@ -345,18 +576,19 @@ func (m OfficerModel) UpdateOfficer(officer *Officer) error {
 		case errors.Is(err, sql.ErrNoRows):
 			return ErrEditConflict
 		default:
-			return err
+			return translatePgError(err)
 		}
 	}
 	return nil
 }
 
-// GetAllOfficers retrieves all officers from the personnel table.
-func (m OfficerModel) GetAllOfficers(filters Filters) ([]*Officer, Metadata, error) {
+// GetAllOfficers retrieves all officers from the personnel table along with the
+// related resources requested in expand.
+func (m OfficerModel) GetAllOfficers(filters Filters, expand Expand) ([]*Officer, Metadata, error) {
 	query := `
-		SELECT COUNT(*) OVER(), id, regulation_number, first_name, last_name, sex, rank_id, formation_id, posting_id, is_active, created_at, updated_at
-		FROM personnel
-		ORDER BY id
+		SELECT COUNT(*) OVER(), ` + officerColumns + `
+		FROM ` + officerTables + `
+		ORDER BY p.id
 		LIMIT $1 OFFSET $2
 	`
 
@@ -374,23 +606,12 @@ func (m OfficerModel) GetAllOfficers(filters Filters) ([]*Officer, Metadata, err
 
 	for rows.Next() {
 		var officer Officer
-		err := rows.Scan(
-			&totalRecords,
-			&officer.ID,
-			&officer.RegulationNumber,
-			&officer.FirstName,
-			&officer.LastName,
-			&officer.Sex,
-			&officer.RankID,
-			&officer.FormationID,
-			&officer.PostingID,
-			&officer.IsActive,
-			&officer.CreatedAt,
-			&officer.UpdatedAt,
-		)
+		var relations officerRelations
+		err := rows.Scan(append([]any{&totalRecords}, relations.dest(&officer)...)...)
 		if err != nil {
 			return nil, Metadata{}, err
 		}
+		relations.embed(&officer, expand)
 		officers = append(officers, &officer)
 	}
 
@@ -419,7 +640,7 @@ func (m OfficerModel) DeleteOfficer(id int64) error {
 
 	result, err := m.DB.ExecContext(ctx, query, id)
 	if err != nil {
-		return err
+		return translatePgError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -454,7 +675,8 @@ func (m OfficerModel) CreateOfficer(officer *Officer) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	return m.DB.QueryRowContext(ctx, query, args...).Scan(&officer.ID, &officer.CreatedAt, &officer.UpdatedAt)
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&officer.ID, &officer.CreatedAt, &officer.UpdatedAt)
+	return translatePgError(err)
 }
 
 // GetAllCourses retrieves all courses from the database.
@@ -568,7 +790,7 @@ func (m CourseModel) CreateCourse(course *Course) error {
 		&course.UpdatedAt,
 	)
 
-	return err
+	return translatePgError(err)
 }
 
 // UpdateCourse modifies an existing course in the database.
@@ -598,7 +820,7 @@ func (m CourseModel) UpdateCourse(course *Course) error {
 		case errors.Is(err, sql.ErrNoRows):
 			return ErrRecordNotFound
 		default:
-			return err
+			return translatePgError(err)
 		}
 	}
 
@@ -621,7 +843,7 @@ func (m CourseModel) DeleteCourse(id int64) error {
 
 	result, err := m.DB.ExecContext(ctx, query, id)
 	if err != nil {
-		return err
+		return translatePgError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -0,0 +1,271 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Region defines the structure for a police region.
+type Region struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// Formation defines the structure for a police formation within a region.
+type Formation struct {
+	ID       int64   `json:"id"`
+	Name     string  `json:"name"`
+	RegionID int64   `json:"region_id"`
+	Region   *Region `json:"region,omitempty"`
+}
+
+// Rank defines the structure for a police rank.
+type Rank struct {
+	ID           int64  `json:"id"`
+	Name         string `json:"name"`
+	Abbreviation string `json:"abbreviation"`
+}
+
+// Posting defines the structure for a duty posting.
+type Posting struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// OrgStats summarises the officers of a formation or region and their training
+// for a given year. Officers are counted against their current formation.
+type OrgStats struct {
+	ActiveOfficers          int     `json:"active_officers"`
+	Enrollments             int     `json:"enrollments"`
+	CreditHoursCompleted    float64 `json:"credit_hours_completed"`
+	MandatoryCompliant      int     `json:"mandatory_compliant_officers"`
+	MandatoryComplianceRate float64 `json:"mandatory_compliance_rate"`
+}
+
+// add accumulates the counts of other into s and recalculates the compliance rate.
+func (s *OrgStats) add(other OrgStats) {
+	s.ActiveOfficers += other.ActiveOfficers
+	s.Enrollments += other.Enrollments
+	s.CreditHoursCompleted += other.CreditHoursCompleted
+	s.MandatoryCompliant += other.MandatoryCompliant
+	s.calculateComplianceRate()
+}
+
+func (s *OrgStats) calculateComplianceRate() {
+	s.MandatoryComplianceRate = 0
+	if s.ActiveOfficers > 0 {
+		s.MandatoryComplianceRate = float64(s.MandatoryCompliant) / float64(s.ActiveOfficers)
+	}
+}
+
+// CourseStats summarises the enrollments in a course by the officers of a formation.
+type CourseStats struct {
+	CourseID    int64  `json:"course_id"`
+	Title       string `json:"title"`
+	Category    string `json:"category"`
+	Enrollments int    `json:"enrollments"`
+	Completions int    `json:"completions"`
+}
+
+// FormationNode is a formation in the organisational hierarchy.
+type FormationNode struct {
+	Formation
+	Stats   OrgStats       `json:"stats"`
+	Courses []*CourseStats `json:"courses,omitempty"`
+}
+
+// RegionNode is a region in the organisational hierarchy along with its formations.
+type RegionNode struct {
+	Region
+	Stats      OrgStats         `json:"stats"`
+	Formations []*FormationNode `json:"formations"`
+}
+
+// OrgTree is the region to formation hierarchy for a given year.
+type OrgTree struct {
+	Year    int           `json:"year"`
+	Stats   OrgStats      `json:"stats"`
+	Regions []*RegionNode `json:"regions"`
+}
+
+// OrganisationModel wraps the database connection pool.
+type OrganisationModel struct {
+	DB *sql.DB
+}
+
+// GetTree returns every region and formation along with their statistics for the year.
+func (m OrganisationModel) GetTree(year int) (*OrgTree, error) {
+	regions, err := m.getRegionNodes(year, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := &OrgTree{Year: year, Regions: regions}
+	for _, region := range regions {
+		tree.Stats.add(region.Stats)
+	}
+
+	return tree, nil
+}
+
+// GetRegion returns a specific region and its formations along with their statistics for the year.
+func (m OrganisationModel) GetRegion(id int64, year int) (*RegionNode, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	regions, err := m.getRegionNodes(year, id, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(regions) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return regions[0], nil
+}
+
+// GetFormation returns a specific formation along with its statistics and a
+// per-course breakdown of its officers' enrollments for the year.
+func (m OrganisationModel) GetFormation(id int64, year int) (*FormationNode, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	regions, err := m.getRegionNodes(year, 0, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(regions) == 0 || len(regions[0].Formations) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	formation := regions[0].Formations[0]
+	formation.Region = &regions[0].Region
+
+	query := `
+		SELECT c.id, c.title, c.category,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE se.status = 'Completed')
+		FROM session_enrollment se
+		INNER JOIN personnel p ON p.id = se.personnel_id
+		INNER JOIN training_sessions ts ON ts.id = se.session_id
+		INNER JOIN courses c ON c.id = ts.course_id
+		WHERE p.formation_id = $1 AND EXTRACT(YEAR FROM ts.start_date) = $2
+		GROUP BY c.id, c.title, c.category
+		ORDER BY c.title`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, id, year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	formation.Courses = []*CourseStats{}
+
+	for rows.Next() {
+		var course CourseStats
+		err := rows.Scan(
+			&course.CourseID,
+			&course.Title,
+			&course.Category,
+			&course.Enrollments,
+			&course.Completions,
+		)
+		if err != nil {
+			return nil, err
+		}
+		formation.Courses = append(formation.Courses, &course)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return formation, nil
+}
+
+// getRegionNodes calculates the statistics of every formation in a single query
+// and groups the formations by region. A non-zero regionID or formationID limits
+// the result to that region or formation.
+func (m OrganisationModel) getRegionNodes(year int, regionID, formationID int64) ([]*RegionNode, error) {
+	query := `
+		WITH year_enrollments AS (
+			SELECT se.personnel_id, se.status, ts.course_id, c.credit_hours, c.category
+			FROM session_enrollment se
+			INNER JOIN training_sessions ts ON ts.id = se.session_id
+			INNER JOIN courses c ON c.id = ts.course_id
+			WHERE EXTRACT(YEAR FROM ts.start_date) = $1
+		), officer_stats AS (
+			SELECT p.id, p.formation_id, COALESCE(p.is_active, FALSE) AS is_active,
+				COUNT(ye.personnel_id) AS enrollments,
+				COALESCE(SUM(ye.credit_hours) FILTER (WHERE ye.status = 'Completed'), 0) AS credit_hours,
+				COUNT(DISTINCT ye.course_id) FILTER (WHERE ye.status = 'Completed' AND ye.category = 'Mandatory') AS mandatory_completed
+			FROM personnel p
+			LEFT JOIN year_enrollments ye ON ye.personnel_id = p.id
+			GROUP BY p.id
+		)
+		SELECT rg.id, rg.name, fm.id, fm.name,
+			COUNT(os.id) FILTER (WHERE os.is_active),
+			COALESCE(SUM(os.enrollments), 0),
+			COALESCE(SUM(os.credit_hours), 0),
+			COUNT(os.id) FILTER (WHERE os.is_active AND os.mandatory_completed >= (
+				SELECT COUNT(*) FROM courses WHERE category = 'Mandatory'
+			))
+		FROM regions rg
+		INNER JOIN formations fm ON fm.region_id = rg.id
+		LEFT JOIN officer_stats os ON os.formation_id = fm.id
+		WHERE ($2 = 0 OR rg.id = $2) AND ($3 = 0 OR fm.id = $3)
+		GROUP BY rg.id, rg.name, fm.id, fm.name
+		ORDER BY rg.name, fm.name`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, year, regionID, formationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	regions := []*RegionNode{}
+	var current *RegionNode
+
+	for rows.Next() {
+		var region Region
+		var formation FormationNode
+		err := rows.Scan(
+			&region.ID,
+			&region.Name,
+			&formation.ID,
+			&formation.Name,
+			&formation.Stats.ActiveOfficers,
+			&formation.Stats.Enrollments,
+			&formation.Stats.CreditHoursCompleted,
+			&formation.Stats.MandatoryCompliant,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		formation.RegionID = region.ID
+		formation.Stats.calculateComplianceRate()
+
+		if current == nil || current.ID != region.ID {
+			current = &RegionNode{Region: region, Formations: []*FormationNode{}}
+			regions = append(regions, current)
+		}
+		current.Formations = append(current.Formations, &formation)
+		current.Stats.add(formation.Stats)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return regions, nil
+}
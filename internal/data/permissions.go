@@ -69,7 +69,7 @@ WHERE permissions.code = ANY($2)
 	defer cancel()
 	// slices need to be converted to arrays to work in PostgreSQL
 	_, err := p.DB.ExecContext(ctx, query, userID, pq.Array(codes))
-	return err
+	return translatePgError(err)
 }
 
 func ValidatePermissionCode(v *validator.Validator, code string) {
@@ -0,0 +1,100 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// detailKeyRX picks the column list out of a constraint error detail such as
+// `Key (regulation_number)=(101) already exists.`
+var detailKeyRX = regexp.MustCompile(`^Key \(([^)]+)\)=`)
+
+// ConstraintError describes a write which was rejected by a database constraint.
+// Err is one of ErrDuplicateRecord, ErrInvalidReference, ErrRecordInUse or
+// ErrCheckViolation, so callers can test for the kind of violation with errors.Is.
+type ConstraintError struct {
+	Err        error
+	Constraint string
+	Table      string
+	Column     string
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("%s: %s violates constraint %q", e.Err, e.Table, e.Constraint)
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+// IsConstraintViolation reports whether err was caused by a database constraint.
+func IsConstraintViolation(err error) bool {
+	var constraintErr *ConstraintError
+	return errors.As(err, &constraintErr)
+}
+
+// ViolatesConstraint reports whether err was caused by the named database
+// constraint, for callers which explain some constraints differently.
+func ViolatesConstraint(err error, constraint string) bool {
+	var constraintErr *ConstraintError
+	return errors.As(err, &constraintErr) && constraintErr.Constraint == constraint
+}
+
+// translatePgError converts unique, foreign key and check violations reported by
+// PostgreSQL into a *ConstraintError. Any other error is returned unchanged.
+func translatePgError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	var kind error
+	switch pqErr.Code.Name() {
+	case "unique_violation":
+		kind = ErrDuplicateRecord
+	case "foreign_key_violation":
+		// The same code is used when inserting a row which points at a missing
+		// record and when deleting a record which other rows still point at
+		kind = ErrInvalidReference
+		if strings.Contains(pqErr.Detail, "is still referenced") {
+			kind = ErrRecordInUse
+		}
+	case "check_violation":
+		kind = ErrCheckViolation
+	default:
+		return err
+	}
+
+	return &ConstraintError{
+		Err:        kind,
+		Constraint: pqErr.Constraint,
+		Table:      pqErr.Table,
+		Column:     constraintColumn(pqErr),
+	}
+}
+
+// constraintColumn works out which column a constraint error relates to. Unique
+// and foreign key errors name the key columns in their detail, and for a
+// composite key the last column is the one which distinguishes the row (e.g. the
+// session_id of a (personnel_id, session_id) enrollment). Check constraints are
+// only identifiable by PostgreSQL's default naming of <table>_<column>_check.
+func constraintColumn(pqErr *pq.Error) string {
+	if pqErr.Column != "" {
+		return pqErr.Column
+	}
+
+	if matches := detailKeyRX.FindStringSubmatch(pqErr.Detail); matches != nil {
+		columns := strings.Split(matches[1], ",")
+		return strings.TrimSpace(columns[len(columns)-1])
+	}
+
+	column := strings.TrimPrefix(pqErr.Constraint, pqErr.Table+"_")
+	for _, suffix := range []string{"_check", "_key", "_fkey"} {
+		column = strings.TrimSuffix(column, suffix)
+	}
+	return column
+}
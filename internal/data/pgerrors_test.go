@@ -0,0 +1,148 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestTranslatePgError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantKind   error
+		wantColumn string
+	}{
+		{
+			name: "duplicate regulation number",
+			err: &pq.Error{
+				Code:       "23505",
+				Table:      "personnel",
+				Constraint: "personnel_regulation_number_key",
+				Detail:     "Key (regulation_number)=(PC-101) already exists.",
+			},
+			wantKind:   ErrDuplicateRecord,
+			wantColumn: "regulation_number",
+		},
+		{
+			name: "duplicate email",
+			err: &pq.Error{
+				Code:       "23505",
+				Table:      "users",
+				Constraint: "users_email_key",
+				Detail:     "Key (email)=(alice@example.com) already exists.",
+			},
+			wantKind:   ErrDuplicateRecord,
+			wantColumn: "email",
+		},
+		{
+			name: "duplicate composite key reports the last column",
+			err: &pq.Error{
+				Code:       "23505",
+				Table:      "session_enrollment",
+				Constraint: "session_enrollment_personnel_id_session_id_key",
+				Detail:     "Key (personnel_id, session_id)=(1, 2) already exists.",
+			},
+			wantKind:   ErrDuplicateRecord,
+			wantColumn: "session_id",
+		},
+		{
+			name: "missing referenced record",
+			err: &pq.Error{
+				Code:       "23503",
+				Table:      "training_sessions",
+				Constraint: "training_sessions_course_id_fkey",
+				Detail:     `Key (course_id)=(99) is not present in table "courses".`,
+			},
+			wantKind:   ErrInvalidReference,
+			wantColumn: "course_id",
+		},
+		{
+			name: "record still referenced",
+			err: &pq.Error{
+				Code:       "23503",
+				Table:      "training_sessions",
+				Constraint: "training_sessions_course_id_fkey",
+				Detail:     `Key (id)=(1) is still referenced from table "training_sessions".`,
+			},
+			wantKind:   ErrRecordInUse,
+			wantColumn: "id",
+		},
+		{
+			name: "check constraint falls back to the constraint name",
+			err: &pq.Error{
+				Code:       "23514",
+				Table:      "courses",
+				Constraint: "courses_category_check",
+			},
+			wantKind:   ErrCheckViolation,
+			wantColumn: "category",
+		},
+		{
+			name: "wrapped error",
+			err: fmt.Errorf("insert: %w", &pq.Error{
+				Code:       "23505",
+				Table:      "users",
+				Constraint: "users_email_key",
+				Detail:     "Key (email)=(alice@example.com) already exists.",
+			}),
+			wantKind:   ErrDuplicateRecord,
+			wantColumn: "email",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := translatePgError(tt.err)
+
+			if !errors.Is(err, tt.wantKind) {
+				t.Fatalf("expected %v, got %v", tt.wantKind, err)
+			}
+			if !IsConstraintViolation(err) {
+				t.Fatal("expected a constraint violation")
+			}
+
+			var constraintErr *ConstraintError
+			errors.As(err, &constraintErr)
+			if constraintErr.Column != tt.wantColumn {
+				t.Errorf("expected column %q, got %q", tt.wantColumn, constraintErr.Column)
+			}
+		})
+	}
+
+	t.Run("other errors are unchanged", func(t *testing.T) {
+		for _, err := range []error{
+			nil,
+			ErrRecordNotFound,
+			&pq.Error{Code: "40001"},
+		} {
+			if got := translatePgError(err); got != err {
+				t.Errorf("expected %v to be returned unchanged, got %v", err, got)
+			}
+			if IsConstraintViolation(err) {
+				t.Errorf("expected %v not to be a constraint violation", err)
+			}
+		}
+	})
+}
+
+func TestViolatesConstraint(t *testing.T) {
+	err := translatePgError(fmt.Errorf("insert: %w", &pq.Error{
+		Code:       "23505",
+		Table:      "users",
+		Constraint: "users_personnel_id_key",
+		Detail:     "Key (personnel_id)=(7) already exists.",
+	}))
+
+	if !ViolatesConstraint(err, "users_personnel_id_key") {
+		t.Error("expected the personnel_id constraint to be reported")
+	}
+	if ViolatesConstraint(err, "users_email_key") {
+		t.Error("expected the email constraint not to be reported")
+	}
+	if ViolatesConstraint(ErrDuplicateRecord, "users_email_key") {
+		t.Error("expected a bare error not to name a constraint")
+	}
+}
@@ -0,0 +1,280 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+// Qualification records that a facilitator may teach a course. A qualification
+// without an expiry date does not lapse.
+type Qualification struct {
+	ID               int64      `json:"id"`
+	FacilitatorID    int64      `json:"facilitator_id"`
+	CourseID         int64      `json:"course_id"`
+	QualifiedSince   time.Time  `json:"qualified_since"`
+	ExpiresOn        *time.Time `json:"expires_on"`
+	EvidenceDocument string     `json:"evidence_document,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	Version          int32      `json:"version"`
+}
+
+// QualifiedFacilitator is a facilitator along with their qualification for a course.
+type QualifiedFacilitator struct {
+	Facilitator   *Facilitator   `json:"facilitator"`
+	Qualification *Qualification `json:"qualification"`
+}
+
+// AssignmentOverride records an administrator assigning a facilitator to a
+// session without a valid qualification for its course.
+type AssignmentOverride struct {
+	ID            int64     `json:"id"`
+	SessionID     int64     `json:"session_id"`
+	FacilitatorID int64     `json:"facilitator_id"`
+	UserID        int64     `json:"user_id"`
+	Reason        string    `json:"reason"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// qualificationColumns is shared by the qualification queries and matches the
+// destinations returned by Qualification.dest.
+const qualificationColumns = `fq.id, fq.facilitator_id, fq.course_id, fq.qualified_since, fq.expires_on, fq.evidence_document, fq.created_at, fq.version`
+
+// dest returns the scan destinations for qualificationColumns.
+func (qualification *Qualification) dest() []any {
+	return []any{
+		&qualification.ID,
+		&qualification.FacilitatorID,
+		&qualification.CourseID,
+		&qualification.QualifiedSince,
+		&qualification.ExpiresOn,
+		&qualification.EvidenceDocument,
+		&qualification.CreatedAt,
+		&qualification.Version,
+	}
+}
+
+// ValidateQualification validates a Qualification struct
+func ValidateQualification(v *validator.Validator, qualification *Qualification) {
+	v.Check(qualification.CourseID > 0, "course_id", "must be provided and be a positive integer")
+	v.Check(!qualification.QualifiedSince.IsZero(), "qualified_since", "must be provided")
+	if qualification.ExpiresOn != nil {
+		v.Check(!qualification.ExpiresOn.Before(qualification.QualifiedSince), "expires_on", "must not be before qualified_since")
+	}
+	v.Check(len(qualification.EvidenceDocument) <= 500, "evidence_document", "must not be more than 500 bytes long")
+}
+
+// ValidateOverrideReason validates the reason given for an assignment override
+func ValidateOverrideReason(v *validator.Validator, reason string) {
+	v.Check(reason != "", "override_reason", "must be provided")
+	v.Check(len(reason) <= 500, "override_reason", "must not be more than 500 bytes long")
+}
+
+// QualificationModel wraps the database connection pool.
+type QualificationModel struct {
+	DB *sql.DB
+}
+
+// Insert adds a course qualification for a facilitator.
+func (m QualificationModel) Insert(qualification *Qualification) error {
+	query := `
+		INSERT INTO facilitator_qualifications (facilitator_id, course_id, qualified_since, expires_on, evidence_document)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, version`
+
+	args := []any{
+		qualification.FacilitatorID,
+		qualification.CourseID,
+		qualification.QualifiedSince,
+		qualification.ExpiresOn,
+		qualification.EvidenceDocument,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&qualification.ID, &qualification.CreatedAt, &qualification.Version)
+	return translatePgError(err)
+}
+
+// Get retrieves a specific qualification belonging to a facilitator.
+func (m QualificationModel) Get(facilitatorID, id int64) (*Qualification, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT ` + qualificationColumns + `
+		FROM facilitator_qualifications fq
+		WHERE fq.id = $1 AND fq.facilitator_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var qualification Qualification
+
+	err := m.DB.QueryRowContext(ctx, query, id, facilitatorID).Scan(qualification.dest()...)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &qualification, nil
+}
+
+// Update updates the dates and evidence of a specific qualification.
+func (m QualificationModel) Update(qualification *Qualification) error {
+	query := `
+		UPDATE facilitator_qualifications
+		SET qualified_since = $1, expires_on = $2, evidence_document = $3, version = version + 1
+		WHERE id = $4 AND version = $5
+		RETURNING version`
+
+	args := []any{
+		qualification.QualifiedSince,
+		qualification.ExpiresOn,
+		qualification.EvidenceDocument,
+		qualification.ID,
+		qualification.Version,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&qualification.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return translatePgError(err)
+		}
+	}
+
+	return nil
+}
+
+// Delete removes a specific qualification belonging to a facilitator.
+func (m QualificationModel) Delete(facilitatorID, id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+		DELETE FROM facilitator_qualifications
+		WHERE id = $1 AND facilitator_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, facilitatorID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetAllForFacilitator returns every qualification held by a specific
+// facilitator, including those which have expired.
+func (m QualificationModel) GetAllForFacilitator(facilitatorID int64, filters Filters) ([]*Qualification, Metadata, error) {
+	query := `
+		SELECT COUNT(*) OVER(), ` + qualificationColumns + `
+		FROM facilitator_qualifications fq
+		WHERE fq.facilitator_id = $1
+		ORDER BY fq.course_id
+		LIMIT $2 OFFSET $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, facilitatorID, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	qualifications := []*Qualification{}
+
+	for rows.Next() {
+		var qualification Qualification
+		err := rows.Scan(append([]any{&totalRecords}, qualification.dest()...)...)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		qualifications = append(qualifications, &qualification)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return qualifications, metadata, nil
+}
+
+// GetFacilitatorsForCourse returns the facilitators who currently hold a
+// qualification for a specific course, i.e. one which has not yet expired.
+func (m QualificationModel) GetFacilitatorsForCourse(courseID int64, filters Filters) ([]*QualifiedFacilitator, Metadata, error) {
+	query := `
+		SELECT COUNT(*) OVER(), ` + facilitatorColumns + `, ` + qualificationColumns + `
+		FROM ` + facilitatorTables + `
+		INNER JOIN facilitator_qualifications fq ON fq.facilitator_id = f.id
+		WHERE fq.course_id = $1
+		AND fq.qualified_since <= CURRENT_DATE
+		AND (fq.expires_on IS NULL OR fq.expires_on >= CURRENT_DATE)
+		ORDER BY last_name, first_name, f.id
+		LIMIT $2 OFFSET $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, courseID, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	qualified := []*QualifiedFacilitator{}
+
+	for rows.Next() {
+		var facilitator Facilitator
+		var qualification Qualification
+		dest := append([]any{&totalRecords}, facilitator.dest()...)
+		err := rows.Scan(append(dest, qualification.dest()...)...)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		qualified = append(qualified, &QualifiedFacilitator{
+			Facilitator:   &facilitator,
+			Qualification: &qualification,
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return qualified, metadata, nil
+}
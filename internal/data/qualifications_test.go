@@ -0,0 +1,29 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+func TestValidateQualification(t *testing.T) {
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("qualification without expiry is valid", func(t *testing.T) {
+		v := validator.New()
+		ValidateQualification(v, &Qualification{CourseID: 1, QualifiedSince: since})
+		if !v.IsEmpty() {
+			t.Errorf("expected qualification to be valid, but got errors: %v", v.Errors)
+		}
+	})
+
+	t.Run("expires before it was gained", func(t *testing.T) {
+		expires := since.AddDate(0, -1, 0)
+		v := validator.New()
+		ValidateQualification(v, &Qualification{CourseID: 1, QualifiedSince: since, ExpiresOn: &expires})
+		if _, exists := v.Errors["expires_on"]; !exists {
+			t.Error("expected error on expires_on field, but it was not found")
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// FeedbackReminder is an officer who completed a session which has ended but
+// has not yet rated the course, or one or more of its facilitators.
+type FeedbackReminder struct {
+	EnrollmentID  int64
+	SessionID     int64
+	CourseTitle   string
+	EndDate       time.Time
+	Location      string
+	Email         string
+	FirstName     string
+	RemindersSent int
+	CourseRated   bool
+	Facilitators  []string
+}
+
+// ReminderModel finds the officers due a feedback reminder and records the
+// reminders sent to them.
+type ReminderModel struct {
+	DB *sql.DB
+}
+
+// GetDue returns up to limit officers who completed a session which ended
+// within window, still have feedback to give, have been sent fewer than max
+// reminders and have not been sent one within gap. Sessions which ended
+// earliest come first.
+func (m ReminderModel) GetDue(ctx context.Context, max int, gap, window time.Duration, limit int) ([]*FeedbackReminder, error) {
+	query := `
+		SELECT se.id, ts.id, c.title, ts.end_date, COALESCE(ts.location, ''), u.email, p.first_name,
+			COALESCE(fr.reminders_sent, 0),
+			EXISTS (SELECT 1 FROM course_ratings cr WHERE cr.session_enrollment_id = se.id),
+			ARRAY(
+				SELECT f.first_name || ' ' || f.last_name
+				FROM session_facilitators sf
+				INNER JOIN facilitators f ON f.id = sf.facilitator_id
+				WHERE sf.session_id = ts.id AND NOT EXISTS (
+					SELECT 1 FROM facilitator_ratings r
+					WHERE r.session_enrollment_id = se.id AND r.facilitator_id = sf.facilitator_id
+				)
+				ORDER BY f.last_name, f.first_name
+			)
+		FROM session_enrollment se
+		INNER JOIN training_sessions ts ON ts.id = se.session_id
+		INNER JOIN courses c ON c.id = ts.course_id
+		INNER JOIN personnel p ON p.id = se.personnel_id
+		INNER JOIN users u ON u.personnel_id = p.id AND u.activated
+		LEFT JOIN feedback_reminders fr ON fr.session_enrollment_id = se.id
+		WHERE se.status = 'Completed'
+		AND ts.end_date < CURRENT_DATE
+		AND ts.end_date >= NOW() - make_interval(secs => $3)
+		AND COALESCE(fr.reminders_sent, 0) < $1
+		AND (fr.last_sent_at IS NULL OR fr.last_sent_at <= NOW() - make_interval(secs => $2))
+		AND (
+			NOT EXISTS (SELECT 1 FROM course_ratings cr WHERE cr.session_enrollment_id = se.id)
+			OR EXISTS (
+				SELECT 1 FROM session_facilitators sf
+				WHERE sf.session_id = ts.id AND NOT EXISTS (
+					SELECT 1 FROM facilitator_ratings r
+					WHERE r.session_enrollment_id = se.id AND r.facilitator_id = sf.facilitator_id
+				)
+			)
+		)
+		ORDER BY ts.end_date, se.id
+		LIMIT $4`
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, max, gap.Seconds(), window.Seconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reminders := []*FeedbackReminder{}
+
+	for rows.Next() {
+		var reminder FeedbackReminder
+
+		err := rows.Scan(
+			&reminder.EnrollmentID,
+			&reminder.SessionID,
+			&reminder.CourseTitle,
+			&reminder.EndDate,
+			&reminder.Location,
+			&reminder.Email,
+			&reminder.FirstName,
+			&reminder.RemindersSent,
+			&reminder.CourseRated,
+			pq.Array(&reminder.Facilitators),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		reminders = append(reminders, &reminder)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+// Record counts a reminder as sent for an enrollment.
+func (m ReminderModel) Record(ctx context.Context, enrollmentID int64) error {
+	query := `
+		INSERT INTO feedback_reminders (session_enrollment_id, reminders_sent, last_sent_at)
+		VALUES ($1, 1, NOW())
+		ON CONFLICT (session_enrollment_id) DO UPDATE
+		SET reminders_sent = feedback_reminders.reminders_sent + 1, last_sent_at = NOW()`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, enrollmentID)
+	return translatePgError(err)
+}
@@ -0,0 +1,130 @@
+package data
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/testdb"
+)
+
+func TestReminderModelGetDue(t *testing.T) {
+	db := testdb.Open(t)
+	m := ReminderModel{DB: db}
+	ctx := context.Background()
+
+	const max = 2
+	gap, window := 24*time.Hour, 7*24*time.Hour
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	ended := newTestSession(t, db, today.AddDate(0, 0, -3), today.AddDate(0, 0, -2))
+	running := newTestSession(t, db, today.AddDate(0, 0, -1), today.AddDate(0, 0, 1))
+	old := newTestSession(t, db, today.AddDate(0, 0, -30), today.AddDate(0, 0, -30))
+
+	facilitatorID := newTestFacilitator(t, db, newTestOfficer(t, db))
+	facilitated := newTestSession(t, db, today.AddDate(0, 0, -3), today.AddDate(0, 0, -2))
+	mustExec(t, db, `INSERT INTO session_facilitators (session_id, facilitator_id) VALUES ($1, $2)`, facilitated, facilitatorID)
+
+	// enroll creates an officer with an activated account and enrolls them
+	enroll := func(sessionID int64, status string) int64 {
+		officerID := newTestOfficer(t, db)
+		user := newTestUser(t, db, "pa55word1234", "System User")
+		mustExec(t, db, `UPDATE users SET personnel_id = $1 WHERE id = $2`, officerID, user.ID)
+		return newTestEnrollment(t, db, officerID, sessionID, status)
+	}
+
+	rateCourse := func(enrollmentID int64) {
+		mustExec(t, db, `INSERT INTO course_ratings (session_enrollment_id, score) VALUES ($1, 4)`, enrollmentID)
+	}
+
+	remind := func(enrollmentID int64, sent int, at time.Time) {
+		mustExec(t, db, `
+			INSERT INTO feedback_reminders (session_enrollment_id, reminders_sent, last_sent_at)
+			VALUES ($1, $2, $3)`, enrollmentID, sent, at)
+	}
+
+	due := enroll(ended, "Completed")
+
+	facilitatorUnrated := enroll(facilitated, "Completed")
+	rateCourse(facilitatorUnrated)
+
+	remindedOnce := enroll(ended, "Completed")
+	remind(remindedOnce, 1, time.Now().Add(-2*gap))
+
+	notCompleted := enroll(ended, "Enrolled")
+	notEnded := enroll(running, "Completed")
+	outsideWindow := enroll(old, "Completed")
+
+	rated := enroll(ended, "Completed")
+	rateCourse(rated)
+
+	remindedRecently := enroll(ended, "Completed")
+	remind(remindedRecently, 1, time.Now().Add(-time.Hour))
+
+	remindedEnough := enroll(ended, "Completed")
+	remind(remindedEnough, max, time.Now().Add(-2*gap))
+
+	noAccount := newTestEnrollment(t, db, newTestOfficer(t, db), ended, "Completed")
+
+	reminders, err := m.GetDue(ctx, max, gap, window, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[int64]*FeedbackReminder{}
+	for _, reminder := range reminders {
+		got[reminder.EnrollmentID] = reminder
+	}
+
+	for _, id := range []int64{due, facilitatorUnrated, remindedOnce} {
+		if _, ok := got[id]; !ok {
+			t.Errorf("expected enrollment %d to be due a reminder", id)
+		}
+	}
+
+	for name, id := range map[string]int64{
+		"not completed":     notCompleted,
+		"not ended":         notEnded,
+		"outside window":    outsideWindow,
+		"everything rated":  rated,
+		"reminded recently": remindedRecently,
+		"reminded enough":   remindedEnough,
+		"no account":        noAccount,
+	} {
+		if _, ok := got[id]; ok {
+			t.Errorf("expected the %s enrollment not to be due a reminder", name)
+		}
+	}
+
+	if reminder := got[facilitatorUnrated]; reminder != nil {
+		if !reminder.CourseRated || !slices.Equal(reminder.Facilitators, []string{"Test Facilitator"}) {
+			t.Errorf("expected only the facilitator to be left to rate, got course rated %t and %v",
+				reminder.CourseRated, reminder.Facilitators)
+		}
+	}
+	if reminder := got[remindedOnce]; reminder != nil && reminder.RemindersSent != 1 {
+		t.Errorf("expected 1 reminder sent, got %d", reminder.RemindersSent)
+	}
+
+	t.Run("record", func(t *testing.T) {
+		for range 2 {
+			err := m.Record(ctx, due)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if n := queryInt(t, db, `SELECT reminders_sent FROM feedback_reminders WHERE session_enrollment_id = $1`, due); n != 2 {
+			t.Errorf("expected 2 reminders recorded, got %d", n)
+		}
+
+		reminders, err := m.GetDue(ctx, max, gap, window, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if slices.ContainsFunc(reminders, func(r *FeedbackReminder) bool { return r.EnrollmentID == due }) {
+			t.Error("expected a recorded reminder to stop the enrollment being due")
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Role defines the structure for a user role.
+type Role struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// RoleModel wraps the database connection pool.
+type RoleModel struct {
+	DB *sql.DB
+}
+
+// Get retrieves a specific role by ID.
+func (m RoleModel) Get(id int) (*Role, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT id, name
+		FROM roles
+		WHERE id = $1`
+
+	var role Role
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(&role.ID, &role.Name)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &role, nil
+}
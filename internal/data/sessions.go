@@ -0,0 +1,296 @@
+package data
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrRefreshTokenReused is returned when a refresh token which has already
+// been exchanged is presented again, which suggests it has been stolen.
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+// maxUserAgentLength is the most of a client's user agent recorded for a session
+const maxUserAgentLength = 512
+
+// sessionScopes are the scopes of the tokens which make up a session.
+var sessionScopes = []string{ScopeAuthentication, ScopeRefresh}
+
+// Session is a login, made up of a short-lived access token and the refresh
+// token it is renewed with. Its ID is the family the tokens belong to, and
+// CreatedAt is when the user logged in. Current is set for the session the
+// listing was requested with.
+type Session struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	Expiry     time.Time  `json:"expiry"`
+	ClientIP   string     `json:"client_ip"`
+	UserAgent  string     `json:"user_agent"`
+	Current    bool       `json:"current"`
+}
+
+// insertSessionTokens issues an access token and a refresh token to a user in
+// a token family. A familyID of zero starts a new family, and a nil createdAt
+// a new session.
+func insertSessionTokens(ctx context.Context, tx *sql.Tx, userID, familyID int64, createdAt *time.Time, accessTTL, refreshTTL time.Duration, clientIP, userAgent string) (*Token, *Token, error) {
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
+	}
+
+	query := `
+		INSERT INTO tokens (hash, user_id, expiry, scope, client_ip, user_agent, family_id, created_at, last_used_at)
+		VALUES ($1, $2, $3, $4, $5, $6, COALESCE(NULLIF($7, 0), nextval('token_families_seq')), COALESCE($8, NOW()), NOW())
+		RETURNING family_id`
+
+	tokens := []*Token{}
+
+	for _, issue := range []struct {
+		scope string
+		ttl   time.Duration
+	}{{ScopeAuthentication, accessTTL}, {ScopeRefresh, refreshTTL}} {
+		token, err := generateToken(userID, issue.ttl, issue.scope)
+		if err != nil {
+			return nil, nil, err
+		}
+		token.ClientIP = clientIP
+		token.UserAgent = userAgent
+
+		args := []any{token.Hash, token.UserID, token.Expiry, token.Scope, token.ClientIP, token.UserAgent, familyID, createdAt}
+
+		err = tx.QueryRowContext(ctx, query, args...).Scan(&familyID)
+		if err != nil {
+			return nil, nil, translatePgError(err)
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	return tokens[0], tokens[1], nil
+}
+
+// NewSession logs a user in, issuing an access token and a refresh token in a
+// new family and recording the address and user agent of the client.
+func (m TokenModel) NewSession(userID int64, accessTTL, refreshTTL time.Duration, clientIP, userAgent string) (*Token, *Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	access, refresh, err := insertSessionTokens(ctx, tx, userID, 0, nil, accessTTL, refreshTTL, clientIP, userAgent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return access, refresh, nil
+}
+
+// Refresh exchanges a refresh token for a new access token and refresh token
+// in the same family, and revokes the family's previous access token. A
+// refresh token can only be exchanged once. If a spent one is presented again
+// every token in its family is revoked and ErrRefreshTokenReused returned.
+func (m TokenModel) Refresh(tokenPlaintext string, accessTTL, refreshTTL time.Duration, clientIP, userAgent string) (*Token, *Token, error) {
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	var (
+		userID, familyID int64
+		createdAt        time.Time
+		usedAt           *time.Time
+	)
+
+	err = tx.QueryRowContext(ctx, `
+		SELECT user_id, family_id, created_at, used_at
+		FROM tokens
+		WHERE hash = $1 AND scope = $2 AND expiry > NOW()
+		FOR UPDATE`,
+		tokenHash[:], ScopeRefresh).Scan(&userID, &familyID, &createdAt, &usedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, nil, ErrRecordNotFound
+		default:
+			return nil, nil, err
+		}
+	}
+
+	if usedAt != nil {
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM tokens
+			WHERE family_id = $1 AND scope = ANY($2)`,
+			familyID, pq.Array(sessionScopes))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return nil, nil, ErrRefreshTokenReused
+	}
+
+	// The spent refresh token is kept until it expires so reuse can be detected
+	_, err = tx.ExecContext(ctx, `UPDATE tokens SET used_at = NOW() WHERE hash = $1`, tokenHash[:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM tokens
+		WHERE family_id = $1 AND scope = $2`,
+		familyID, ScopeAuthentication)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	access, refresh, err := insertSessionTokens(ctx, tx, userID, familyID, &createdAt, accessTTL, refreshTTL, clientIP, userAgent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return access, refresh, nil
+}
+
+// GetSessionsForUser returns the sessions of a user which can still be used
+// or refreshed, most recently used first.
+func (m TokenModel) GetSessionsForUser(userID int64) ([]*Session, error) {
+	query := `
+		SELECT s.*
+		FROM (
+			SELECT DISTINCT ON (family_id) family_id, user_id, created_at, last_used_at, expiry, client_ip, user_agent
+			FROM tokens
+			WHERE user_id = $1 AND scope = ANY($2) AND used_at IS NULL AND expiry > NOW()
+			ORDER BY family_id, expiry DESC
+		) s
+		ORDER BY COALESCE(s.last_used_at, s.created_at) DESC, s.family_id DESC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, pq.Array(sessionScopes))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*Session{}
+
+	for rows.Next() {
+		var session Session
+		err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.CreatedAt,
+			&session.LastUsedAt,
+			&session.Expiry,
+			&session.ClientIP,
+			&session.UserAgent,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, &session)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// DeleteSession revokes the access and refresh tokens of one of a user's sessions.
+func (m TokenModel) DeleteSession(userID, id int64) error {
+	query := `
+		DELETE FROM tokens
+		WHERE family_id = $1 AND user_id = $2 AND scope = ANY($3)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, userID, pq.Array(sessionScopes))
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// DeleteSessionsForUser revokes every session of a user.
+func (m TokenModel) DeleteSessionsForUser(userID int64) error {
+	query := `
+		DELETE FROM tokens
+		WHERE user_id = $1 AND scope = ANY($2)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, userID, pq.Array(sessionScopes))
+	return err
+}
+
+// Touch records that a session has just been used.
+func (m TokenModel) Touch(id int64) error {
+	query := `
+		UPDATE tokens
+		SET last_used_at = NOW()
+		WHERE family_id = $1 AND used_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, id)
+	return err
+}
+
+// DeleteExpired removes every expired token, returning how many there were.
+func (m TokenModel) DeleteExpired(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, `DELETE FROM tokens WHERE expiry <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
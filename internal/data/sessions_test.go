@@ -0,0 +1,162 @@
+package data
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/testdb"
+)
+
+func TestUserModelGetForAuthenticationToken(t *testing.T) {
+	db := testdb.Open(t)
+	users, tokens := UserModel{DB: db}, TokenModel{DB: db}
+
+	user := newTestUser(t, db, "pa55word1234", "System User")
+
+	access, refresh, err := tokens.NewSession(user.ID, time.Hour, 24*time.Hour, "203.0.113.7", "test agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, session, err := users.GetForAuthenticationToken(access.Plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != user.ID || session.UserID != user.ID {
+		t.Errorf("expected user %d, got %d with a session of user %d", user.ID, got.ID, session.UserID)
+	}
+	if !session.Current || session.ClientIP != "203.0.113.7" || session.UserAgent != "test agent" {
+		t.Errorf("expected the current session from 203.0.113.7 with its user agent, got %+v", session)
+	}
+
+	family := queryInt(t, db, `SELECT family_id FROM tokens WHERE hash = $1`, access.Hash)
+	if session.ID != family {
+		t.Errorf("expected the session id to be family %d, got %d", family, session.ID)
+	}
+
+	// A refresh token cannot be used to authenticate
+	_, _, err = users.GetForAuthenticationToken(refresh.Plaintext)
+	if !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound for a refresh token, got %v", err)
+	}
+
+	mustExec(t, db, `UPDATE tokens SET expiry = NOW() - INTERVAL '1 second' WHERE hash = $1`, access.Hash)
+	_, _, err = users.GetForAuthenticationToken(access.Plaintext)
+	if !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound for an expired token, got %v", err)
+	}
+}
+
+func TestTokenModelRefresh(t *testing.T) {
+	db := testdb.Open(t)
+	users, tokens := UserModel{DB: db}, TokenModel{DB: db}
+
+	user := newTestUser(t, db, "pa55word1234", "System User")
+
+	access, refresh, err := tokens.NewSession(user.ID, time.Hour, 24*time.Hour, "203.0.113.7", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	family := queryInt(t, db, `SELECT family_id FROM tokens WHERE hash = $1`, access.Hash)
+
+	// Rotating issues a new pair in the same family and revokes the old access token
+	newAccess, newRefresh, err := tokens.Refresh(refresh.Plaintext, time.Hour, 24*time.Hour, "203.0.113.8", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newAccess.Plaintext == access.Plaintext || newRefresh.Plaintext == refresh.Plaintext {
+		t.Fatal("expected new tokens to be issued")
+	}
+
+	_, _, err = users.GetForAuthenticationToken(access.Plaintext)
+	if !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected the old access token to be revoked, got %v", err)
+	}
+
+	_, session, err := users.GetForAuthenticationToken(newAccess.Plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.ID != family {
+		t.Errorf("expected the new tokens to stay in family %d, got %d", family, session.ID)
+	}
+
+	// Presenting the spent refresh token again revokes the whole family
+	_, _, err = tokens.Refresh(refresh.Plaintext, time.Hour, 24*time.Hour, "198.51.100.1", "attacker")
+	if !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("expected ErrRefreshTokenReused, got %v", err)
+	}
+
+	_, _, err = users.GetForAuthenticationToken(newAccess.Plaintext)
+	if !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected the family's access token to be revoked, got %v", err)
+	}
+
+	_, _, err = tokens.Refresh(newRefresh.Plaintext, time.Hour, 24*time.Hour, "203.0.113.8", "test")
+	if !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected the family's refresh token to be revoked, got %v", err)
+	}
+
+	if n := queryInt(t, db, `SELECT COUNT(*) FROM tokens WHERE family_id = $1`, family); n != 0 {
+		t.Errorf("expected every token in the family to be deleted, found %d", n)
+	}
+
+	t.Run("unknown token", func(t *testing.T) {
+		_, _, err := tokens.Refresh("ABCDEFGHIJKLMNOPQRSTUVWXYZ", time.Hour, 24*time.Hour, "", "")
+		if !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("expected ErrRecordNotFound, got %v", err)
+		}
+	})
+}
+
+func TestTokenModelGetSessionsForUser(t *testing.T) {
+	db := testdb.Open(t)
+	tokens := TokenModel{DB: db}
+
+	user := newTestUser(t, db, "pa55word1234", "System User")
+	other := newTestUser(t, db, "pa55word1234", "System User")
+
+	_, refresh, err := tokens.NewSession(user.ID, time.Hour, 24*time.Hour, "203.0.113.7", "laptop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = tokens.NewSession(user.ID, time.Hour, 24*time.Hour, "203.0.113.8", "phone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = tokens.NewSession(other.ID, time.Hour, 24*time.Hour, "203.0.113.9", "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A refreshed session is still one session, even though its spent refresh
+	// token is kept until it expires
+	_, _, err = tokens.Refresh(refresh.Plaintext, time.Hour, 24*time.Hour, "203.0.113.7", "laptop")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := tokens.GetSessionsForUser(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(sessions))
+	}
+
+	agents := map[string]bool{}
+	for _, session := range sessions {
+		agents[session.UserAgent] = true
+		if session.UserID != user.ID {
+			t.Errorf("expected only sessions of user %d, got one of user %d", user.ID, session.UserID)
+		}
+		if !session.Expiry.After(time.Now().Add(time.Hour)) {
+			t.Errorf("expected a session to last as long as its refresh token, got expiry %v", session.Expiry)
+		}
+	}
+	if !agents["laptop"] || !agents["phone"] {
+		t.Errorf("expected the laptop and phone sessions, got %v", agents)
+	}
+}
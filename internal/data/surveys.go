@@ -0,0 +1,646 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+	"github.com/lib/pq"
+)
+
+// SurveyQuestionKinds lists the accepted values of SurveyQuestion.Kind.
+var SurveyQuestionKinds = []string{"likert", "multiple_choice", "free_text"}
+
+// Survey is an evaluation form made up of questions, which officers answer
+// for the sessions they were enrolled in. Legacy surveys are the single-score
+// course and facilitator ratings presented as a survey of one question.
+type Survey struct {
+	ID          int64             `json:"id"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Legacy      bool              `json:"legacy,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	Version     int32             `json:"version"`
+	Questions   []*SurveyQuestion `json:"questions,omitempty"`
+}
+
+// SurveyQuestion is a single question of a survey. Likert questions are
+// answered with a score from 1 to 5, multiple choice questions with one of
+// the options and free text questions with a comment.
+type SurveyQuestion struct {
+	ID       int64    `json:"id"`
+	SurveyID int64    `json:"survey_id"`
+	Position int      `json:"position"`
+	Kind     string   `json:"kind"`
+	Prompt   string   `json:"prompt"`
+	Options  []string `json:"options,omitempty"`
+	Required bool     `json:"required"`
+}
+
+// SurveySubmission is an officer's answers to a survey for one enrollment.
+type SurveySubmission struct {
+	ID                  int64           `json:"id"`
+	SurveyID            int64           `json:"survey_id"`
+	SessionEnrollmentID int64           `json:"session_enrollment_id"`
+	CreatedAt           time.Time       `json:"created_at"`
+	Answers             []*SurveyAnswer `json:"answers"`
+}
+
+// SurveyAnswer is the answer to a single question. Only the field matching the
+// kind of question is set.
+type SurveyAnswer struct {
+	QuestionID int64  `json:"question_id"`
+	Score      *int   `json:"score,omitempty"`
+	Choice     string `json:"choice,omitempty"`
+	Text       string `json:"text,omitempty"`
+}
+
+// QuestionResult aggregates the answers given to a single question.
+type QuestionResult struct {
+	Question   *SurveyQuestion `json:"question"`
+	Responses  int             `json:"responses"`
+	Reportable bool            `json:"reportable"`
+	Scores     *ScoreSummary   `json:"scores,omitempty"`
+	Choices    map[string]int  `json:"choices,omitempty"`
+	Comments   []string        `json:"comments,omitempty"`
+}
+
+// SurveyResults aggregates every submission of a survey, question by question.
+// The answers to a question are only reported once at least MinResponses
+// officers have answered it; until then only the number of answers is given.
+type SurveyResults struct {
+	Survey       *Survey           `json:"survey"`
+	Submissions  int               `json:"submissions"`
+	MinResponses int               `json:"min_responses"`
+	Questions    []*QuestionResult `json:"questions"`
+}
+
+// ValidateSurvey validates a Survey struct and its questions
+func ValidateSurvey(v *validator.Validator, survey *Survey) {
+	v.Check(survey.Title != "", "title", "must be provided")
+	v.Check(len(survey.Title) <= 200, "title", "must not be more than 200 bytes long")
+	v.Check(len(survey.Description) <= 2000, "description", "must not be more than 2000 bytes long")
+	v.Check(len(survey.Questions) > 0, "questions", "must contain at least one question")
+	v.Check(len(survey.Questions) <= 50, "questions", "must not contain more than 50 questions")
+
+	for _, q := range survey.Questions {
+		v.Check(slices.Contains(SurveyQuestionKinds, q.Kind), "questions", "kind must be one of likert, multiple_choice or free_text")
+		v.Check(q.Prompt != "", "questions", "prompt must be provided")
+		v.Check(len(q.Prompt) <= 500, "questions", "prompt must not be more than 500 bytes long")
+
+		if q.Kind == "multiple_choice" {
+			v.Check(len(q.Options) >= 2 && len(q.Options) <= 20, "questions", "multiple choice questions must have between 2 and 20 options")
+			v.Check(uniqueOptions(q.Options), "questions", "options must not contain duplicate values")
+			v.Check(!slices.Contains(q.Options, ""), "questions", "options must not be empty")
+		} else {
+			v.Check(len(q.Options) == 0, "questions", "only multiple choice questions have options")
+		}
+	}
+}
+
+// uniqueOptions reports whether no option is given more than once.
+func uniqueOptions(options []string) bool {
+	seen := make(map[string]bool, len(options))
+	for _, option := range options {
+		if seen[option] {
+			return false
+		}
+		seen[option] = true
+	}
+	return true
+}
+
+// ValidateSurveyAnswers validates the answers submitted to a survey: every
+// answer must be for one of its questions and of the right kind, and every
+// required question must be answered.
+func ValidateSurveyAnswers(v *validator.Validator, survey *Survey, answers []*SurveyAnswer) {
+	questions := make(map[int64]*SurveyQuestion, len(survey.Questions))
+	for _, q := range survey.Questions {
+		questions[q.ID] = q
+	}
+
+	answered := make(map[int64]bool, len(answers))
+
+	for _, a := range answers {
+		q, ok := questions[a.QuestionID]
+		if !ok {
+			v.AddError("answers", "must only answer questions in the survey")
+			continue
+		}
+		v.Check(!answered[a.QuestionID], "answers", "must not answer the same question more than once")
+		answered[a.QuestionID] = true
+
+		switch q.Kind {
+		case "likert":
+			v.Check(a.Score != nil && *a.Score >= 1 && *a.Score <= 5, "answers", "likert questions must be answered with a score from 1 to 5")
+			v.Check(a.Choice == "" && a.Text == "", "answers", "likert questions must only be answered with a score")
+		case "multiple_choice":
+			v.Check(slices.Contains(q.Options, a.Choice), "answers", "multiple choice questions must be answered with one of their options")
+			v.Check(a.Score == nil && a.Text == "", "answers", "multiple choice questions must only be answered with a choice")
+		case "free_text":
+			v.Check(a.Text != "", "answers", "free text questions must be answered with text")
+			v.Check(len(a.Text) <= 2000, "answers", "text must not be more than 2000 bytes long")
+			v.Check(a.Score == nil && a.Choice == "", "answers", "free text questions must only be answered with text")
+		}
+	}
+
+	for _, q := range survey.Questions {
+		if q.Required {
+			v.Check(answered[q.ID], "answers", "must answer every required question")
+		}
+	}
+}
+
+// buildSurveyResults folds the answers given to a survey into per-question
+// results, withholding the answers to questions with fewer than minResponses.
+func buildSurveyResults(survey *Survey, submissions, minResponses int, answers []*SurveyAnswer) *SurveyResults {
+	results := &SurveyResults{
+		Survey:       survey,
+		Submissions:  submissions,
+		MinResponses: minResponses,
+		Questions:    make([]*QuestionResult, 0, len(survey.Questions)),
+	}
+
+	byQuestion := make(map[int64]*QuestionResult, len(survey.Questions))
+
+	for _, q := range survey.Questions {
+		result := &QuestionResult{Question: q}
+		switch q.Kind {
+		case "likert":
+			scores := newScoreSummary()
+			result.Scores = &scores
+		case "multiple_choice":
+			result.Choices = make(map[string]int, len(q.Options))
+			for _, option := range q.Options {
+				result.Choices[option] = 0
+			}
+		case "free_text":
+			result.Comments = []string{}
+		}
+		byQuestion[q.ID] = result
+		results.Questions = append(results.Questions, result)
+	}
+
+	for _, a := range answers {
+		result, ok := byQuestion[a.QuestionID]
+		if !ok {
+			continue
+		}
+
+		switch {
+		case result.Scores != nil && a.Score != nil:
+			result.Scores.add(*a.Score, 1)
+		case result.Choices != nil && a.Choice != "":
+			result.Choices[a.Choice]++
+		case result.Comments != nil && a.Text != "":
+			result.Comments = append(result.Comments, a.Text)
+		default:
+			continue
+		}
+		result.Responses++
+	}
+
+	for _, result := range results.Questions {
+		result.Reportable = result.Responses > 0 && result.Responses >= minResponses
+		if result.Scores != nil {
+			result.Scores.finish(minResponses)
+		}
+		if !result.Reportable {
+			result.Choices = nil
+			result.Comments = nil
+		}
+	}
+
+	return results
+}
+
+// Legacy surveys present the single-score course and facilitator ratings in the
+// same shape as a survey, so they can be read alongside newer results.
+const (
+	legacyScoreQuestionID   = 1
+	legacyCommentQuestionID = 2
+)
+
+func legacySurvey(title, prompt string) *Survey {
+	return &Survey{
+		Title:  title,
+		Legacy: true,
+		Questions: []*SurveyQuestion{
+			{ID: legacyScoreQuestionID, Position: 1, Kind: "likert", Prompt: prompt, Required: true},
+			{ID: legacyCommentQuestionID, Position: 2, Kind: "free_text", Prompt: "Comments"},
+		},
+	}
+}
+
+// SurveyModel wraps the database connection pool.
+type SurveyModel struct {
+	DB *sql.DB
+}
+
+// Insert creates a survey along with its questions, which are numbered in the
+// order given.
+func (m SurveyModel) Insert(survey *Survey) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO surveys (title, description)
+		VALUES ($1, $2)
+		RETURNING id, created_at, version`,
+		survey.Title, survey.Description,
+	).Scan(&survey.ID, &survey.CreatedAt, &survey.Version)
+	if err != nil {
+		return translatePgError(err)
+	}
+
+	for i, q := range survey.Questions {
+		q.SurveyID = survey.ID
+		q.Position = i + 1
+
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO survey_questions (survey_id, position, kind, prompt, options, required)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id`,
+			q.SurveyID, q.Position, q.Kind, q.Prompt, pq.Array(q.Options), q.Required,
+		).Scan(&q.ID)
+		if err != nil {
+			return translatePgError(err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Get retrieves a specific survey along with its questions.
+func (m SurveyModel) Get(id int64) (*Survey, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT id, title, description, created_at, version
+		FROM surveys
+		WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var survey Survey
+
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&survey.ID,
+		&survey.Title,
+		&survey.Description,
+		&survey.CreatedAt,
+		&survey.Version,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	survey.Questions, err = m.getQuestions(ctx, survey.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &survey, nil
+}
+
+// getQuestions retrieves the questions of a survey in order.
+func (m SurveyModel) getQuestions(ctx context.Context, surveyID int64) ([]*SurveyQuestion, error) {
+	query := `
+		SELECT id, survey_id, position, kind, prompt, options, required
+		FROM survey_questions
+		WHERE survey_id = $1
+		ORDER BY position`
+
+	rows, err := m.DB.QueryContext(ctx, query, surveyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questions := []*SurveyQuestion{}
+
+	for rows.Next() {
+		var q SurveyQuestion
+		err := rows.Scan(&q.ID, &q.SurveyID, &q.Position, &q.Kind, &q.Prompt, pq.Array(&q.Options), &q.Required)
+		if err != nil {
+			return nil, err
+		}
+		questions = append(questions, &q)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return questions, nil
+}
+
+// GetAll returns a page of surveys, without their questions.
+func (m SurveyModel) GetAll(filters Filters) ([]*Survey, Metadata, error) {
+	query := `
+		SELECT COUNT(*) OVER(), id, title, description, created_at, version
+		FROM surveys
+		ORDER BY id
+		LIMIT $1 OFFSET $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	surveys := []*Survey{}
+
+	for rows.Next() {
+		var survey Survey
+		err := rows.Scan(&totalRecords, &survey.ID, &survey.Title, &survey.Description, &survey.CreatedAt, &survey.Version)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		surveys = append(surveys, &survey)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return surveys, metadata, nil
+}
+
+// Delete deletes a specific survey. Surveys which have been answered cannot be deleted.
+func (m SurveyModel) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, `DELETE FROM surveys WHERE id = $1`, id)
+	if err != nil {
+		return translatePgError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetForCourse retrieves the survey attached to a course along with its
+// questions. ErrRecordNotFound is returned if the course has no survey.
+func (m SurveyModel) GetForCourse(courseID int64) (*Survey, error) {
+	query := `
+		SELECT survey_id
+		FROM courses
+		WHERE id = $1 AND survey_id IS NOT NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var surveyID int64
+
+	err := m.DB.QueryRowContext(ctx, query, courseID).Scan(&surveyID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return m.Get(surveyID)
+}
+
+// SetForCourse attaches a survey to a course, replacing any attached before.
+// A surveyID of zero detaches the survey.
+func (m SurveyModel) SetForCourse(courseID, surveyID int64) error {
+	query := `
+		UPDATE courses
+		SET survey_id = $1, updated_at = NOW()
+		WHERE id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, sql.NullInt64{Int64: surveyID, Valid: surveyID > 0}, courseID)
+	if err != nil {
+		return translatePgError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// InsertSubmission records an officer's answers to a survey.
+func (m SurveyModel) InsertSubmission(submission *SurveySubmission) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO survey_submissions (survey_id, session_enrollment_id)
+		VALUES ($1, $2)
+		RETURNING id, created_at`,
+		submission.SurveyID, submission.SessionEnrollmentID,
+	).Scan(&submission.ID, &submission.CreatedAt)
+	if err != nil {
+		return translatePgError(err)
+	}
+
+	for _, a := range submission.Answers {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO survey_answers (submission_id, question_id, score, choice, answer_text)
+			VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''))`,
+			submission.ID, a.QuestionID, a.Score, a.Choice, a.Text)
+		if err != nil {
+			return translatePgError(err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetResults aggregates the submissions of a survey, optionally only those made
+// for sessions of a specific course.
+func (m SurveyModel) GetResults(survey *Survey, courseID int64, minResponses int) (*SurveyResults, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var submissions int
+
+	err := m.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM survey_submissions ss
+		INNER JOIN session_enrollment se ON se.id = ss.session_enrollment_id
+		INNER JOIN training_sessions ts ON ts.id = se.session_id
+		WHERE ss.survey_id = $1 AND ($2 = 0 OR ts.course_id = $2)`,
+		survey.ID, courseID).Scan(&submissions)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT sa.question_id, sa.score, COALESCE(sa.choice, ''), COALESCE(sa.answer_text, '')
+		FROM survey_answers sa
+		INNER JOIN survey_submissions ss ON ss.id = sa.submission_id
+		INNER JOIN session_enrollment se ON se.id = ss.session_enrollment_id
+		INNER JOIN training_sessions ts ON ts.id = se.session_id
+		WHERE ss.survey_id = $1 AND ($2 = 0 OR ts.course_id = $2)
+		ORDER BY ss.created_at DESC, sa.id`,
+		survey.ID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	answers, err := scanSurveyAnswers(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildSurveyResults(survey, submissions, minResponses, answers), nil
+}
+
+// GetLegacyResults presents the single-score course ratings and facilitator
+// ratings given for a course as two legacy surveys. Only published comments
+// are included.
+func (m SurveyModel) GetLegacyResults(courseID int64, minResponses int) ([]*SurveyResults, error) {
+	legacy := []struct {
+		survey *Survey
+		query  string
+	}{
+		{
+			survey: legacySurvey("Course rating", "How would you rate this course overall?"),
+			query: `
+				SELECT cr.score, CASE WHEN cr.status = 'published' THEN COALESCE(cr.comment, '') ELSE '' END
+				FROM course_ratings cr
+				INNER JOIN session_enrollment se ON se.id = cr.session_enrollment_id
+				INNER JOIN training_sessions ts ON ts.id = se.session_id
+				WHERE ts.course_id = $1
+				ORDER BY cr.created_at DESC, cr.id`,
+		},
+		{
+			survey: legacySurvey("Facilitator rating", "How would you rate the facilitator?"),
+			query: `
+				SELECT fr.score, CASE WHEN fr.status = 'published' THEN COALESCE(fr.comment, '') ELSE '' END
+				FROM facilitator_ratings fr
+				INNER JOIN session_enrollment se ON se.id = fr.session_enrollment_id
+				INNER JOIN training_sessions ts ON ts.id = se.session_id
+				WHERE ts.course_id = $1
+				ORDER BY fr.created_at DESC, fr.id`,
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	results := make([]*SurveyResults, 0, len(legacy))
+
+	for _, l := range legacy {
+		rows, err := m.DB.QueryContext(ctx, l.query, courseID)
+		if err != nil {
+			return nil, err
+		}
+
+		submissions := 0
+		answers := []*SurveyAnswer{}
+
+		for rows.Next() {
+			var score int
+			var comment string
+			err := rows.Scan(&score, &comment)
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+
+			submissions++
+			answers = append(answers, &SurveyAnswer{QuestionID: legacyScoreQuestionID, Score: &score})
+			if comment != "" {
+				answers = append(answers, &SurveyAnswer{QuestionID: legacyCommentQuestionID, Text: comment})
+			}
+		}
+
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, buildSurveyResults(l.survey, submissions, minResponses, answers))
+	}
+
+	return results, nil
+}
+
+// scanSurveyAnswers reads rows of question_id, score, choice and text.
+func scanSurveyAnswers(rows *sql.Rows) ([]*SurveyAnswer, error) {
+	answers := []*SurveyAnswer{}
+
+	for rows.Next() {
+		var a SurveyAnswer
+		var score sql.NullInt64
+
+		err := rows.Scan(&a.QuestionID, &score, &a.Choice, &a.Text)
+		if err != nil {
+			return nil, err
+		}
+
+		if score.Valid {
+			s := int(score.Int64)
+			a.Score = &s
+		}
+
+		answers = append(answers, &a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return answers, nil
+}
@@ -0,0 +1,205 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+func testSurvey() *Survey {
+	return &Survey{
+		ID:    1,
+		Title: "End of course evaluation",
+		Questions: []*SurveyQuestion{
+			{ID: 10, Position: 1, Kind: "likert", Prompt: "How useful was the course?", Required: true},
+			{ID: 11, Position: 2, Kind: "multiple_choice", Prompt: "Was the pace right?", Options: []string{"Too slow", "About right", "Too fast"}, Required: true},
+			{ID: 12, Position: 3, Kind: "free_text", Prompt: "Any other comments?"},
+		},
+	}
+}
+
+func score(s int) *int {
+	return &s
+}
+
+func TestValidateSurvey(t *testing.T) {
+	tests := []struct {
+		name   string
+		survey func(s *Survey)
+		valid  bool
+	}{
+		{"valid", func(s *Survey) {}, true},
+		{"missing title", func(s *Survey) { s.Title = "" }, false},
+		{"no questions", func(s *Survey) { s.Questions = nil }, false},
+		{"unknown kind", func(s *Survey) { s.Questions[0].Kind = "rating" }, false},
+		{"too few options", func(s *Survey) { s.Questions[1].Options = []string{"Yes"} }, false},
+		{"duplicate options", func(s *Survey) { s.Questions[1].Options = []string{"Yes", "Yes"} }, false},
+		{"options on a likert question", func(s *Survey) { s.Questions[0].Options = []string{"Yes", "No"} }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			survey := testSurvey()
+			tt.survey(survey)
+
+			v := validator.New()
+			ValidateSurvey(v, survey)
+
+			if v.IsEmpty() != tt.valid {
+				t.Errorf("expected valid to be %t, got errors %v", tt.valid, v.Errors)
+			}
+		})
+	}
+}
+
+func TestValidateSurveyAnswers(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers []*SurveyAnswer
+		valid   bool
+	}{
+		{"required questions answered", []*SurveyAnswer{
+			{QuestionID: 10, Score: score(4)},
+			{QuestionID: 11, Choice: "About right"},
+		}, true},
+		{"every question answered", []*SurveyAnswer{
+			{QuestionID: 10, Score: score(4)},
+			{QuestionID: 11, Choice: "About right"},
+			{QuestionID: 12, Text: "More exercises please"},
+		}, true},
+		{"required question missing", []*SurveyAnswer{
+			{QuestionID: 10, Score: score(4)},
+		}, false},
+		{"question from another survey", []*SurveyAnswer{
+			{QuestionID: 10, Score: score(4)},
+			{QuestionID: 11, Choice: "About right"},
+			{QuestionID: 99, Text: "?"},
+		}, false},
+		{"score out of range", []*SurveyAnswer{
+			{QuestionID: 10, Score: score(6)},
+			{QuestionID: 11, Choice: "About right"},
+		}, false},
+		{"choice not among the options", []*SurveyAnswer{
+			{QuestionID: 10, Score: score(4)},
+			{QuestionID: 11, Choice: "Fine"},
+		}, false},
+		{"text given for a likert question", []*SurveyAnswer{
+			{QuestionID: 10, Score: score(4), Text: "Good"},
+			{QuestionID: 11, Choice: "About right"},
+		}, false},
+		{"question answered twice", []*SurveyAnswer{
+			{QuestionID: 10, Score: score(4)},
+			{QuestionID: 10, Score: score(2)},
+			{QuestionID: 11, Choice: "About right"},
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			ValidateSurveyAnswers(v, testSurvey(), tt.answers)
+
+			if v.IsEmpty() != tt.valid {
+				t.Errorf("expected valid to be %t, got errors %v", tt.valid, v.Errors)
+			}
+		})
+	}
+}
+
+func TestBuildSurveyResults(t *testing.T) {
+	answers := []*SurveyAnswer{
+		{QuestionID: 10, Score: score(5)},
+		{QuestionID: 11, Choice: "About right"},
+		{QuestionID: 12, Text: "Great trainers"},
+		{QuestionID: 10, Score: score(3)},
+		{QuestionID: 11, Choice: "Too fast"},
+		{QuestionID: 10, Score: score(4)},
+		{QuestionID: 11, Choice: "About right"},
+	}
+
+	results := buildSurveyResults(testSurvey(), 3, 1, answers)
+
+	if results.Submissions != 3 || len(results.Questions) != 3 {
+		t.Fatalf("expected 3 submissions and 3 questions, got %d and %d", results.Submissions, len(results.Questions))
+	}
+
+	t.Run("likert scores are averaged", func(t *testing.T) {
+		likert := results.Questions[0]
+		if likert.Responses != 3 || likert.Scores.Average == nil || *likert.Scores.Average != 4 {
+			t.Errorf("expected 3 responses averaging 4, got %+v", likert.Scores)
+		}
+	})
+
+	t.Run("choices are counted including unchosen options", func(t *testing.T) {
+		choices := results.Questions[1].Choices
+		if choices["About right"] != 2 || choices["Too fast"] != 1 || choices["Too slow"] != 0 {
+			t.Errorf("unexpected choice counts %v", choices)
+		}
+		if _, ok := choices["Too slow"]; !ok {
+			t.Errorf("expected unchosen options to be reported")
+		}
+	})
+
+	t.Run("comments are collected", func(t *testing.T) {
+		text := results.Questions[2]
+		if text.Responses != 1 || len(text.Comments) != 1 || text.Comments[0] != "Great trainers" {
+			t.Errorf("unexpected comments %v", text.Comments)
+		}
+	})
+}
+
+func TestBuildSurveyResultsWithheld(t *testing.T) {
+	answers := []*SurveyAnswer{
+		{QuestionID: 10, Score: score(5)},
+		{QuestionID: 11, Choice: "About right"},
+		{QuestionID: 12, Text: "Great trainers"},
+		{QuestionID: 10, Score: score(3)},
+		{QuestionID: 11, Choice: "Too fast"},
+	}
+
+	results := buildSurveyResults(testSurvey(), 2, 2, answers)
+
+	likert, choice, text := results.Questions[0], results.Questions[1], results.Questions[2]
+
+	if !likert.Reportable || likert.Scores.Average == nil || likert.Scores.Distribution == nil {
+		t.Errorf("expected a question answered by the minimum to be reported, got %+v", likert.Scores)
+	}
+	if !choice.Reportable || choice.Choices["Too fast"] != 1 {
+		t.Errorf("expected the choices to be reported, got %v", choice.Choices)
+	}
+	if text.Reportable || text.Comments != nil || text.Responses != 1 {
+		t.Errorf("expected a single comment to be withheld and counted, got %d responses and %v", text.Responses, text.Comments)
+	}
+
+	results = buildSurveyResults(testSurvey(), 2, 3, answers)
+
+	for _, result := range results.Questions {
+		if result.Reportable || result.Choices != nil || result.Comments != nil {
+			t.Errorf("expected the answers to question %d to be withheld, got %+v", result.Question.ID, result)
+		}
+		if result.Scores != nil && (result.Scores.Average != nil || result.Scores.Distribution != nil) {
+			t.Errorf("expected the scores to be withheld, got %+v", result.Scores)
+		}
+	}
+}
+
+func TestLegacySurvey(t *testing.T) {
+	survey := legacySurvey("Course rating", "How would you rate this course overall?")
+	answers := []*SurveyAnswer{
+		{QuestionID: legacyScoreQuestionID, Score: score(2)},
+		{QuestionID: legacyScoreQuestionID, Score: score(4)},
+		{QuestionID: legacyCommentQuestionID, Text: "Too long"},
+	}
+
+	results := buildSurveyResults(survey, 2, 1, answers)
+
+	if !results.Survey.Legacy {
+		t.Errorf("expected a legacy survey")
+	}
+	if results.Questions[0].Scores.Responses != 2 || *results.Questions[0].Scores.Average != 3 {
+		t.Errorf("expected 2 scores averaging 3, got %+v", results.Questions[0].Scores)
+	}
+	if len(results.Questions[1].Comments) != 1 {
+		t.Errorf("expected 1 comment, got %v", results.Questions[1].Comments)
+	}
+}
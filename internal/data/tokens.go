@@ -12,6 +12,9 @@ import (
 const (
 	ScopeActivation     = "activation"
 	ScopeAuthentication = "authentication"
+	ScopeInvitation     = "invitation"
+	ScopePasswordReset  = "password-reset"
+	ScopeRefresh        = "refresh"
 )
 
 type Token struct {
@@ -20,6 +23,8 @@ type Token struct {
 	UserID    int64     `json:"-"`
 	Expiry    time.Time `json:"expiry"`
 	Scope     string    `json:"-"`
+	ClientIP  string    `json:"-"`
+	UserAgent string    `json:"-"`
 }
 
 func generateToken(userID int64, ttl time.Duration, scope string) (*Token, error) {
@@ -56,13 +61,13 @@ func (m TokenModel) New(userID int64, ttl time.Duration, scope string) (*Token,
 
 func (m TokenModel) Insert(token *Token) error {
 	query := `
-		INSERT INTO tokens (hash, user_id, expiry, scope)
-		VALUES ($1, $2, $3, $4)`
-	args := []any{token.Hash, token.UserID, token.Expiry, token.Scope}
+		INSERT INTO tokens (hash, user_id, expiry, scope, client_ip, user_agent)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+	args := []any{token.Hash, token.UserID, token.Expiry, token.Scope, token.ClientIP, token.UserAgent}
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 	_, err := m.DB.ExecContext(ctx, query, args...)
-	return err
+	return translatePgError(err)
 }
 
 func (m TokenModel) DeleteAllForUser(scope string, userID int64) error {
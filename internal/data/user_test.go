@@ -1,8 +1,11 @@
 package data
 
 import (
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/Syha-01/national-inservice-training/internal/testdb"
 	"github.com/Syha-01/national-inservice-training/internal/validator"
 )
 
@@ -207,4 +210,89 @@ func TestValidateUser(t *testing.T) {
 			t.Error("expected error on email field, but it was not found")
 		}
 	})
-}
\ No newline at end of file
+}
+
+func TestUserModelResetPassword(t *testing.T) {
+	db := testdb.Open(t)
+	users, tokens := UserModel{DB: db}, TokenModel{DB: db}
+
+	user := newTestUser(t, db, "pa55word1234", "System User")
+	mustExec(t, db, `UPDATE users SET failed_login_attempts = 5, locked_until = NOW() + INTERVAL '1 hour' WHERE id = $1`, user.ID)
+
+	reset, err := tokens.New(user.ID, time.Hour, ScopePasswordReset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tokens.New(user.ID, time.Hour, ScopeAuthentication)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tokens.New(user.ID, time.Hour, ScopeActivation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("expired token", func(t *testing.T) {
+		expired, err := generateToken(user.ID, -time.Minute, ScopePasswordReset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = tokens.Insert(expired)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = users.ResetPassword(expired.Plaintext, "n3wpa55word")
+		if !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("expected ErrRecordNotFound, got %v", err)
+		}
+	})
+
+	t.Run("token of another scope", func(t *testing.T) {
+		activation, err := tokens.New(user.ID, time.Hour, ScopeActivation)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = users.ResetPassword(activation.Plaintext, "n3wpa55word")
+		if !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("expected ErrRecordNotFound, got %v", err)
+		}
+	})
+
+	got, err := users.ResetPassword(reset.Plaintext, "n3wpa55word")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != user.ID {
+		t.Errorf("expected user %d, got %d", user.ID, got.ID)
+	}
+
+	stored, err := users.GetByEmail(user.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if matches, err := stored.Password.Matches("n3wpa55word"); err != nil || !matches {
+		t.Errorf("expected the new password to be set, got %t, %v", matches, err)
+	}
+
+	if n := queryInt(t, db, `SELECT failed_login_attempts FROM users WHERE id = $1 AND locked_until IS NULL`, user.ID); n != 0 {
+		t.Errorf("expected the account to be unlocked, got %d failed attempts", n)
+	}
+
+	for scope, want := range map[string]int64{
+		ScopePasswordReset:  0,
+		ScopeAuthentication: 0,
+		ScopeActivation:     2,
+	} {
+		n := queryInt(t, db, `SELECT COUNT(*) FROM tokens WHERE user_id = $1 AND scope = $2`, user.ID, scope)
+		if n != want {
+			t.Errorf("expected %d %s tokens, got %d", want, scope, n)
+		}
+	}
+
+	_, err = users.ResetPassword(reset.Plaintext, "an0therpa55word")
+	if !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected the token to be single use, got %v", err)
+	}
+}
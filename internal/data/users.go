@@ -7,12 +7,12 @@ import (
 	"errors"
 	"time"
 
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 )
 
 var (
-	ErrDuplicateEmail = errors.New("duplicate email")
-	AnonymousUser     = &User{}
+	AnonymousUser = &User{}
 )
 
 type User struct {
@@ -23,6 +23,9 @@ type User struct {
 	Activated   bool          `json:"activated"`
 	PersonnelID sql.NullInt64 `json:"personnel_id,omitempty"`
 	RoleID      int           `json:"role_id"` // Add this
+	// FacilitatorID links the account to a facilitator, giving access to the
+	// rosters, attendance and outcomes of the sessions they are assigned to.
+	FacilitatorID NullInt64 `json:"facilitator_id,omitempty"`
 }
 
 // IsAnonymous checks if a User instance is the AnonymousUser
@@ -66,30 +69,25 @@ type UserModel struct {
 
 func (m UserModel) Insert(user *User) error {
 query := `
-	INSERT INTO users (email, password_hash, activated, personnel_id, role_id)
-	VALUES ($1, $2, $3, $4, $5)
+	INSERT INTO users (email, password_hash, activated, personnel_id, role_id, facilitator_id)
+	VALUES ($1, $2, $3, $4, $5, $6)
 	RETURNING id, created_at`
 
-	args := []any{user.Email, user.Password.hash, user.Activated, user.PersonnelID, user.RoleID}
+	args := []any{user.Email, user.Password.hash, user.Activated, user.PersonnelID, user.RoleID, sql.NullInt64(user.FacilitatorID)}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
 	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&user.ID, &user.CreatedAt)
 	if err != nil {
-		switch {
-		case err.Error() == `pq: duplicate key value violates unique constraint "users_email_key"`:
-			return ErrDuplicateEmail
-		default:
-			return err
-		}
+		return translatePgError(err)
 	}
 	return nil
 }
 
 func (m UserModel) GetByEmail(email string) (*User, error) {
 query := `
-	SELECT id, created_at, email, password_hash, activated, personnel_id, role_id
+	SELECT id, created_at, email, password_hash, activated, personnel_id, role_id, facilitator_id
 	FROM users
 	WHERE email = $1`
 
@@ -106,6 +104,46 @@ query := `
 		&user.Activated,
 		&user.PersonnelID,
 		&user.RoleID, // Add this
+		(*sql.NullInt64)(&user.FacilitatorID),
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &user, nil
+}
+
+// Get retrieves a specific user by ID.
+func (m UserModel) Get(id int64) (*User, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT id, created_at, email, password_hash, activated, personnel_id, role_id, facilitator_id
+		FROM users
+		WHERE id = $1`
+
+	var user User
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&user.ID,
+		&user.CreatedAt,
+		&user.Email,
+		&user.Password.hash,
+		&user.Activated,
+		&user.PersonnelID,
+		&user.RoleID,
+		(*sql.NullInt64)(&user.FacilitatorID),
 	)
 
 	if err != nil {
@@ -123,8 +161,8 @@ query := `
 func (m UserModel) Update(user *User) error {
 query := `
 	UPDATE users
-	SET email = $1, password_hash = $2, activated = $3, personnel_id = $4, role_id = $5
-	WHERE id = $6
+	SET email = $1, password_hash = $2, activated = $3, personnel_id = $4, role_id = $5, facilitator_id = $6
+	WHERE id = $7
 	RETURNING id`
 
 
@@ -134,6 +172,7 @@ query := `
 		user.Activated,
 		user.PersonnelID,
 		user.RoleID, // Add this
+		sql.NullInt64(user.FacilitatorID),
 		user.ID,
 	}
 
@@ -143,12 +182,10 @@ query := `
 	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&user.ID)
 	if err != nil {
 		switch {
-		case err.Error() == `pq: duplicate key value violates unique constraint "users_email_key"`:
-			return ErrDuplicateEmail
 		case errors.Is(err, sql.ErrNoRows):
 			return ErrEditConflict
 		default:
-			return err
+			return translatePgError(err)
 		}
 	}
 
@@ -159,7 +196,7 @@ func (m UserModel) GetForToken(tokenScope, tokenPlaintext string) (*User, error)
 	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
 
 	query := `
-		SELECT users.id, users.created_at, users.email, users.password_hash, users.activated, users.personnel_id, users.role_id
+		SELECT users.id, users.created_at, users.email, users.password_hash, users.activated, users.personnel_id, users.role_id, users.facilitator_id
 		FROM users
 		INNER JOIN tokens
 		ON users.id = tokens.user_id
@@ -182,6 +219,7 @@ func (m UserModel) GetForToken(tokenScope, tokenPlaintext string) (*User, error)
 		&user.Activated,
 		&user.PersonnelID,
 		&user.RoleID, // Add this
+		(*sql.NullInt64)(&user.FacilitatorID),
 	)
 
 	if err != nil {
@@ -196,3 +234,175 @@ func (m UserModel) GetForToken(tokenScope, tokenPlaintext string) (*User, error)
 	return &user, nil
 }
 
+// GetForAuthenticationToken returns the user an unexpired authentication token
+// was issued to, along with the token as a session.
+func (m UserModel) GetForAuthenticationToken(tokenPlaintext string) (*User, *Session, error) {
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+
+	query := `
+		SELECT u.id, u.created_at, u.email, u.password_hash, u.activated, u.personnel_id, u.role_id, u.facilitator_id,
+			t.family_id, t.created_at, t.last_used_at, t.expiry, t.client_ip, t.user_agent
+		FROM users u
+		INNER JOIN tokens t ON t.user_id = u.id
+		WHERE t.hash = $1 AND t.scope = $2 AND t.expiry > NOW()`
+
+	var user User
+	session := Session{Current: true}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, tokenHash[:], ScopeAuthentication).Scan(
+		&user.ID,
+		&user.CreatedAt,
+		&user.Email,
+		&user.Password.hash,
+		&user.Activated,
+		&user.PersonnelID,
+		&user.RoleID,
+		(*sql.NullInt64)(&user.FacilitatorID),
+		&session.ID,
+		&session.CreatedAt,
+		&session.LastUsedAt,
+		&session.Expiry,
+		&session.ClientIP,
+		&session.UserAgent,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, nil, ErrRecordNotFound
+		default:
+			return nil, nil, err
+		}
+	}
+
+	session.UserID = user.ID
+
+	return &user, &session, nil
+}
+
+// UnlinkOfficer removes the link between an officer and their user account.
+func (m UserModel) UnlinkOfficer(personnelID int64) error {
+	query := `
+		UPDATE users
+		SET personnel_id = NULL
+		WHERE personnel_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, personnelID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// UnlinkFacilitator removes the link between a facilitator and their user account.
+func (m UserModel) UnlinkFacilitator(facilitatorID int64) error {
+	query := `
+		UPDATE users
+		SET facilitator_id = NULL
+		WHERE facilitator_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, facilitatorID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// ResetPassword sets the password of the user a password reset token was
+// issued to. The user's password reset, authentication and refresh tokens are
+// deleted, so the token cannot be used again and every session is signed out,
+// and the account is unlocked.
+func (m UserModel) ResetPassword(tokenPlaintext, plaintextPassword string) (*User, error) {
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+
+	var user User
+
+	err := user.Password.Set(plaintextPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		SELECT u.id, u.created_at, u.email, u.activated, u.personnel_id, u.role_id, u.facilitator_id
+		FROM users u
+		INNER JOIN tokens t ON t.user_id = u.id
+		WHERE t.hash = $1 AND t.scope = $2 AND t.expiry > NOW()
+		FOR UPDATE OF u`,
+		tokenHash[:], ScopePasswordReset).Scan(
+		&user.ID,
+		&user.CreatedAt,
+		&user.Email,
+		&user.Activated,
+		&user.PersonnelID,
+		&user.RoleID,
+		(*sql.NullInt64)(&user.FacilitatorID),
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users
+		SET password_hash = $1, failed_login_attempts = 0, locked_until = NULL, updated_at = NOW()
+		WHERE id = $2`, user.Password.hash, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM tokens
+		WHERE user_id = $1 AND scope = ANY($2)`,
+		user.ID, pq.Array([]string{ScopePasswordReset, ScopeAuthentication, ScopeRefresh}))
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
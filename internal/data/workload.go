@@ -0,0 +1,196 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/Syha-01/national-inservice-training/internal/validator"
+)
+
+// WorkloadFilters restricts the workload report to sessions starting within a
+// date range, and sets how many teaching days a facilitator may take on before
+// they are flagged.
+type WorkloadFilters struct {
+	From            time.Time
+	To              time.Time
+	MaxTeachingDays int
+}
+
+// ValidateWorkloadFilters validates a WorkloadFilters struct
+func ValidateWorkloadFilters(v *validator.Validator, f WorkloadFilters) {
+	v.Check(!f.To.Before(f.From), "to", "must not be before from")
+	v.Check(f.To.Sub(f.From) <= 366*24*time.Hour, "to", "must be no more than a year after from")
+	v.Check(f.MaxTeachingDays >= 1, "max_teaching_days", "must be greater than zero")
+	v.Check(f.MaxTeachingDays <= 366, "max_teaching_days", "must be a maximum of 366")
+}
+
+// Workload totals the teaching done by one or more facilitators.
+type Workload struct {
+	Sessions        int     `json:"sessions"`
+	TeachingDays    int     `json:"teaching_days"`
+	CreditHours     float64 `json:"credit_hours"`
+	LearnersReached int     `json:"learners_reached"`
+}
+
+// FacilitatorWorkload is the teaching done by a single facilitator. OverMaximum
+// is set when their teaching days exceed the maximum for the report.
+type FacilitatorWorkload struct {
+	Facilitator *Facilitator `json:"facilitator"`
+	Workload
+	OverMaximum bool `json:"over_maximum"`
+}
+
+// FormationWorkload is the teaching done by the police facilitators of a
+// formation. LearnersReached is the sum over its facilitators, so an officer
+// taught by two of them is counted twice.
+type FormationWorkload struct {
+	Formation    *Formation `json:"formation"`
+	Facilitators int        `json:"facilitators"`
+	Workload
+}
+
+// WorkloadReport is the teaching load of every facilitator who taught a session
+// starting within the report's date range.
+type WorkloadReport struct {
+	From            time.Time              `json:"from"`
+	To              time.Time              `json:"to"`
+	MaxTeachingDays int                    `json:"max_teaching_days"`
+	OverMaximum     int                    `json:"over_maximum"`
+	Facilitators    []*FacilitatorWorkload `json:"facilitators"`
+	Formations      []*FormationWorkload   `json:"formations"`
+}
+
+// add adds other to the workload.
+func (w *Workload) add(other Workload) {
+	w.Sessions += other.Sessions
+	w.TeachingDays += other.TeachingDays
+	w.CreditHours += other.CreditHours
+	w.LearnersReached += other.LearnersReached
+}
+
+// buildWorkloadReport flags the facilitators over the maximum and totals the
+// facilitators who are police personnel by their formation.
+func buildWorkloadReport(filters WorkloadFilters, workloads []*FacilitatorWorkload, formations map[int64]*Formation) *WorkloadReport {
+	report := &WorkloadReport{
+		From:            filters.From,
+		To:              filters.To,
+		MaxTeachingDays: filters.MaxTeachingDays,
+		Facilitators:    workloads,
+		Formations:      []*FormationWorkload{},
+	}
+
+	totals := map[int64]*FormationWorkload{}
+
+	for _, workload := range workloads {
+		workload.OverMaximum = workload.TeachingDays > filters.MaxTeachingDays
+		if workload.OverMaximum {
+			report.OverMaximum++
+		}
+
+		formation, ok := formations[workload.Facilitator.ID]
+		if !ok {
+			continue
+		}
+
+		total, ok := totals[formation.ID]
+		if !ok {
+			total = &FormationWorkload{Formation: formation}
+			totals[formation.ID] = total
+			report.Formations = append(report.Formations, total)
+		}
+		total.Facilitators++
+		total.add(workload.Workload)
+	}
+
+	sort.Slice(report.Formations, func(i, j int) bool {
+		if report.Formations[i].TeachingDays != report.Formations[j].TeachingDays {
+			return report.Formations[i].TeachingDays > report.Formations[j].TeachingDays
+		}
+		return report.Formations[i].Formation.ID < report.Formations[j].Formation.ID
+	})
+
+	return report
+}
+
+// GetWorkload reports the sessions, teaching days, credit hours and learners of
+// every facilitator who taught a session starting within the date range, busiest
+// first. Learners are the distinct officers enrolled, excluding withdrawals.
+func (m FacilitatorModel) GetWorkload(filters WorkloadFilters) (*WorkloadReport, error) {
+	query := `
+		WITH taught AS (
+			SELECT sf.facilitator_id, ts.id AS session_id,
+				ts.end_date - ts.start_date + 1 AS days,
+				c.credit_hours
+			FROM session_facilitators sf
+			INNER JOIN training_sessions ts ON ts.id = sf.session_id
+			INNER JOIN courses c ON c.id = ts.course_id
+			WHERE ts.start_date BETWEEN $1 AND $2
+		)
+		SELECT ` + facilitatorColumns + `,
+			fm.id, fm.name, fm.region_id,
+			COUNT(*), SUM(t.days), SUM(t.credit_hours)::float8,
+			(
+				SELECT COUNT(DISTINCT se.personnel_id)
+				FROM session_enrollment se
+				INNER JOIN taught l ON l.session_id = se.session_id
+				WHERE l.facilitator_id = f.id AND se.status <> 'Withdrew'
+			)
+		FROM ` + facilitatorTables + `
+		INNER JOIN taught t ON t.facilitator_id = f.id
+		LEFT JOIN formations fm ON fm.id = fp.formation_id
+		GROUP BY f.id, fp.id, fm.id
+		ORDER BY SUM(t.days) DESC, f.id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, filters.From, filters.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workloads := []*FacilitatorWorkload{}
+	formations := map[int64]*Formation{}
+
+	for rows.Next() {
+		var facilitator Facilitator
+		var formationID, regionID sql.NullInt64
+		var formationName sql.NullString
+
+		workload := &FacilitatorWorkload{Facilitator: &facilitator}
+
+		dest := append(facilitator.dest(),
+			&formationID,
+			&formationName,
+			&regionID,
+			&workload.Sessions,
+			&workload.TeachingDays,
+			&workload.CreditHours,
+			&workload.LearnersReached,
+		)
+
+		err := rows.Scan(dest...)
+		if err != nil {
+			return nil, err
+		}
+
+		if formationID.Valid {
+			formations[facilitator.ID] = &Formation{
+				ID:       formationID.Int64,
+				Name:     formationName.String,
+				RegionID: regionID.Int64,
+			}
+		}
+
+		workloads = append(workloads, workload)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return buildWorkloadReport(filters, workloads, formations), nil
+}
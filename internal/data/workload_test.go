@@ -0,0 +1,47 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildWorkloadReport(t *testing.T) {
+	filters := WorkloadFilters{
+		From:            time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:              time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC),
+		MaxTeachingDays: 10,
+	}
+
+	workloads := []*FacilitatorWorkload{
+		{Facilitator: &Facilitator{ID: 1}, Workload: Workload{Sessions: 3, TeachingDays: 12, CreditHours: 24, LearnersReached: 40}},
+		{Facilitator: &Facilitator{ID: 2}, Workload: Workload{Sessions: 2, TeachingDays: 6, CreditHours: 8, LearnersReached: 15}},
+		{Facilitator: &Facilitator{ID: 3}, Workload: Workload{Sessions: 1, TeachingDays: 2, CreditHours: 4, LearnersReached: 10}},
+	}
+
+	// Facilitators 1 and 2 are officers of the same formation, 3 is a guest
+	formations := map[int64]*Formation{
+		1: {ID: 5, Name: "Precinct 1"},
+		2: {ID: 5, Name: "Precinct 1"},
+	}
+
+	report := buildWorkloadReport(filters, workloads, formations)
+
+	t.Run("facilitators over the maximum are flagged", func(t *testing.T) {
+		if !report.Facilitators[0].OverMaximum || report.Facilitators[1].OverMaximum {
+			t.Errorf("expected only facilitator 1 to be over the maximum")
+		}
+		if report.OverMaximum != 1 {
+			t.Errorf("expected 1 facilitator over the maximum, got %d", report.OverMaximum)
+		}
+	})
+
+	t.Run("police facilitators are totalled by formation", func(t *testing.T) {
+		if len(report.Formations) != 1 {
+			t.Fatalf("expected 1 formation, got %d", len(report.Formations))
+		}
+		total := report.Formations[0]
+		if total.Facilitators != 2 || total.TeachingDays != 18 || total.CreditHours != 32 || total.LearnersReached != 55 {
+			t.Errorf("unexpected formation totals %+v", total)
+		}
+	})
+}
@@ -0,0 +1,107 @@
+// Package testdb provides a migrated PostgreSQL database for tests which need
+// one. Tests using it are skipped unless TRAINING_TEST_DB_DSN names a database
+// they may create schemas in.
+package testdb
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+
+	_ "github.com/lib/pq"
+)
+
+// EnvDSN is the environment variable holding the test database DSN.
+const EnvDSN = "TRAINING_TEST_DB_DSN"
+
+// Open creates a new schema in the test database, applies the migrations to it
+// and returns a connection pool which uses it. The schema is dropped when the
+// test finishes.
+func Open(t testing.TB) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv(EnvDSN)
+	if dsn == "" {
+		t.Skipf("%s is not set", EnvDSN)
+	}
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	suffix := make([]byte, 6)
+	rand.Read(suffix)
+	schema := "test_" + hex.EncodeToString(suffix)
+
+	_, err = admin.Exec(`CREATE SCHEMA ` + schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_, err := admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`)
+		if err != nil {
+			t.Errorf("dropping schema %s: %v", schema, err)
+		}
+	})
+
+	db, err := sql.Open("postgres", withSearchPath(dsn, schema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	for _, file := range migrations(t) {
+		script, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = db.Exec(string(script))
+		if err != nil {
+			t.Fatalf("applying %s: %v", filepath.Base(file), err)
+		}
+	}
+
+	return db
+}
+
+// withSearchPath adds the schema search path to a DSN in either URL or
+// keyword/value form. The driver passes it on as a connection parameter.
+func withSearchPath(dsn, schema string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		if strings.Contains(dsn, "?") {
+			return dsn + "&search_path=" + schema
+		}
+		return dsn + "?search_path=" + schema
+	}
+	return dsn + " search_path=" + schema
+}
+
+// migrations returns the up migrations in the order they are applied.
+func migrations(t testing.TB) []string {
+	t.Helper()
+
+	_, file, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal("cannot locate the migrations directory")
+	}
+
+	files, err := filepath.Glob(filepath.Join(filepath.Dir(file), "..", "..", "migrations", "*.up.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no migrations found")
+	}
+
+	sort.Strings(files)
+	return files
+}
@@ -0,0 +1,162 @@
+package textanalysis
+
+// lexicon scores words by the sentiment they carry in training feedback, from
+// -3 (very negative) to 3 (very positive). Words not listed are neutral.
+var lexicon = map[string]float64{
+	// positive
+	"amazing":       3,
+	"excellent":     3,
+	"outstanding":   3,
+	"superb":        3,
+	"brilliant":     3,
+	"fantastic":     3,
+	"perfect":       3,
+	"best":          3,
+	"great":         2,
+	"good":          2,
+	"useful":        2,
+	"helpful":       2,
+	"informative":   2,
+	"engaging":      2,
+	"interesting":   2,
+	"knowledgeable": 2,
+	"clear":         2,
+	"enjoyed":       2,
+	"enjoyable":     2,
+	"relevant":      2,
+	"practical":     2,
+	"valuable":      2,
+	"professional":  2,
+	"recommend":     2,
+	"effective":     2,
+	"well":          1,
+	"organised":     2,
+	"organized":     2,
+	"friendly":      2,
+	"patient":       2,
+	"thank":         2,
+	"thanks":        2,
+	"love":          3,
+	"loved":         3,
+	"liked":         2,
+	"like":          1,
+	"nice":          1,
+	"fine":          1,
+	"comfortable":   2,
+	"improved":      2,
+	"learned":       1,
+	"learnt":        1,
+	"insightful":    2,
+	"motivating":    2,
+	"motivated":     2,
+	"fun":           2,
+	"happy":         2,
+	"satisfied":     2,
+	"adequate":      1,
+	"ok":            1,
+	"okay":          1,
+
+	// negative
+	"awful":         -3,
+	"terrible":      -3,
+	"horrible":      -3,
+	"worst":         -3,
+	"useless":       -3,
+	"waste":         -3,
+	"bad":           -2,
+	"poor":          -2,
+	"boring":        -2,
+	"confusing":     -2,
+	"unclear":       -2,
+	"irrelevant":    -2,
+	"disorganised":  -2,
+	"disorganized":  -2,
+	"rushed":        -2,
+	"late":          -1,
+	"difficult":     -1,
+	"hard":          -1,
+	"hot":           -1,
+	"cold":          -1,
+	"noisy":         -2,
+	"crowded":       -2,
+	"cramped":       -2,
+	"uncomfortable": -2,
+	"dirty":         -2,
+	"broken":        -2,
+	"tired":         -1,
+	"tiring":        -1,
+	"repetitive":    -2,
+	"outdated":      -2,
+	"slow":          -1,
+	"lacking":       -2,
+	"lack":          -2,
+	"missing":       -1,
+	"problem":       -2,
+	"problems":      -2,
+	"issue":         -1,
+	"issues":        -1,
+	"disappointed":  -2,
+	"disappointing": -2,
+	"unprepared":    -2,
+	"rude":          -3,
+	"unhelpful":     -2,
+	"dull":          -2,
+	"complaint":     -2,
+	"hate":          -3,
+	"hated":         -3,
+	"dislike":       -2,
+	"disliked":      -2,
+	"frustrating":   -2,
+	"frustrated":    -2,
+	"wasted":        -3,
+	"inadequate":    -2,
+	"insufficient":  -2,
+}
+
+// negations reverse the sentiment of the words which follow them.
+var negations = map[string]bool{
+	"not":     true,
+	"no":      true,
+	"never":   true,
+	"nothing": true,
+	"neither": true,
+	"nor":     true,
+	"hardly":  true,
+	"without": true,
+}
+
+// intensifiers strengthen the sentiment of the word which follows them.
+var intensifiers = map[string]float64{
+	"very":       1.5,
+	"really":     1.5,
+	"extremely":  2,
+	"so":         1.3,
+	"quite":      1.2,
+	"highly":     1.5,
+	"incredibly": 2,
+	"totally":    1.5,
+}
+
+// stopwords carry no theme of their own. A keyword may contain them but not
+// begin or end with one. Words such as "too", "not" and "very" are kept out of
+// this list because they are what make phrases like "too long" a theme.
+var stopwords = map[string]bool{
+	"a": true, "about": true, "after": true, "all": true, "also": true, "am": true,
+	"an": true, "and": true, "any": true, "are": true, "as": true, "at": true,
+	"be": true, "because": true, "been": true, "before": true, "being": true,
+	"but": true, "by": true, "can": true, "could": true,
+	"did": true, "do": true, "does": true, "during": true, "each": true,
+	"for": true, "from": true, "had": true, "has": true, "have": true,
+	"he": true, "her": true, "here": true, "him": true, "his": true, "how": true,
+	"i": true, "if": true, "in": true, "into": true, "is": true, "it": true,
+	"its": true, "it's": true, "i'm": true, "i've": true, "just": true, "me": true,
+	"more": true, "most": true, "my": true, "of": true, "on": true, "one": true,
+	"or": true, "other": true, "our": true, "out": true, "over": true,
+	"she": true, "should": true, "some": true, "such": true, "than": true,
+	"that": true, "the": true, "their": true, "them": true, "then": true,
+	"there": true, "these": true, "they": true, "this": true, "those": true,
+	"to": true, "up": true, "us": true, "was": true, "we": true, "were": true,
+	"what": true, "when": true, "where": true, "which": true, "while": true,
+	"who": true, "will": true, "with": true, "would": true, "you": true,
+	"your": true,
+}
@@ -0,0 +1,270 @@
+// Package textanalysis scores the sentiment of free-text feedback and finds
+// the themes which recur across it. It uses a built-in lexicon and needs no
+// external service.
+package textanalysis
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Sentiment labels, decided by a comment's score.
+const (
+	Positive = "positive"
+	Neutral  = "neutral"
+	Negative = "negative"
+)
+
+// neutralBand is how far either side of zero a score may be and still be neutral.
+const neutralBand = 0.05
+
+// maxPhraseWords is the longest phrase reported as a keyword.
+const maxPhraseWords = 3
+
+// Keyword is a word or phrase which recurs across comments. Comments is the
+// number of comments it appears in and Sentiment their average score.
+type Keyword struct {
+	Term      string  `json:"term"`
+	Comments  int     `json:"comments"`
+	Sentiment float64 `json:"sentiment"`
+}
+
+// Analysis summarises a set of comments.
+type Analysis struct {
+	Comments  int       `json:"comments"`
+	Sentiment float64   `json:"sentiment"`
+	Positive  int       `json:"positive"`
+	Neutral   int       `json:"neutral"`
+	Negative  int       `json:"negative"`
+	Keywords  []Keyword `json:"keywords"`
+}
+
+// Tokenize splits text into lower case words. Apostrophes are kept inside
+// words so contractions such as "didn't" stay whole.
+func Tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '’'
+	})
+
+	tokens := make([]string, 0, len(fields))
+	for _, field := range fields {
+		field = strings.ReplaceAll(field, "’", "'")
+		field = strings.Trim(field, "'")
+		if field != "" {
+			tokens = append(tokens, field)
+		}
+	}
+
+	return tokens
+}
+
+// isNegation reports whether a word reverses the sentiment of those after it.
+func isNegation(word string) bool {
+	return negations[word] || strings.HasSuffix(word, "n't")
+}
+
+// Sentiment scores text from -1 (negative) to 1 (positive). A negation reverses
+// the next three words, an intensifier strengthens the next word, and "too"
+// makes the word after it a complaint, as in "too long" or "too hot".
+func Sentiment(text string) float64 {
+	tokens := Tokenize(text)
+
+	var total float64
+	negated := 0
+	boost := 1.0
+
+	for i, token := range tokens {
+		score, scored := lexicon[token]
+
+		// "long" is neutral but "too long" is a complaint
+		if token == "too" && i+1 < len(tokens) && lexicon[tokens[i+1]] == 0 {
+			score, scored = -1, true
+		}
+
+		if scored {
+			score *= boost
+			if negated > 0 {
+				score = -score / 2
+			}
+			total += score
+		}
+
+		boost = 1.0
+		if factor, ok := intensifiers[token]; ok {
+			boost = factor
+		}
+
+		switch {
+		case isNegation(token):
+			negated = 3
+		case negated > 0:
+			negated--
+		}
+	}
+
+	// Normalise so long comments do not score without bound
+	return total / math.Sqrt(total*total+15)
+}
+
+// Label names the sentiment of a score.
+func Label(score float64) string {
+	switch {
+	case score >= neutralBand:
+		return Positive
+	case score <= -neutralBand:
+		return Negative
+	default:
+		return Neutral
+	}
+}
+
+// phrases returns the distinct words and phrases of up to maxPhraseWords words
+// in text which do not begin or end with a stopword.
+func phrases(text string) []string {
+	tokens := Tokenize(text)
+
+	seen := map[string]bool{}
+	found := []string{}
+
+	for i := range tokens {
+		if stopwords[tokens[i]] || len(tokens[i]) < 2 {
+			continue
+		}
+		for n := 1; n <= maxPhraseWords && i+n <= len(tokens); n++ {
+			last := tokens[i+n-1]
+			if stopwords[last] || len(last) < 2 {
+				continue
+			}
+			// A lone modifier such as "too" or "very" is not a theme
+			if n == 1 && (tokens[i] == "too" || intensifiers[tokens[i]] != 0 || isNegation(tokens[i])) {
+				continue
+			}
+			phrase := strings.Join(tokens[i:i+n], " ")
+			if !seen[phrase] {
+				seen[phrase] = true
+				found = append(found, phrase)
+			}
+		}
+	}
+
+	return found
+}
+
+// containsPhrase reports whether phrase occurs as whole words within longer.
+func containsPhrase(longer, phrase string) bool {
+	return strings.Contains(" "+longer+" ", " "+phrase+" ")
+}
+
+// Keywords returns up to limit words and phrases which appear in at least
+// minComments comments, most common first. A term is left out when a longer
+// phrase containing it appears in just as many comments, so "too long" is
+// reported rather than "long" as well.
+func Keywords(comments []string, minComments, limit int) []Keyword {
+	counts := map[string]int{}
+	totals := map[string]float64{}
+
+	for _, comment := range comments {
+		score := Sentiment(comment)
+		for _, phrase := range phrases(comment) {
+			counts[phrase]++
+			totals[phrase] += score
+		}
+	}
+
+	candidates := []Keyword{}
+	for term, count := range counts {
+		if count >= minComments {
+			candidates = append(candidates, Keyword{Term: term, Comments: count, Sentiment: totals[term] / float64(count)})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Comments != candidates[j].Comments {
+			return candidates[i].Comments > candidates[j].Comments
+		}
+		wi, wj := strings.Count(candidates[i].Term, " "), strings.Count(candidates[j].Term, " ")
+		if wi != wj {
+			return wi > wj
+		}
+		return candidates[i].Term < candidates[j].Term
+	})
+
+	keywords := []Keyword{}
+	for _, candidate := range candidates {
+		subsumed := false
+		for _, kept := range keywords {
+			if kept.Comments == candidate.Comments && containsPhrase(kept.Term, candidate.Term) {
+				subsumed = true
+				break
+			}
+		}
+		if subsumed {
+			continue
+		}
+
+		keywords = append(keywords, candidate)
+		if len(keywords) == limit {
+			break
+		}
+	}
+
+	return keywords
+}
+
+// Analyse scores every comment and finds up to limit keywords which recur in at
+// least minComments of them. Empty comments are ignored.
+func Analyse(comments []string, minComments, limit int) Analysis {
+	analysis := Analysis{Keywords: []Keyword{}}
+
+	texts := []string{}
+	var total float64
+
+	for _, comment := range comments {
+		if strings.TrimSpace(comment) == "" {
+			continue
+		}
+		texts = append(texts, comment)
+
+		score := Sentiment(comment)
+		total += score
+
+		switch Label(score) {
+		case Positive:
+			analysis.Positive++
+		case Negative:
+			analysis.Negative++
+		default:
+			analysis.Neutral++
+		}
+	}
+
+	analysis.Comments = len(texts)
+	if analysis.Comments > 0 {
+		analysis.Sentiment = total / float64(analysis.Comments)
+		analysis.Keywords = Keywords(texts, minComments, limit)
+	}
+
+	return analysis
+}
+
+// Matches returns the terms found in text as whole words, ignoring case and
+// punctuation, so "idiot" matches "Idiot!" but not "idiotic". A term may be a
+// phrase of several words.
+func Matches(text string, terms []string) []string {
+	tokens := " " + strings.Join(Tokenize(text), " ") + " "
+
+	matched := []string{}
+	for _, term := range terms {
+		words := Tokenize(term)
+		if len(words) == 0 {
+			continue
+		}
+		if strings.Contains(tokens, " "+strings.Join(words, " ")+" ") {
+			matched = append(matched, term)
+		}
+	}
+
+	return matched
+}
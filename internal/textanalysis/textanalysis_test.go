@@ -0,0 +1,127 @@
+package textanalysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("The venue was TOO hot; didn't enjoy it’s 'layout'.")
+	want := []string{"the", "venue", "was", "too", "hot", "didn't", "enjoy", "it's", "layout"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestSentiment(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Excellent facilitator, very knowledgeable and engaging", Positive},
+		{"The course was boring and the venue too hot", Negative},
+		{"Far too long", Negative},
+		{"The course was not useful", Negative},
+		{"Wasn't bad at all", Positive},
+		{"We covered chapter four", Neutral},
+		{"", Neutral},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			score := Sentiment(tt.text)
+			if got := Label(score); got != tt.want {
+				t.Errorf("expected %s, got %s (%.2f)", tt.want, got, score)
+			}
+			if score < -1 || score > 1 {
+				t.Errorf("expected a score between -1 and 1, got %.2f", score)
+			}
+		})
+	}
+}
+
+func TestSentimentIntensifiers(t *testing.T) {
+	if Sentiment("very good") <= Sentiment("good") {
+		t.Errorf("expected an intensifier to strengthen the score")
+	}
+}
+
+func TestKeywords(t *testing.T) {
+	comments := []string{
+		"The session was too long.",
+		"Too long, and the venue too hot",
+		"Venue too hot to concentrate, too long as well",
+		"Great facilitator",
+	}
+
+	keywords := Keywords(comments, 2, 10)
+
+	terms := map[string]int{}
+	for _, keyword := range keywords {
+		terms[keyword.Term] = keyword.Comments
+	}
+
+	if terms["too long"] != 3 {
+		t.Errorf("expected \"too long\" in 3 comments, got %v", keywords)
+	}
+	if terms["venue too hot"] != 2 {
+		t.Errorf("expected \"venue too hot\" in 2 comments, got %v", keywords)
+	}
+	if _, ok := terms["long"]; ok {
+		t.Errorf("expected \"long\" to be subsumed by \"too long\", got %v", keywords)
+	}
+	if _, ok := terms["facilitator"]; ok {
+		t.Errorf("expected terms in a single comment to be left out, got %v", keywords)
+	}
+	if keywords[0].Term != "too long" {
+		t.Errorf("expected the most common theme first, got %q", keywords[0].Term)
+	}
+	if keywords[0].Sentiment >= 0 {
+		t.Errorf("expected \"too long\" to be negative, got %.2f", keywords[0].Sentiment)
+	}
+}
+
+func TestKeywordsLimit(t *testing.T) {
+	comments := []string{"alpha beta gamma", "alpha beta gamma"}
+
+	if got := len(Keywords(comments, 1, 1)); got != 1 {
+		t.Errorf("expected 1 keyword, got %d", got)
+	}
+}
+
+func TestAnalyse(t *testing.T) {
+	analysis := Analyse([]string{"Excellent course", "Too long", "  ", "We met at nine"}, 2, 10)
+
+	if analysis.Comments != 3 {
+		t.Errorf("expected empty comments to be ignored, got %d comments", analysis.Comments)
+	}
+	if analysis.Positive != 1 || analysis.Negative != 1 || analysis.Neutral != 1 {
+		t.Errorf("expected one comment of each sentiment, got %+v", analysis)
+	}
+	if len(analysis.Keywords) != 0 {
+		t.Errorf("expected no recurring keywords, got %v", analysis.Keywords)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	terms := []string{"idiot", "waste of time", "  "}
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"The trainer is an Idiot!", []string{"idiot"}},
+		{"Idiotic scheduling", []string{}},
+		{"A complete WASTE of time, idiot.", []string{"idiot", "waste of time"}},
+		{"Wasted time", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := Matches(tt.text, terms); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
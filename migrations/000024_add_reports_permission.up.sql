@@ -0,0 +1,9 @@
+INSERT INTO permissions (code, description) VALUES
+    ('reports:read', 'View training reports and statistics')
+ON CONFLICT (code) DO NOTHING;
+
+-- Administrators and Content Contributors can view reports
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT role_id, (SELECT id FROM permissions WHERE code = 'reports:read')
+FROM (VALUES (1), (2)) AS r(role_id)
+ON CONFLICT DO NOTHING;
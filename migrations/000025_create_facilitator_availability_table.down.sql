@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS facilitator_availability;
+ALTER TABLE facilitators DROP COLUMN IF EXISTS max_sessions_per_month;
@@ -0,0 +1,17 @@
+ALTER TABLE facilitators
+    ADD COLUMN IF NOT EXISTS max_sessions_per_month integer NOT NULL DEFAULT 4
+    CHECK (max_sessions_per_month >= 0);
+
+CREATE TABLE IF NOT EXISTS facilitator_availability (
+    id bigserial PRIMARY KEY,
+    facilitator_id integer NOT NULL REFERENCES facilitators(id) ON DELETE CASCADE,
+    kind text NOT NULL CHECK (kind IN ('available', 'blackout')),
+    starts_on date NOT NULL,
+    ends_on date NOT NULL,
+    note text NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    CONSTRAINT facilitator_availability_dates_check CHECK (ends_on >= starts_on)
+);
+
+CREATE INDEX IF NOT EXISTS facilitator_availability_facilitator_id_idx
+    ON facilitator_availability (facilitator_id, starts_on, ends_on);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS facilitator_assignment_overrides;
+DROP TABLE IF EXISTS facilitator_qualifications;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS facilitator_qualifications (
+    id bigserial PRIMARY KEY,
+    facilitator_id integer NOT NULL REFERENCES facilitators(id) ON DELETE CASCADE,
+    course_id integer NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    qualified_since date NOT NULL,
+    expires_on date,
+    evidence_document text NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    version integer NOT NULL DEFAULT 1,
+    CONSTRAINT facilitator_qualifications_facilitator_id_course_id_key UNIQUE (facilitator_id, course_id),
+    CONSTRAINT facilitator_qualifications_dates_check CHECK (expires_on IS NULL OR expires_on >= qualified_since)
+);
+
+CREATE INDEX IF NOT EXISTS facilitator_qualifications_course_id_idx
+    ON facilitator_qualifications (course_id);
+
+-- Every assignment of a facilitator without a valid qualification is recorded
+CREATE TABLE IF NOT EXISTS facilitator_assignment_overrides (
+    id bigserial PRIMARY KEY,
+    session_id integer NOT NULL REFERENCES training_sessions(id) ON DELETE CASCADE,
+    facilitator_id integer NOT NULL REFERENCES facilitators(id) ON DELETE CASCADE,
+    user_id bigint REFERENCES users(id) ON DELETE SET NULL,
+    reason text NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS session_attendance;
+ALTER TABLE session_enrollment DROP COLUMN IF EXISTS grade;
+ALTER TABLE users DROP COLUMN IF EXISTS facilitator_id;
@@ -0,0 +1,18 @@
+-- A user account may belong to a facilitator, giving them access to their own sessions
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS facilitator_id integer UNIQUE REFERENCES facilitators(id) ON DELETE SET NULL;
+
+-- The outcome awarded by the facilitator, e.g. "Distinction" or "78%"
+ALTER TABLE session_enrollment
+    ADD COLUMN IF NOT EXISTS grade varchar(20);
+
+CREATE TABLE IF NOT EXISTS session_attendance (
+    id bigserial PRIMARY KEY,
+    session_enrollment_id integer NOT NULL REFERENCES session_enrollment(id) ON DELETE CASCADE,
+    attended_on date NOT NULL,
+    present boolean NOT NULL,
+    note text NOT NULL DEFAULT '',
+    recorded_by bigint REFERENCES users(id) ON DELETE SET NULL,
+    recorded_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    CONSTRAINT session_attendance_session_enrollment_id_attended_on_key UNIQUE (session_enrollment_id, attended_on)
+);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS facilitator_invitations;
+DELETE FROM tokens WHERE scope = 'invitation';
+UPDATE users SET role_id = (SELECT id FROM roles WHERE name = 'System User')
+WHERE role_id = (SELECT id FROM roles WHERE name = 'Facilitator');
+DELETE FROM roles WHERE name = 'Facilitator';
@@ -0,0 +1,27 @@
+-- Invited facilitators sign in with the Facilitator role, which reaches their
+-- own sessions through session_facilitators rather than blanket permissions
+INSERT INTO roles (name) VALUES ('Facilitator')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.name = 'Facilitator' AND p.code IN ('courses:read', 'facilitators:read')
+ON CONFLICT DO NOTHING;
+
+-- Each invitation creates or reuses a pending user account linked to the
+-- facilitator, and is accepted with a token in the invitation scope
+CREATE TABLE IF NOT EXISTS facilitator_invitations (
+    id bigserial PRIMARY KEY,
+    facilitator_id integer NOT NULL REFERENCES facilitators(id) ON DELETE CASCADE,
+    user_id bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email varchar(255) NOT NULL,
+    invited_by bigint REFERENCES users(id) ON DELETE SET NULL,
+    expires_at timestamp(0) with time zone NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    accepted_at timestamp(0) with time zone,
+    revoked_at timestamp(0) with time zone
+);
+
+CREATE INDEX IF NOT EXISTS facilitator_invitations_facilitator_id_idx ON facilitator_invitations(facilitator_id);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS survey_answers;
+DROP TABLE IF EXISTS survey_submissions;
+ALTER TABLE courses DROP COLUMN IF EXISTS survey_id;
+DROP TABLE IF EXISTS survey_questions;
+DROP TABLE IF EXISTS surveys;
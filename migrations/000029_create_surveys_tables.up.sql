@@ -0,0 +1,42 @@
+-- Survey templates hold the questions of an evaluation form and are attached to courses
+CREATE TABLE IF NOT EXISTS surveys (
+    id bigserial PRIMARY KEY,
+    title varchar(200) NOT NULL,
+    description text NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    version integer NOT NULL DEFAULT 1
+);
+
+CREATE TABLE IF NOT EXISTS survey_questions (
+    id bigserial PRIMARY KEY,
+    survey_id bigint NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    position integer NOT NULL,
+    kind text NOT NULL,
+    prompt text NOT NULL,
+    options text[] NOT NULL DEFAULT '{}',
+    required boolean NOT NULL DEFAULT true,
+    CONSTRAINT survey_questions_kind_check CHECK (kind IN ('likert', 'multiple_choice', 'free_text')),
+    CONSTRAINT survey_questions_survey_id_position_key UNIQUE (survey_id, position)
+);
+
+ALTER TABLE courses
+    ADD COLUMN IF NOT EXISTS survey_id bigint REFERENCES surveys(id) ON DELETE SET NULL;
+
+-- A survey cannot be deleted once it has been answered
+CREATE TABLE IF NOT EXISTS survey_submissions (
+    id bigserial PRIMARY KEY,
+    survey_id bigint NOT NULL REFERENCES surveys(id) ON DELETE RESTRICT,
+    session_enrollment_id integer NOT NULL REFERENCES session_enrollment(id) ON DELETE CASCADE,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    CONSTRAINT survey_submissions_survey_id_session_enrollment_id_key UNIQUE (survey_id, session_enrollment_id)
+);
+
+CREATE TABLE IF NOT EXISTS survey_answers (
+    id bigserial PRIMARY KEY,
+    submission_id bigint NOT NULL REFERENCES survey_submissions(id) ON DELETE CASCADE,
+    question_id bigint NOT NULL REFERENCES survey_questions(id) ON DELETE CASCADE,
+    score smallint CHECK (score BETWEEN 1 AND 5),
+    choice text,
+    answer_text text,
+    CONSTRAINT survey_answers_submission_id_question_id_key UNIQUE (submission_id, question_id)
+);
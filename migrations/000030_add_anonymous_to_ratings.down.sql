@@ -0,0 +1,5 @@
+ALTER TABLE facilitator_ratings
+    DROP COLUMN IF EXISTS anonymous;
+
+ALTER TABLE course_ratings
+    DROP COLUMN IF EXISTS anonymous;
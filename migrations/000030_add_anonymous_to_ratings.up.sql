@@ -0,0 +1,7 @@
+-- Anonymous ratings keep their enrollment only to prevent duplicates and check
+-- eligibility; it is never returned when they are listed
+ALTER TABLE course_ratings
+    ADD COLUMN IF NOT EXISTS anonymous boolean NOT NULL DEFAULT false;
+
+ALTER TABLE facilitator_ratings
+    ADD COLUMN IF NOT EXISTS anonymous boolean NOT NULL DEFAULT false;
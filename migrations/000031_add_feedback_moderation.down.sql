@@ -0,0 +1,18 @@
+DELETE FROM permissions WHERE code = 'feedback:moderate';
+
+DROP INDEX IF EXISTS facilitator_ratings_pending_idx;
+DROP INDEX IF EXISTS course_ratings_pending_idx;
+
+ALTER TABLE facilitator_ratings
+    DROP CONSTRAINT IF EXISTS facilitator_ratings_status_check,
+    DROP COLUMN IF EXISTS moderated_at,
+    DROP COLUMN IF EXISTS moderated_by,
+    DROP COLUMN IF EXISTS moderation_reason,
+    DROP COLUMN IF EXISTS status;
+
+ALTER TABLE course_ratings
+    DROP CONSTRAINT IF EXISTS course_ratings_status_check,
+    DROP COLUMN IF EXISTS moderated_at,
+    DROP COLUMN IF EXISTS moderated_by,
+    DROP COLUMN IF EXISTS moderation_reason,
+    DROP COLUMN IF EXISTS status;
@@ -0,0 +1,28 @@
+-- Ratings are published straight away unless their comment is held for a
+-- moderator, who can publish or hide it
+ALTER TABLE course_ratings
+    ADD COLUMN IF NOT EXISTS status text NOT NULL DEFAULT 'published',
+    ADD COLUMN IF NOT EXISTS moderation_reason text NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS moderated_by bigint REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS moderated_at timestamp(0) with time zone,
+    ADD CONSTRAINT course_ratings_status_check CHECK (status IN ('pending', 'published', 'hidden'));
+
+ALTER TABLE facilitator_ratings
+    ADD COLUMN IF NOT EXISTS status text NOT NULL DEFAULT 'published',
+    ADD COLUMN IF NOT EXISTS moderation_reason text NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS moderated_by bigint REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS moderated_at timestamp(0) with time zone,
+    ADD CONSTRAINT facilitator_ratings_status_check CHECK (status IN ('pending', 'published', 'hidden'));
+
+CREATE INDEX IF NOT EXISTS course_ratings_pending_idx ON course_ratings(created_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS facilitator_ratings_pending_idx ON facilitator_ratings(created_at) WHERE status = 'pending';
+
+INSERT INTO permissions (code, description) VALUES
+    ('feedback:moderate', 'Moderate feedback and see unpublished feedback')
+ON CONFLICT (code) DO NOTHING;
+
+-- Administrators (role_id = 1) moderate feedback
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT 1, id FROM permissions
+WHERE code = 'feedback:moderate'
+ON CONFLICT DO NOTHING;
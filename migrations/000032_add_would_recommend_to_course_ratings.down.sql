@@ -0,0 +1,2 @@
+ALTER TABLE course_ratings
+    DROP COLUMN IF EXISTS would_recommend;
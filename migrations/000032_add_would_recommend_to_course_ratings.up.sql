@@ -0,0 +1,4 @@
+-- How likely the officer is to recommend the course, from 0 to 10, from which
+-- a Net Promoter Score is worked out
+ALTER TABLE course_ratings
+    ADD COLUMN IF NOT EXISTS would_recommend smallint CHECK (would_recommend BETWEEN 0 AND 10);
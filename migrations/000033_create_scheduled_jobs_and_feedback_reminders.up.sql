@@ -0,0 +1,18 @@
+-- Each periodic job has one row recording when it is next due. An instance
+-- claims a due job by moving next_run_at forward before running it, so a job
+-- never runs twice for the same period, whether across instances or restarts
+CREATE TABLE IF NOT EXISTS scheduled_jobs (
+    name text PRIMARY KEY,
+    next_run_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    last_started_at timestamp(0) with time zone,
+    last_finished_at timestamp(0) with time zone,
+    last_error text NOT NULL DEFAULT ''
+);
+
+-- Feedback reminders sent for each enrollment, so an officer is reminded no
+-- more than the configured number of times
+CREATE TABLE IF NOT EXISTS feedback_reminders (
+    session_enrollment_id integer PRIMARY KEY REFERENCES session_enrollment(id) ON DELETE CASCADE,
+    reminders_sent integer NOT NULL DEFAULT 0,
+    last_sent_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,7 @@
+-- Authentication tokens are listed to their user as sessions, showing when and
+-- from where they were created and last used
+ALTER TABLE tokens
+    ADD COLUMN IF NOT EXISTS created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS last_used_at timestamp(0) with time zone,
+    ADD COLUMN IF NOT EXISTS client_ip text NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS user_agent text NOT NULL DEFAULT '';
@@ -0,0 +1,10 @@
+-- A login issues an access token and a refresh token in a new family. Every
+-- refresh uses up the refresh token and issues the next pair in the same
+-- family, so reusing a spent refresh token can revoke the whole family
+CREATE SEQUENCE IF NOT EXISTS token_families_seq;
+
+ALTER TABLE tokens
+    ADD COLUMN IF NOT EXISTS family_id bigint NOT NULL DEFAULT nextval('token_families_seq'),
+    ADD COLUMN IF NOT EXISTS used_at timestamp(0) with time zone;
+
+CREATE INDEX IF NOT EXISTS tokens_family_id_idx ON tokens(family_id);
@@ -0,0 +1,21 @@
+-- Consecutive failed logins lock an account for a time which doubles with each
+-- further failure, until a successful login or an administrator unlocks it
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS failed_login_attempts integer NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS locked_until timestamp(0) with time zone;
+
+-- Every login attempt is recorded, whether or not the email address belongs
+-- to an account
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id bigserial PRIMARY KEY,
+    user_id bigint REFERENCES users(id) ON DELETE SET NULL,
+    email text NOT NULL,
+    result text NOT NULL,
+    client_ip text NOT NULL DEFAULT '',
+    user_agent text NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    CONSTRAINT login_attempts_result_check CHECK (result IN ('success', 'unknown_email', 'invalid_password', 'locked_out', 'locked'))
+);
+
+CREATE INDEX IF NOT EXISTS login_attempts_created_at_idx ON login_attempts(created_at);
+CREATE INDEX IF NOT EXISTS login_attempts_user_id_idx ON login_attempts(user_id, created_at);
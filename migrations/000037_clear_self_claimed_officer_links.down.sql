@@ -0,0 +1,2 @@
+-- The links cleared by the up migration cannot be restored
+SELECT 1;
@@ -0,0 +1,4 @@
+-- Until now the officer an account belongs to was chosen by whoever registered
+-- it, so none of the links can be trusted. Administrators link accounts again
+-- with PUT /v1/officers/:id/user once they have checked who they belong to.
+UPDATE users SET personnel_id = NULL WHERE personnel_id IS NOT NULL;